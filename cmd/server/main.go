@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -15,6 +16,8 @@ import (
 	_ "github.com/lib/pq"
 
 	"agendaFacil/internal/handlers"
+	"agendaFacil/internal/mail"
+	"agendaFacil/internal/notificacion"
 )
 
 func main() {
@@ -59,59 +62,43 @@ func main() {
 
 	log.Println("Conectado a la DB correctamente")
 
+	// Claves de firma de los JWT
+	claves, err := handlers.CargarClavesJWT(os.Getenv)
+	switch {
+	case errors.Is(err, handlers.ErrSinClavesJWT) && os.Getenv("APP_ENV") == "dev":
+		log.Println("ADVERTENCIA: sin JWT_KEYS ni JWT_SECRET, se usa una clave al azar (APP_ENV=dev)")
+		handlers.ConfigurarClavesJWT(handlers.ClavesDesarrollo())
+	case errors.Is(err, handlers.ErrSinClavesJWT):
+		log.Fatal("Falta JWT_KEYS o JWT_SECRET (para desarrollo: APP_ENV=dev)")
+	case err != nil:
+		log.Fatal("Configuración de JWT inválida: ", err)
+	default:
+		handlers.ConfigurarClavesJWT(claves)
+	}
+
 	// Inicializar queries y handlers
 	queries := db.New(dbConn)
 
-	authHandler := handlers.NewAuthHandler(queries)
-	barberiaHandler := handlers.NewBarberiaHandler(queries)
-	serviciosHandler := handlers.NewServiciosHandler(queries)
-	barberosHandler := handlers.NewBarberosHandler(queries)
-
-	// Router
-	r := chi.NewRouter()
-
-	// --- RUTAS API ---
-	r.Post("/login", authHandler.Login) // <--- NUEVA RUTA
-	r.Get("/b/{slug}", barberiaHandler.GetBarberiaPublic)
-	r.Get("/b/{slug}/agenda", barberiaHandler.GetAgendaPublic)
-	r.Get("/b/{slug}/servicios", serviciosHandler.ListServiciosActivos)
-	r.Get("/b/{slug}/barberos", barberosHandler.ListBarberos)
-	r.Get("/b/{slug}/disponibilidad", barberiaHandler.GetDisponibilidad)
-	r.Post("/b/{slug}/reservar", barberiaHandler.PostReservar)
-
-	r.Group(func(r chi.Router) {
-		// Aquí usamos el middleware que acabamos de crear en auth.go
-		r.Use(handlers.AuthMiddleware)
-
-		// Rutas protegidas
-		r.Post("/b/{slug}/servicios", serviciosHandler.CreateServicio)
-		r.Post("/b/{slug}/barberos", barberosHandler.CreateBarbero)
-	})
-
-	// --- ARCHIVOS ESTÁTICOS (CORREGIDO PARA CHI) ---
-	workDir, _ := os.Getwd()
-	filesDir := http.Dir(filepath.Join(workDir, "web"))
-
-	// Servir archivos HTML específicos
-	r.Get("/handlers.html", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, filepath.Join(workDir, "web", "HANDLERS_VISUALIZER.html"))
-	})
-
-	// Admin dashboard demo
-	r.Get("/admin", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, filepath.Join(workDir, "web", "admin_dashboard.html"))
-	})
+	mailer, err := mail.DesdeEntorno(os.Getenv)
+	if err != nil {
+		log.Fatal("Configuración de mail inválida: ", err)
+	}
 
-	// AGREGA ESTA LÍNEA (Maneja la raíz "/")
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, filepath.Join(workDir, "web", "index.html"))
-	})
-	r.Get("/index.html", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, filepath.Join(workDir, "web", "index.html"))
-	})
+	authHandler := handlers.NewAuthHandler(queries, dbConn, mailer)
+	authHandler.URLBase = os.Getenv("APP_URL")
 
-	// FileServer para otros archivos estáticos
-	FileServer(r, "/", filesDir)
+	workDir, _ := os.Getwd()
+	r := nuevoRouter(handlersAPI{
+		auth:      authHandler,
+		barberia:  handlers.NewBarberiaHandler(queries, dbConn),
+		servicios: handlers.NewServiciosHandler(queries, dbConn),
+		barberos:  handlers.NewBarberosHandler(queries, dbConn, notificacion.LogNotificador{}),
+		horarios:  handlers.NewHorariosHandler(queries, dbConn),
+		bloqueos:  handlers.NewBloqueosHandler(queries),
+		turnos:    handlers.NewTurnosHandler(queries),
+		clientes:  handlers.NewClientesHandler(queries, dbConn),
+		resolver:  handlers.ResolverBarberia(queries),
+	}, filepath.Join(workDir, "web"))
 
 	// Puerto
 	port := os.Getenv("PORT")
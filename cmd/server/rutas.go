@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/go-chi/chi/v5"
+
+	"agendaFacil/internal/handlers"
+)
+
+// handlersAPI agrupa los handlers que atienden la API JSON
+type handlersAPI struct {
+	auth      *handlers.AuthHandler
+	barberia  *handlers.BarberiaHandler
+	servicios *handlers.ServiciosHandler
+	barberos  *handlers.BarberosHandler
+	horarios  *handlers.HorariosHandler
+	bloqueos  *handlers.BloqueosHandler
+	turnos    *handlers.TurnosHandler
+	clientes  *handlers.ClientesHandler
+
+	// resolver busca la barbería del slug para el TenantGuard
+	resolver handlers.BarberiaResolver
+}
+
+// nuevoRouter arma el router completo: la API bajo handlers.PrefijoAPI, los
+// alias deprecados sin prefijo y los archivos estáticos de webDir
+func nuevoRouter(h handlersAPI, webDir string) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(handlers.RequestID) // X-Request-Id en las respuestas y en los errores
+
+	// --- RUTAS API ---
+	r.Route(handlers.PrefijoAPI, func(r chi.Router) {
+		r.Get("/openapi.json", handlers.OpenAPI)
+		rutasAPI(r, h)
+	})
+
+	// Las mismas rutas sin prefijo, para las páginas de web/ que todavía las usan
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.Deprecada)
+		rutasAPI(r, h)
+	})
+
+	// --- ARCHIVOS ESTÁTICOS (CORREGIDO PARA CHI) ---
+	// Servir archivos HTML específicos
+	r.Get("/handlers.html", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(webDir, "HANDLERS_VISUALIZER.html"))
+	})
+
+	// Admin dashboard demo
+	r.Get("/admin", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(webDir, "admin_dashboard.html"))
+	})
+
+	// AGREGA ESTA LÍNEA (Maneja la raíz "/")
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(webDir, "index.html"))
+	})
+	r.Get("/index.html", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(webDir, "index.html"))
+	})
+
+	// FileServer para otros archivos estáticos
+	FileServer(r, "/", http.Dir(webDir))
+
+	return r
+}
+
+// rutasAPI registra los endpoints de la API en r. Cada ruta nueva tiene que
+// documentarse en internal/handlers/openapi.json.
+func rutasAPI(r chi.Router, h handlersAPI) {
+	r.Post("/login", h.auth.Login)
+	r.Post("/refresh", h.auth.Refresh)
+	r.Post("/logout", h.auth.Logout)
+	r.Post("/password/olvido", h.auth.OlvidoPassword)
+	r.Post("/password/reset", h.auth.ResetPassword)
+	r.Post("/barberias", h.auth.RegistrarBarberia) // alta de una barbería nueva con su admin
+	r.With(handlers.AuthMiddleware).Post("/password/cambiar", h.auth.CambiarPassword)
+	r.Get("/b/{slug}", h.barberia.GetBarberiaPublic)
+	r.Get("/b/{slug}/agenda", h.barberia.GetAgendaPublic)
+	r.Get("/b/{slug}/servicios", h.servicios.ListServiciosActivos)
+	r.Get("/b/{slug}/barberos", h.barberos.ListBarberos)
+	r.Get("/b/{slug}/disponibilidad", h.barberia.GetDisponibilidad)
+	r.Post("/b/{slug}/reservar", h.barberia.PostReservar)
+
+	// Autogestión del cliente con el token que recibe al reservar
+	r.Get("/b/{slug}/reservas/{token}", h.barberia.GetReserva)
+	r.Post("/b/{slug}/reservas/{token}/cancelar", h.barberia.CancelarReserva)
+	r.Post("/b/{slug}/reservas/{token}/reprogramar", h.barberia.ReprogramarReserva)
+
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.AuthMiddleware)
+		// Cada usuario sólo opera sobre su propia barbería
+		r.Use(handlers.TenantGuard(h.resolver))
+
+		// Rutas protegidas (ver la matriz de permisos en handlers/permisos.go)
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RequirePermiso(handlers.PermisoServicios))
+			r.Post("/b/{slug}/servicios", h.servicios.CreateServicio)
+			r.Put("/b/{slug}/servicios/orden", h.servicios.OrdenarServicios)
+			r.Put("/b/{slug}/servicios/{id}", h.servicios.UpdateServicio)
+			r.Patch("/b/{slug}/servicios/{id}", h.servicios.PatchServicio)
+			r.Delete("/b/{slug}/servicios/{id}", h.servicios.DeleteServicio)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RequirePermiso(handlers.PermisoStaff))
+			r.Post("/b/{slug}/barberos", h.barberos.CreateBarbero)
+			r.Put("/b/{slug}/barberos/{id}", h.barberos.UpdateBarbero)
+			r.Post("/b/{slug}/barberos/{id}/desactivar", h.barberos.DesactivarBarbero)
+			r.Post("/b/{slug}/barberos/{id}/reactivar", h.barberos.ReactivarBarbero)
+			r.Get("/b/{slug}/barberos/{id}/turnos-futuros", h.barberos.ListTurnosFuturos)
+			r.Get("/b/{slug}/barberos/{id}/servicios", h.barberos.GetBarberoServicios)
+			r.Put("/b/{slug}/barberos/{id}/servicios", h.barberos.PutBarberoServicios)
+			r.Post("/b/{slug}/turnos/{id}/reasignar", h.barberia.ReasignarTurno)
+			r.Post("/b/{slug}/usuarios/{id}/desbloquear", h.auth.DesbloquearUsuario)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RequirePermiso(handlers.PermisoConfiguracion))
+			r.Patch("/b/{slug}/configuracion", h.barberia.UpdateConfiguracion)
+			r.Post("/b/{slug}", h.barberia.UpdateConfiguracion) // lo que usa el panel de admin; deprecada en la spec
+			r.Put("/b/{slug}/horarios", h.horarios.PutHorariosBarberia)
+		})
+
+		// Agenda: el barbero sólo la propia, el admin la de todos
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RequirePermiso(handlers.PermisoAgenda))
+
+			r.Get("/b/{slug}/horarios", h.horarios.GetHorariosBarberia)
+			r.With(handlers.SoloPropioBarbero).Get("/b/{slug}/barberos/{id}/horarios", h.horarios.GetHorariosBarbero)
+			r.With(handlers.SoloPropioBarbero).Put("/b/{slug}/barberos/{id}/horarios", h.horarios.PutHorariosBarbero)
+			r.With(handlers.SoloPropioBarbero).Delete("/b/{slug}/barberos/{id}/horarios", h.horarios.DeleteHorariosBarbero)
+
+			r.Get("/b/{slug}/bloqueos", h.bloqueos.ListBloqueos)
+			r.Post("/b/{slug}/bloqueos", h.bloqueos.CreateBloqueo)
+			r.Put("/b/{slug}/bloqueos/{id}", h.bloqueos.UpdateBloqueo)
+			r.Delete("/b/{slug}/bloqueos/{id}", h.bloqueos.DeleteBloqueo)
+
+			// Ciclo de vida de los turnos
+			r.Get("/b/{slug}/turnos", h.turnos.ListTurnos)
+			r.Post("/b/{slug}/turnos/{id}/confirmar", h.turnos.Confirmar)
+			r.Post("/b/{slug}/turnos/{id}/cancelar", h.turnos.Cancelar)
+			r.Post("/b/{slug}/turnos/{id}/completar", h.turnos.Completar)
+			r.Post("/b/{slug}/turnos/{id}/no-asistio", h.turnos.NoAsistio)
+		})
+
+		// Clientes: los ven todos; fusionar duplicados es del admin
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RequirePermiso(handlers.PermisoClientes))
+			r.Get("/b/{slug}/clientes", h.clientes.ListClientes)
+			r.Get("/b/{slug}/clientes/{id}", h.clientes.GetCliente)
+			r.Get("/b/{slug}/clientes/{id}/turnos", h.clientes.ListTurnosCliente)
+			r.With(handlers.RequireRole(handlers.RolAdmin)).Post("/b/{slug}/clientes/{id}/fusionar", h.clientes.FusionarClientes)
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"agendaFacil/internal/handlers"
+)
+
+// Los handlers quedan en nil: las rutas se registran pero no se llaman
+func routerTest(t *testing.T) *chi.Mux {
+	return nuevoRouter(handlersAPI{}, t.TempDir())
+}
+
+// especificacion baja el documento OpenAPI como lo haría un cliente
+func especificacion(t *testing.T, r http.Handler) map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, handlers.PrefijoAPI+"/openapi.json", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Se esperaba 200 con JSON, se obtuvo %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var spec map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("openapi.json inválido: %v", err)
+	}
+	return spec
+}
+
+// rutas devuelve "MÉTODO /ruta" de cada ruta del router, separando las de la
+// API versionada (sin el prefijo) de las que están en la raíz
+func rutas(t *testing.T, r chi.Routes) (v1, raiz map[string]bool) {
+	t.Helper()
+	v1, raiz = map[string]bool{}, map[string]bool{}
+	chi.Walk(r, func(metodo, ruta string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if sinPrefijo, ok := strings.CutPrefix(ruta, handlers.PrefijoAPI+"/"); ok {
+			v1[metodo+" /"+sinPrefijo] = true
+		} else {
+			raiz[metodo+" "+ruta] = true
+		}
+		return nil
+	})
+	return v1, raiz
+}
+
+// TestOpenAPI_CubreRutas tests que la spec describa exactamente las rutas de la API
+func TestOpenAPI_CubreRutas(t *testing.T) {
+	r := routerTest(t)
+	spec := especificacion(t, r)
+	v1, _ := rutas(t, r)
+	delete(v1, "GET /openapi.json")
+
+	documentadas := map[string]bool{}
+	paths, _ := spec["paths"].(map[string]any)
+	for ruta, item := range paths {
+		for metodo := range item.(map[string]any) {
+			documentadas[strings.ToUpper(metodo)+" "+ruta] = true
+		}
+	}
+
+	for ruta := range v1 {
+		if !documentadas[ruta] {
+			t.Errorf("Ruta sin documentar en openapi.json: %s", ruta)
+		}
+	}
+	for ruta := range documentadas {
+		if !v1[ruta] {
+			t.Errorf("openapi.json describe una ruta que no existe: %s", ruta)
+		}
+	}
+}
+
+var referencia = regexp.MustCompile(`"\$ref":\s*"#/components/(\w+)/(\w+)"`)
+
+// TestOpenAPI_Referencias tests que cada $ref apunte a un componente definido
+func TestOpenAPI_Referencias(t *testing.T) {
+	spec := especificacion(t, routerTest(t))
+	componentes, _ := spec["components"].(map[string]any)
+
+	b, _ := json.Marshal(spec)
+	for _, m := range referencia.FindAllStringSubmatch(string(b), -1) {
+		seccion, _ := componentes[m[1]].(map[string]any)
+		if _, ok := seccion[m[2]]; !ok {
+			t.Errorf("Referencia rota: #/components/%s/%s", m[1], m[2])
+		}
+	}
+}
+
+// TestRutasDeprecadas tests que las rutas viejas sigan respondiendo, marcadas
+func TestRutasDeprecadas(t *testing.T) {
+	r := routerTest(t)
+	v1, raiz := rutas(t, r)
+	delete(v1, "GET /openapi.json")
+	for ruta := range v1 {
+		if !raiz[ruta] {
+			t.Errorf("Falta el alias sin prefijo de %s", ruta)
+		}
+	}
+
+	// Sin token las protegidas cortan en el AuthMiddleware, antes del handler
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/b/centro/servicios", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("Deprecation") != "true" {
+		t.Errorf("Ruta vieja: se esperaba 401 con Deprecation, se obtuvo %d %q", rec.Code, rec.Header().Get("Deprecation"))
+	}
+	if link := rec.Header().Get("Link"); link != `<`+handlers.PrefijoAPI+`/b/centro/servicios>; rel="successor-version"` {
+		t.Errorf("Link inesperado: %q", link)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, handlers.PrefijoAPI+"/b/centro/servicios", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("Deprecation") != "" {
+		t.Errorf("Ruta v1: se esperaba 401 sin Deprecation, se obtuvo %d %q", rec.Code, rec.Header().Get("Deprecation"))
+	}
+
+	// Los archivos estáticos no son API: no se marcan
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/estilos.css", nil))
+	if rec.Header().Get("Deprecation") != "" {
+		t.Error("Los archivos estáticos no deberían marcarse como deprecados")
+	}
+}
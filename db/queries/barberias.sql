@@ -1,6 +1,6 @@
 -- name: CreateBarberia :one
-INSERT INTO barberias (nombre, slug, hora_apertura, hora_cierre)
-VALUES ($1, $2, $3, $4)
+INSERT INTO barberias (nombre, slug, hora_apertura, hora_cierre, zona_horaria, pais)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetBarberiaBySlug :one
@@ -9,6 +9,19 @@ FROM barberias
 WHERE slug = $1
   AND activa = true;
 
+-- name: GetBarberiaBySlugAdmin :one
+-- Incluye las inactivas: el admin tiene que poder reactivar su barbería.
+SELECT *
+FROM barberias
+WHERE slug = $1;
+
+-- name: ExisteSlug :one
+SELECT EXISTS (
+  SELECT 1
+  FROM barberias
+  WHERE slug = $1
+);
+
 -- name: ListBarberosByBarberia :many
 SELECT id, nombre
 FROM usuarios
@@ -16,3 +29,23 @@ WHERE barberia_id = $1
   AND rol = 'barbero'
   AND activo = true
 ORDER BY nombre;
+
+-- name: UpdateBarberiaConfiguracion :one
+-- Los campos en NULL conservan su valor actual.
+UPDATE barberias
+SET intervalo_minutos = COALESCE(sqlc.narg('intervalo_minutos'), intervalo_minutos),
+    buffer_antes_minutos = COALESCE(sqlc.narg('buffer_antes_minutos'), buffer_antes_minutos),
+    buffer_despues_minutos = COALESCE(sqlc.narg('buffer_despues_minutos'), buffer_despues_minutos),
+    aviso_cambio_minutos = COALESCE(sqlc.narg('aviso_cambio_minutos'), aviso_cambio_minutos),
+    nombre = COALESCE(sqlc.narg('nombre'), nombre),
+    slug = COALESCE(sqlc.narg('slug'), slug),
+    hora_apertura = COALESCE(sqlc.narg('hora_apertura'), hora_apertura),
+    hora_cierre = COALESCE(sqlc.narg('hora_cierre'), hora_cierre),
+    activa = COALESCE(sqlc.narg('activa'), activa),
+    zona_horaria = COALESCE(sqlc.narg('zona_horaria'), zona_horaria),
+    anticipacion_minima_minutos = COALESCE(sqlc.narg('anticipacion_minima_minutos'), anticipacion_minima_minutos),
+    anticipacion_maxima_dias = COALESCE(sqlc.narg('anticipacion_maxima_dias'), anticipacion_maxima_dias),
+    max_turnos_activos_cliente = COALESCE(sqlc.narg('max_turnos_activos_cliente'), max_turnos_activos_cliente),
+    pais = COALESCE(sqlc.narg('pais'), pais)
+WHERE id = sqlc.arg('id')
+RETURNING *;
@@ -0,0 +1,22 @@
+-- name: CreateBarberoServicio :one
+INSERT INTO barbero_servicios (
+  barbero_id, servicio_id, duracion_minutos, precio
+)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeleteBarberoServicios :exec
+DELETE FROM barbero_servicios
+WHERE barbero_id = $1;
+
+-- name: ListBarberoServiciosByBarbero :many
+SELECT *
+FROM barbero_servicios
+WHERE barbero_id = $1
+ORDER BY servicio_id;
+
+-- name: ListBarberoServiciosByServicio :many
+SELECT *
+FROM barbero_servicios
+WHERE servicio_id = $1
+ORDER BY barbero_id;
@@ -0,0 +1,48 @@
+-- name: CreateBloqueo :one
+INSERT INTO bloqueos (
+  barberia_id, barbero_id, fecha_desde, fecha_hasta,
+  hora_inicio, hora_fin, motivo, recurrente_anual
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetBloqueo :one
+SELECT *
+FROM bloqueos
+WHERE id = $1
+  AND barberia_id = $2;
+
+-- name: ListBloqueos :many
+SELECT *
+FROM bloqueos
+WHERE barberia_id = $1
+ORDER BY fecha_desde, hora_inicio NULLS FIRST;
+
+-- name: ListBloqueosByFecha :many
+-- Candidatos para una fecha: los recurrentes se filtran en Go por día y mes.
+SELECT *
+FROM bloqueos
+WHERE barberia_id = $1
+  AND (
+    recurrente_anual
+    OR (fecha_desde <= sqlc.arg('fecha') AND fecha_hasta >= sqlc.arg('fecha'))
+  )
+ORDER BY hora_inicio NULLS FIRST;
+
+-- name: UpdateBloqueo :one
+UPDATE bloqueos
+SET barbero_id = $3,
+    fecha_desde = $4,
+    fecha_hasta = $5,
+    hora_inicio = $6,
+    hora_fin = $7,
+    motivo = $8,
+    recurrente_anual = $9
+WHERE id = $1
+  AND barberia_id = $2
+RETURNING *;
+
+-- name: DeleteBloqueo :execrows
+DELETE FROM bloqueos
+WHERE id = $1
+  AND barberia_id = $2;
@@ -0,0 +1,87 @@
+-- name: CreateCliente :one
+-- Si otra transacción ya creó un cliente con ese teléfono o email no inserta
+-- nada y no devuelve filas (no aborta la transacción como un 23505).
+INSERT INTO clientes (barberia_id, nombre, telefono, email)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetCliente :one
+SELECT *
+FROM clientes
+WHERE id = $1
+  AND barberia_id = $2;
+
+-- name: GetClienteByTelefono :one
+SELECT *
+FROM clientes
+WHERE barberia_id = $1
+  AND telefono = $2;
+
+-- name: GetClienteByEmail :one
+SELECT *
+FROM clientes
+WHERE barberia_id = $1
+  AND email = $2;
+
+-- name: CompletarContactoCliente :one
+-- Sólo completa el teléfono o el email si el cliente no tenía.
+UPDATE clientes
+SET telefono = COALESCE(telefono, sqlc.narg('telefono')),
+    email = COALESCE(email, sqlc.narg('email'))
+WHERE id = sqlc.arg('id')
+  AND barberia_id = sqlc.arg('barberia_id')
+RETURNING *;
+
+-- name: SearchClientes :many
+-- Busca por nombre, email o teléfono; con q vacío lista todos. telefono son
+-- sólo los dígitos de q (vacío si no tiene), para encontrar el teléfono
+-- normalizado aunque se busque con espacios o guiones.
+SELECT *
+FROM clientes
+WHERE barberia_id = sqlc.arg('barberia_id')
+  AND (
+    sqlc.arg('q')::text = ''
+    OR nombre ILIKE '%' || sqlc.arg('q') || '%'
+    OR email ILIKE '%' || sqlc.arg('q') || '%'
+    OR (sqlc.arg('telefono')::text != '' AND telefono LIKE '%' || sqlc.arg('telefono') || '%')
+  )
+ORDER BY nombre, id
+LIMIT sqlc.arg('limite');
+
+-- name: GetClienteEstadisticas :one
+-- El gasto suma sólo los turnos completados, al precio del momento de la
+-- reserva (o el actual del servicio para turnos anteriores a guardarlo).
+SELECT COUNT(*) AS total_turnos,
+       COUNT(*) FILTER (WHERE t.estado = 'completado') AS completados,
+       COUNT(*) FILTER (WHERE t.estado = 'no_asistio') AS no_asistio,
+       COUNT(*) FILTER (WHERE t.estado = 'cancelado') AS cancelados,
+       COALESCE(SUM(COALESCE(t.precio, s.precio)) FILTER (WHERE t.estado = 'completado'), 0)::text AS gasto_total
+FROM turnos t
+JOIN servicios s ON s.id = t.servicio_id
+WHERE t.barberia_id = $1
+  AND t.cliente_id = $2;
+
+-- name: ListTurnosCliente :many
+SELECT t.id, t.fecha, t.hora_inicio, t.hora_fin, t.estado,
+       t.servicio_id, s.nombre AS servicio_nombre,
+       t.barbero_id, u.nombre AS barbero_nombre,
+       COALESCE(t.precio, s.precio)::text AS precio
+FROM turnos t
+JOIN servicios s ON s.id = t.servicio_id
+JOIN usuarios u ON u.id = t.barbero_id
+WHERE t.barberia_id = $1
+  AND t.cliente_id = $2
+ORDER BY t.fecha DESC, t.hora_inicio DESC;
+
+-- name: MoverTurnosCliente :execrows
+-- Pasa los turnos de un cliente duplicado al que queda.
+UPDATE turnos
+SET cliente_id = sqlc.arg('cliente_id')
+WHERE barberia_id = sqlc.arg('barberia_id')
+  AND cliente_id = sqlc.arg('duplicado_id');
+
+-- name: DeleteCliente :exec
+DELETE FROM clientes
+WHERE id = $1
+  AND barberia_id = $2;
@@ -0,0 +1,22 @@
+-- name: ListHorarios :many
+SELECT *
+FROM horarios
+WHERE barberia_id = $1
+ORDER BY barbero_id NULLS FIRST, dia_semana, hora_inicio;
+
+-- name: CreateHorario :one
+INSERT INTO horarios (
+  barberia_id, barbero_id, dia_semana, hora_inicio, hora_fin
+)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: DeleteHorariosBarberia :exec
+DELETE FROM horarios
+WHERE barberia_id = $1
+  AND barbero_id IS NULL;
+
+-- name: DeleteHorariosBarbero :exec
+DELETE FROM horarios
+WHERE barberia_id = $1
+  AND barbero_id = $2;
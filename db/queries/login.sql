@@ -0,0 +1,38 @@
+-- name: CreateLoginFallido :exec
+INSERT INTO login_fallidos (username, ip, motivo)
+VALUES ($1, $2, $3);
+
+-- name: GetLoginLimite :one
+SELECT *
+FROM login_limites
+WHERE clave = $1;
+
+-- name: ReservarIntentoLogin :one
+-- Suma el intento antes de verificarlo; si el último fue hace más de un día,
+-- el contador arranca de nuevo. La fila queda bloqueada hasta el commit.
+INSERT INTO login_limites (clave, fallos, actualizado_en)
+VALUES ($1, 1, now())
+ON CONFLICT (clave) DO UPDATE
+SET fallos = CASE
+      WHEN login_limites.actualizado_en < now() - interval '1 day' THEN 1
+      ELSE login_limites.fallos + 1
+    END,
+    actualizado_en = now()
+RETURNING *;
+
+-- name: SetLoginBloqueadoHasta :exec
+UPDATE login_limites
+SET bloqueado_hasta = $2
+WHERE clave = $1;
+
+-- name: LiberarIntentoLogin :exec
+-- Devuelve un intento reservado que salió bien. La demora sólo se borra si
+-- sigue siendo la que dejó esa reserva (no la de un fallo posterior).
+UPDATE login_limites
+SET fallos = GREATEST(fallos - 1, 0),
+    bloqueado_hasta = CASE WHEN bloqueado_hasta = $2 THEN NULL ELSE bloqueado_hasta END
+WHERE clave = $1;
+
+-- name: DeleteLoginLimite :exec
+DELETE FROM login_limites
+WHERE clave = $1;
@@ -0,0 +1,23 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (usuario_id, token_hash, expira_en)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPasswordReset :one
+SELECT *
+FROM password_resets
+WHERE token_hash = $1;
+
+-- name: UsePasswordReset :execrows
+-- Marca el token como usado sólo si no lo estaba (un solo uso).
+UPDATE password_resets
+SET usado_en = now()
+WHERE id = $1
+  AND usado_en IS NULL;
+
+-- name: InvalidatePasswordResets :exec
+-- Al pedir un código nuevo, los anteriores sin usar dejan de servir.
+UPDATE password_resets
+SET usado_en = now()
+WHERE usuario_id = $1
+  AND usado_en IS NULL;
@@ -0,0 +1,22 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (usuario_id, token_hash, expira_en)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetRefreshToken :one
+SELECT *
+FROM refresh_tokens
+WHERE token_hash = $1;
+
+-- name: RevokeRefreshToken :execrows
+-- Sólo revoca si seguía vigente: 0 filas significa que ya se había usado.
+UPDATE refresh_tokens
+SET revocado_en = now()
+WHERE id = $1
+  AND revocado_en IS NULL;
+
+-- name: RevokeRefreshTokensUsuario :exec
+UPDATE refresh_tokens
+SET revocado_en = now()
+WHERE usuario_id = $1
+  AND revocado_en IS NULL;
@@ -1,8 +1,13 @@
 -- name: CreateServicio :one
+-- Los servicios nuevos van al final del listado.
 INSERT INTO servicios (
-  barberia_id, nombre, duracion_minutos, precio
+  barberia_id, nombre, duracion_minutos, precio,
+  buffer_antes_minutos, buffer_despues_minutos, orden
+)
+VALUES (
+  $1, $2, $3, $4, $5, $6,
+  (SELECT COALESCE(MAX(s.orden) + 1, 0) FROM servicios s WHERE s.barberia_id = $1)
 )
-VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: ListServicios :many
@@ -10,9 +15,9 @@ SELECT *
 FROM servicios
 WHERE barberia_id = $1
   AND activo = true
-ORDER BY nombre;
+ORDER BY orden, nombre;
 
--- name: DeactivateServicio :exec
+-- name: DeactivateServicio :execrows
 UPDATE servicios
 SET activo = false
 WHERE id = $1
@@ -23,10 +28,45 @@ SELECT id, nombre, duracion_minutos, precio
 FROM servicios
 WHERE barberia_id = $1
   AND activo = true
-ORDER BY nombre;
+ORDER BY orden, nombre;
 
 -- name: GetServicioByID :one
 SELECT *
 FROM servicios
 WHERE id = $1
   AND activo = true;
+
+-- name: GetServicioDeBarberia :one
+-- Incluye los inactivos: el admin puede editarlos y reactivarlos.
+SELECT *
+FROM servicios
+WHERE id = $1
+  AND barberia_id = $2;
+
+-- name: RestringirServicio :exec
+-- La primera asignación del servicio lo restringe a los asignados; después
+-- sólo lo cambia el admin.
+UPDATE servicios
+SET restringido = true
+WHERE id = $1
+  AND NOT EXISTS (SELECT 1 FROM barbero_servicios WHERE servicio_id = $1);
+
+-- name: UpdateServicio :one
+UPDATE servicios
+SET nombre = $3,
+    duracion_minutos = $4,
+    precio = $5,
+    buffer_antes_minutos = $6,
+    buffer_despues_minutos = $7,
+    orden = $8,
+    activo = $9,
+    restringido = $10
+WHERE id = $1
+  AND barberia_id = $2
+RETURNING *;
+
+-- name: UpdateServicioOrden :execrows
+UPDATE servicios
+SET orden = $3
+WHERE id = $1
+  AND barberia_id = $2;
@@ -8,22 +8,29 @@ INSERT INTO turnos (
   hora_fin,
   cliente_nombre,
   cliente_telefono,
-  estado
+  estado,
+  ocupado_inicio,
+  ocupado_fin,
+  token_hash,
+  cliente_id,
+  precio
 )
 VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 )
 RETURNING *;
 
 -- name: ListTurnosByFecha :many
+-- Agenda del día para el staff; barbero_id NULL trae la de todos.
 SELECT t.*, s.nombre AS servicio_nombre, u.nombre AS barbero_nombre
 FROM turnos t
 JOIN servicios s ON s.id = t.servicio_id
 JOIN usuarios u ON u.id = t.barbero_id
-WHERE t.barberia_id = $1
-  AND t.fecha = $2
+WHERE t.barberia_id = sqlc.arg('barberia_id')
+  AND t.fecha = sqlc.arg('fecha')
+  AND (sqlc.narg('barbero_id')::int IS NULL OR t.barbero_id = sqlc.narg('barbero_id'))
   AND t.estado != 'cancelado'
-ORDER BY t.hora_inicio;
+ORDER BY t.hora_inicio, t.barbero_id;
 
 -- name: ListTurnosByFechaAndBarbero :many
 SELECT t.*, s.nombre AS servicio_nombre
@@ -44,24 +51,92 @@ SELECT EXISTS (
     AND fecha = $3
     AND estado != 'cancelado'
     AND (
-      -- Lógica correcta de intersección:
+      -- Lógica correcta de intersección (con buffers incluidos):
       -- (Inicio_Existente < Fin_Nuevo) Y (Fin_Existente > Inicio_Nuevo)
-      hora_inicio < sqlc.arg('hora_fin') 
-      AND hora_fin > sqlc.arg('hora_inicio')
+      ocupado_inicio < sqlc.arg('ocupado_fin')
+      AND ocupado_fin > sqlc.arg('ocupado_inicio')
     )
+    -- Al reprogramar, el propio turno no cuenta (0 = turno nuevo)
+    AND id != sqlc.arg('excluir_id')
 );
 
--- name: CancelTurno :exec
-UPDATE turnos
-SET estado = 'cancelado'
-WHERE id = $1
-  AND barberia_id = $2;
-
-
 -- name: ListTurnosOcupados :many
-SELECT barbero_id, hora_inicio, hora_fin
+-- Devuelve el tiempo ocupado (buffers incluidos) con los nombres hora_inicio/hora_fin.
+SELECT barbero_id, ocupado_inicio AS hora_inicio, ocupado_fin AS hora_fin
 FROM turnos
 WHERE barberia_id = $1
   AND fecha = $2
   AND estado != 'cancelado'
 ORDER BY hora_inicio;
+
+-- name: GetTurnoByID :one
+SELECT *
+FROM turnos
+WHERE id = $1
+  AND barberia_id = $2;
+
+-- name: UpdateTurnoEstado :one
+-- Sólo aplica si el turno sigue en estado_actual, así dos transiciones
+-- simultáneas no se pisan. Un estado NULL cuenta como pendiente.
+-- Registra el momento de la transición.
+UPDATE turnos
+SET estado = sqlc.arg('estado_nuevo'),
+    confirmado_en = CASE WHEN sqlc.arg('estado_nuevo') = 'confirmado' THEN now() ELSE confirmado_en END,
+    completado_en = CASE WHEN sqlc.arg('estado_nuevo') = 'completado' THEN now() ELSE completado_en END,
+    no_asistio_en = CASE WHEN sqlc.arg('estado_nuevo') = 'no_asistio' THEN now() ELSE no_asistio_en END,
+    cancelado_en = CASE WHEN sqlc.arg('estado_nuevo') = 'cancelado' THEN now() ELSE cancelado_en END
+WHERE id = sqlc.arg('id')
+  AND barberia_id = sqlc.arg('barberia_id')
+  AND COALESCE(estado, 'pendiente') = sqlc.arg('estado_actual')::text
+RETURNING *;
+
+-- name: GetTurnoByToken :one
+SELECT *
+FROM turnos
+WHERE barberia_id = $1
+  AND token_hash = $2;
+
+-- name: UpdateTurnoHorario :one
+-- Mueve un turno activo a otro horario (y eventualmente otro barbero). La
+-- duración y el precio son los del barbero que lo va a atender.
+UPDATE turnos
+SET barbero_id = sqlc.arg('barbero_id'),
+    fecha = sqlc.arg('fecha'),
+    hora_inicio = sqlc.arg('hora_inicio'),
+    hora_fin = sqlc.arg('hora_fin'),
+    ocupado_inicio = sqlc.arg('ocupado_inicio'),
+    ocupado_fin = sqlc.arg('ocupado_fin'),
+    precio = sqlc.arg('precio')
+WHERE id = sqlc.arg('id')
+  AND barberia_id = sqlc.arg('barberia_id')
+  AND estado IN ('pendiente', 'confirmado')
+RETURNING *;
+
+-- name: ListTurnosFuturosBarbero :many
+-- Turnos todavía activos del barbero a partir del momento indicado.
+SELECT *
+FROM turnos
+WHERE barberia_id = $1
+  AND barbero_id = $2
+  AND estado IN ('pendiente', 'confirmado')
+  AND (
+    fecha > sqlc.arg('desde_fecha')
+    OR (fecha = sqlc.arg('desde_fecha') AND hora_inicio >= sqlc.arg('desde_hora'))
+  )
+ORDER BY fecha, hora_inicio;
+
+-- name: CountTurnosActivosCliente :one
+-- Turnos todavía activos del cliente (por teléfono) a partir del momento indicado.
+SELECT COUNT(*)
+FROM turnos
+WHERE barberia_id = $1
+  AND cliente_telefono = $2
+  AND estado IN ('pendiente', 'confirmado')
+  AND (
+    fecha > sqlc.arg('desde_fecha')
+    OR (fecha = sqlc.arg('desde_fecha') AND hora_inicio >= sqlc.arg('desde_hora'))
+  );
+
+-- name: LockTurnosCliente :exec
+-- Serializa hasta el commit las reservas del mismo cliente (por teléfono).
+SELECT pg_advisory_xact_lock(sqlc.arg('barberia_id')::int, hashtext(sqlc.arg('telefono')::text));
@@ -11,6 +11,12 @@ FROM usuarios
 WHERE email = $1
   AND activo = true;
 
+-- name: GetUsuarioByID :one
+SELECT *
+FROM usuarios
+WHERE id = $1
+  AND activo = true;
+
 -- name: ListBarberos :many
 SELECT id, nombre, apellido
 FROM usuarios
@@ -23,4 +29,61 @@ ORDER BY nombre;
 SELECT *
 FROM usuarios
 WHERE username = $1
-  AND activo = true;
\ No newline at end of file
+  AND activo = true;
+-- name: LockBarbero :one
+SELECT id
+FROM usuarios
+WHERE id = $1
+  AND barberia_id = $2
+  AND rol = 'barbero'
+  AND activo = true
+FOR UPDATE;
+
+-- name: ListBarberosPorCarga :many
+-- Barberos activos ordenados por carga del día y, a igual carga, por quién
+-- recibió un turno hace más tiempo (round-robin).
+SELECT u.id, u.nombre, COUNT(t.id) AS turnos_del_dia
+FROM usuarios u
+LEFT JOIN turnos t
+  ON t.barbero_id = u.id
+  AND t.fecha = $2
+  AND t.estado != 'cancelado'
+WHERE u.barberia_id = $1
+  AND u.rol = 'barbero'
+  AND u.activo = true
+GROUP BY u.id, u.nombre
+ORDER BY turnos_del_dia,
+  (SELECT MAX(creado_en) FROM turnos WHERE barbero_id = u.id) NULLS FIRST,
+  u.id;
+
+-- name: UpdateUsuarioPassword :exec
+UPDATE usuarios
+SET password_hash = $2
+WHERE id = $1;
+
+-- name: GetBarbero :one
+-- Incluye los inactivos, para poder editarlos y reactivarlos.
+SELECT *
+FROM usuarios
+WHERE id = $1
+  AND barberia_id = $2
+  AND rol = 'barbero';
+
+-- name: UpdateBarbero :one
+UPDATE usuarios
+SET nombre = $3,
+    apellido = $4,
+    username = $5,
+    email = $6
+WHERE id = $1
+  AND barberia_id = $2
+  AND rol = 'barbero'
+RETURNING *;
+
+-- name: SetBarberoActivo :one
+UPDATE usuarios
+SET activo = $3
+WHERE id = $1
+  AND barberia_id = $2
+  AND rol = 'barbero'
+RETURNING *;
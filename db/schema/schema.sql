@@ -1,3 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
 
 CREATE TABLE barberias (
     id SERIAL PRIMARY KEY,
@@ -5,7 +7,16 @@ CREATE TABLE barberias (
     slug VARCHAR(50) UNIQUE NOT NULL,
     hora_apertura TIME NOT NULL,
     hora_cierre TIME NOT NULL,
-    activa BOOLEAN DEFAULT true
+    activa BOOLEAN DEFAULT true,
+    intervalo_minutos INT NOT NULL DEFAULT 15, -- cada cuánto se ofrece un inicio de turno
+    buffer_antes_minutos INT NOT NULL DEFAULT 0,   -- default para servicios sin buffer propio
+    buffer_despues_minutos INT NOT NULL DEFAULT 0,
+    aviso_cambio_minutos INT NOT NULL DEFAULT 120, -- anticipación mínima para que el cliente cancele o reprograme
+    zona_horaria VARCHAR(64) NOT NULL DEFAULT 'America/Argentina/Buenos_Aires', -- IANA; fechas y horas de turnos son hora local de la barbería
+    anticipacion_minima_minutos INT NOT NULL DEFAULT 0, -- margen mínimo entre la reserva y el turno
+    anticipacion_maxima_dias INT NOT NULL DEFAULT 0,    -- hasta cuántos días adelante se reserva (0 = sin límite)
+    max_turnos_activos_cliente INT NOT NULL DEFAULT 0,  -- turnos futuros por teléfono (0 = sin límite)
+    pais VARCHAR(2) NOT NULL DEFAULT 'AR' -- ISO 3166-1; país de los teléfonos sin código internacional
 );
 
 CREATE TABLE usuarios (
@@ -30,7 +41,42 @@ CREATE TABLE servicios (
     duracion_minutos INT NOT NULL,
     precio DECIMAL(10,2) NOT NULL,
     activo BOOLEAN DEFAULT true,
+    buffer_antes_minutos INT,   -- NULL = usar el default de la barbería
+    buffer_despues_minutos INT,
+    orden INT NOT NULL DEFAULT 0, -- posición en el listado público
+    restringido BOOLEAN NOT NULL DEFAULT false, -- true = sólo los barberos asignados
+
+    FOREIGN KEY (barberia_id) REFERENCES barberias(id)
+);
+
+-- Qué servicios hace cada barbero, con duración y precio propios. Un servicio
+-- sin restringir lo hacen todos; al asignarlo a alguien queda restringido a
+-- los barberos con fila, aunque después se borren todas o sólo queden
+-- barberos dados de baja. Vuelve a ser de todos sólo si el admin lo abre.
+CREATE TABLE barbero_servicios (
+    barbero_id INT NOT NULL,
+    servicio_id INT NOT NULL,
+    duracion_minutos INT,  -- NULL = la duración del servicio
+    precio DECIMAL(10,2),  -- NULL = el precio del servicio
+
+    PRIMARY KEY (barbero_id, servicio_id),
+    FOREIGN KEY (barbero_id) REFERENCES usuarios(id),
+    FOREIGN KEY (servicio_id) REFERENCES servicios(id)
+);
+
+-- Clientes de cada barbería. Se reconocen por teléfono o email normalizados,
+-- así que ninguno de los dos se repite dentro de la barbería.
+CREATE TABLE clientes (
+    id SERIAL PRIMARY KEY,
+    barberia_id INT NOT NULL,
+    nombre VARCHAR(100) NOT NULL,
+    telefono VARCHAR(20),
+    email VARCHAR(100),
+    creado_en TIMESTAMP NOT NULL DEFAULT now(),
 
+    UNIQUE (barberia_id, telefono),
+    UNIQUE (barberia_id, email),
+    CHECK (telefono IS NOT NULL OR email IS NOT NULL),
     FOREIGN KEY (barberia_id) REFERENCES barberias(id)
 );
 
@@ -47,12 +93,124 @@ CREATE TABLE turnos (
     cliente_nombre VARCHAR(100) NOT NULL,
     cliente_telefono VARCHAR(20),
 
-    estado VARCHAR(20) DEFAULT 'pendiente', -- pendiente | confirmado | cancelado
+    estado VARCHAR(20) DEFAULT 'pendiente', -- pendiente | confirmado | completado | no_asistio | cancelado
     creado_en TIMESTAMP DEFAULT now(),
 
+    -- Tiempo que el barbero queda tomado: el turno más los buffers de
+    -- preparación/limpieza. hora_inicio/hora_fin siguen siendo lo que ve el cliente.
+    ocupado_inicio TIME NOT NULL,
+    ocupado_fin TIME NOT NULL,
+
+    -- Momento de cada transición de estado
+    confirmado_en TIMESTAMP,
+    completado_en TIMESTAMP,
+    no_asistio_en TIMESTAMP,
+    cancelado_en TIMESTAMP,
+
+    -- SHA-256 del token que recibe el cliente para gestionar su turno.
+    -- Nunca se guarda el token en claro.
+    token_hash VARCHAR(64) UNIQUE,
+
+    -- Cliente reconocido al reservar (NULL si no dejó teléfono ni email) y
+    -- precio del servicio con ese barbero en ese momento
+    cliente_id INT,
+    precio DECIMAL(10,2),
+
+    CHECK (estado IN ('pendiente', 'confirmado', 'completado', 'no_asistio', 'cancelado')),
+
     FOREIGN KEY (barberia_id) REFERENCES barberias(id),
     FOREIGN KEY (barbero_id) REFERENCES usuarios(id),
-    FOREIGN KEY (servicio_id) REFERENCES servicios(id)
+    FOREIGN KEY (servicio_id) REFERENCES servicios(id),
+    FOREIGN KEY (cliente_id) REFERENCES clientes(id),
+
+    -- Red de seguridad: un barbero no puede tener dos turnos activos que se pisen
+    CONSTRAINT turnos_sin_superposicion EXCLUDE USING gist (
+        barbero_id WITH =,
+        tsrange(fecha + ocupado_inicio, fecha + ocupado_fin) WITH &&
+    ) WHERE (estado != 'cancelado')
+);
+
+-- Horario semanal. Con barbero_id NULL es el horario general de la barbería;
+-- con barbero_id reemplaza por completo al general para ese barbero.
+-- Puede haber varios rangos por día (ej. corte al mediodía).
+CREATE TABLE horarios (
+    id SERIAL PRIMARY KEY,
+    barberia_id INT NOT NULL,
+    barbero_id INT,
+    dia_semana INT NOT NULL, -- 0 = domingo ... 6 = sábado
+    hora_inicio TIME NOT NULL,
+    hora_fin TIME NOT NULL,
+
+    FOREIGN KEY (barberia_id) REFERENCES barberias(id),
+    FOREIGN KEY (barbero_id) REFERENCES usuarios(id),
+    CHECK (dia_semana BETWEEN 0 AND 6),
+    CHECK (hora_inicio < hora_fin)
+);
+
+-- Refresh tokens de sesión. Se guarda sólo el SHA-256; cada uso lo revoca y
+-- emite uno nuevo (rotación), y el logout lo revoca.
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    usuario_id INT NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expira_en TIMESTAMP NOT NULL,
+    revocado_en TIMESTAMP,
+    creado_en TIMESTAMP NOT NULL DEFAULT now(),
+
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+);
+
+-- Pedidos de recuperación de contraseña: token hasheado, de un solo uso y con vencimiento
+CREATE TABLE password_resets (
+    id SERIAL PRIMARY KEY,
+    usuario_id INT NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expira_en TIMESTAMP NOT NULL,
+    usado_en TIMESTAMP,
+    creado_en TIMESTAMP NOT NULL DEFAULT now(),
+
+    FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+);
+
+-- Auditoría de logins fallidos
+CREATE TABLE login_fallidos (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50) NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    motivo VARCHAR(30) NOT NULL, -- usuario_inexistente | password_incorrecto | bloqueado
+    creado_en TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Contador de fallos por clave ("usuario:<username>" o "ip:<ip>") para
+-- demoras progresivas y bloqueo temporal. Persiste entre reinicios.
+CREATE TABLE login_limites (
+    clave VARCHAR(120) PRIMARY KEY,
+    fallos INT NOT NULL DEFAULT 0,
+    bloqueado_hasta TIMESTAMP,
+    actualizado_en TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Bloqueos de agenda: feriados, cierres o licencias de un barbero.
+-- Con barbero_id NULL aplican a toda la barbería. Sin horas bloquean el día
+-- completo; con horas bloquean ese rango en cada día del período.
+-- Los recurrentes se repiten todos los años (se ignora el año de las fechas).
+CREATE TABLE bloqueos (
+    id SERIAL PRIMARY KEY,
+    barberia_id INT NOT NULL,
+    barbero_id INT,
+    fecha_desde DATE NOT NULL,
+    fecha_hasta DATE NOT NULL,
+    hora_inicio TIME,
+    hora_fin TIME,
+    motivo VARCHAR(100) NOT NULL,
+    recurrente_anual BOOLEAN NOT NULL DEFAULT false,
+    creado_en TIMESTAMP DEFAULT now(),
+
+    FOREIGN KEY (barberia_id) REFERENCES barberias(id),
+    FOREIGN KEY (barbero_id) REFERENCES usuarios(id),
+    CHECK (fecha_desde <= fecha_hasta),
+    CHECK ((hora_inicio IS NULL) = (hora_fin IS NULL)),
+    CHECK (hora_inicio < hora_fin)
 );
 
 INSERT INTO barberias (nombre, slug, hora_apertura, hora_cierre)
@@ -66,6 +224,10 @@ VALUES (1, 'Corte de Cabello', 30, 15.00),
 INSERT INTO usuarios (barberia_id, username, nombre, apellido, email, password_hash, rol)
 VALUES (1, 'admin_juan', 'Juan', 'Pérez', 'juan@correo.com', '$2a$12$MC9cqg7Om6vGwYDBHe6Qsuu.HRaKeH915xHISHFhTo9R80RImnlum', 'barbero');
 
+-- Dueño de la barbería (misma contraseña que admin_juan)
+INSERT INTO usuarios (barberia_id, username, nombre, apellido, email, password_hash, rol)
+VALUES (1, 'admin', 'Admin', 'Test', 'admin@correo.com', '$2a$12$MC9cqg7Om6vGwYDBHe6Qsuu.HRaKeH915xHISHFhTo9R80RImnlum', 'admin');
+
 INSERT INTO turnos (
   barberia_id,
   barbero_id,
@@ -73,7 +235,9 @@ INSERT INTO turnos (
   fecha,
   hora_inicio,
   hora_fin,
-  cliente_nombre
+  cliente_nombre,
+  ocupado_inicio,
+  ocupado_fin
 ) VALUES (
   1,
   1,
@@ -81,5 +245,7 @@ INSERT INTO turnos (
   '2026-01-05',
   '10:00',
   '10:30',
-  'Juan'
+  'Juan',
+  '10:00',
+  '10:30'
 );
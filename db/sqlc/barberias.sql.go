@@ -7,13 +7,14 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
 const createBarberia = `-- name: CreateBarberia :one
-INSERT INTO barberias (nombre, slug, hora_apertura, hora_cierre)
-VALUES ($1, $2, $3, $4)
-RETURNING id, nombre, slug, hora_apertura, hora_cierre, activa
+INSERT INTO barberias (nombre, slug, hora_apertura, hora_cierre, zona_horaria, pais)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, nombre, slug, hora_apertura, hora_cierre, activa, intervalo_minutos, buffer_antes_minutos, buffer_despues_minutos, aviso_cambio_minutos, zona_horaria, anticipacion_minima_minutos, anticipacion_maxima_dias, max_turnos_activos_cliente, pais
 `
 
 type CreateBarberiaParams struct {
@@ -21,6 +22,8 @@ type CreateBarberiaParams struct {
 	Slug         string    `json:"slug"`
 	HoraApertura time.Time `json:"hora_apertura"`
 	HoraCierre   time.Time `json:"hora_cierre"`
+	ZonaHoraria  string    `json:"zona_horaria"`
+	Pais         string    `json:"pais"`
 }
 
 func (q *Queries) CreateBarberia(ctx context.Context, arg CreateBarberiaParams) (Barberia, error) {
@@ -29,6 +32,8 @@ func (q *Queries) CreateBarberia(ctx context.Context, arg CreateBarberiaParams)
 		arg.Slug,
 		arg.HoraApertura,
 		arg.HoraCierre,
+		arg.ZonaHoraria,
+		arg.Pais,
 	)
 	var i Barberia
 	err := row.Scan(
@@ -38,12 +43,36 @@ func (q *Queries) CreateBarberia(ctx context.Context, arg CreateBarberiaParams)
 		&i.HoraApertura,
 		&i.HoraCierre,
 		&i.Activa,
+		&i.IntervaloMinutos,
+		&i.BufferAntesMinutos,
+		&i.BufferDespuesMinutos,
+		&i.AvisoCambioMinutos,
+		&i.ZonaHoraria,
+		&i.AnticipacionMinimaMinutos,
+		&i.AnticipacionMaximaDias,
+		&i.MaxTurnosActivosCliente,
+		&i.Pais,
 	)
 	return i, err
 }
 
+const existeSlug = `-- name: ExisteSlug :one
+SELECT EXISTS (
+  SELECT 1
+  FROM barberias
+  WHERE slug = $1
+)
+`
+
+func (q *Queries) ExisteSlug(ctx context.Context, slug string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, existeSlug, slug)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const getBarberiaBySlug = `-- name: GetBarberiaBySlug :one
-SELECT id, nombre, slug, hora_apertura, hora_cierre, activa
+SELECT id, nombre, slug, hora_apertura, hora_cierre, activa, intervalo_minutos, buffer_antes_minutos, buffer_despues_minutos, aviso_cambio_minutos, zona_horaria, anticipacion_minima_minutos, anticipacion_maxima_dias, max_turnos_activos_cliente, pais
 FROM barberias
 WHERE slug = $1
   AND activa = true
@@ -59,6 +88,45 @@ func (q *Queries) GetBarberiaBySlug(ctx context.Context, slug string) (Barberia,
 		&i.HoraApertura,
 		&i.HoraCierre,
 		&i.Activa,
+		&i.IntervaloMinutos,
+		&i.BufferAntesMinutos,
+		&i.BufferDespuesMinutos,
+		&i.AvisoCambioMinutos,
+		&i.ZonaHoraria,
+		&i.AnticipacionMinimaMinutos,
+		&i.AnticipacionMaximaDias,
+		&i.MaxTurnosActivosCliente,
+		&i.Pais,
+	)
+	return i, err
+}
+
+const getBarberiaBySlugAdmin = `-- name: GetBarberiaBySlugAdmin :one
+SELECT id, nombre, slug, hora_apertura, hora_cierre, activa, intervalo_minutos, buffer_antes_minutos, buffer_despues_minutos, aviso_cambio_minutos, zona_horaria, anticipacion_minima_minutos, anticipacion_maxima_dias, max_turnos_activos_cliente, pais
+FROM barberias
+WHERE slug = $1
+`
+
+// Incluye las inactivas: el admin tiene que poder reactivar su barbería.
+func (q *Queries) GetBarberiaBySlugAdmin(ctx context.Context, slug string) (Barberia, error) {
+	row := q.db.QueryRowContext(ctx, getBarberiaBySlugAdmin, slug)
+	var i Barberia
+	err := row.Scan(
+		&i.ID,
+		&i.Nombre,
+		&i.Slug,
+		&i.HoraApertura,
+		&i.HoraCierre,
+		&i.Activa,
+		&i.IntervaloMinutos,
+		&i.BufferAntesMinutos,
+		&i.BufferDespuesMinutos,
+		&i.AvisoCambioMinutos,
+		&i.ZonaHoraria,
+		&i.AnticipacionMinimaMinutos,
+		&i.AnticipacionMaximaDias,
+		&i.MaxTurnosActivosCliente,
+		&i.Pais,
 	)
 	return i, err
 }
@@ -99,3 +167,81 @@ func (q *Queries) ListBarberosByBarberia(ctx context.Context, barberiaID int32)
 	}
 	return items, nil
 }
+
+const updateBarberiaConfiguracion = `-- name: UpdateBarberiaConfiguracion :one
+UPDATE barberias
+SET intervalo_minutos = COALESCE($1, intervalo_minutos),
+    buffer_antes_minutos = COALESCE($2, buffer_antes_minutos),
+    buffer_despues_minutos = COALESCE($3, buffer_despues_minutos),
+    aviso_cambio_minutos = COALESCE($4, aviso_cambio_minutos),
+    nombre = COALESCE($5, nombre),
+    slug = COALESCE($6, slug),
+    hora_apertura = COALESCE($7, hora_apertura),
+    hora_cierre = COALESCE($8, hora_cierre),
+    activa = COALESCE($9, activa),
+    zona_horaria = COALESCE($10, zona_horaria),
+    anticipacion_minima_minutos = COALESCE($11, anticipacion_minima_minutos),
+    anticipacion_maxima_dias = COALESCE($12, anticipacion_maxima_dias),
+    max_turnos_activos_cliente = COALESCE($13, max_turnos_activos_cliente),
+    pais = COALESCE($14, pais)
+WHERE id = $15
+RETURNING id, nombre, slug, hora_apertura, hora_cierre, activa, intervalo_minutos, buffer_antes_minutos, buffer_despues_minutos, aviso_cambio_minutos, zona_horaria, anticipacion_minima_minutos, anticipacion_maxima_dias, max_turnos_activos_cliente, pais
+`
+
+type UpdateBarberiaConfiguracionParams struct {
+	IntervaloMinutos          sql.NullInt32  `json:"intervalo_minutos"`
+	BufferAntesMinutos        sql.NullInt32  `json:"buffer_antes_minutos"`
+	BufferDespuesMinutos      sql.NullInt32  `json:"buffer_despues_minutos"`
+	AvisoCambioMinutos        sql.NullInt32  `json:"aviso_cambio_minutos"`
+	Nombre                    sql.NullString `json:"nombre"`
+	Slug                      sql.NullString `json:"slug"`
+	HoraApertura              sql.NullTime   `json:"hora_apertura"`
+	HoraCierre                sql.NullTime   `json:"hora_cierre"`
+	Activa                    sql.NullBool   `json:"activa"`
+	ZonaHoraria               sql.NullString `json:"zona_horaria"`
+	AnticipacionMinimaMinutos sql.NullInt32  `json:"anticipacion_minima_minutos"`
+	AnticipacionMaximaDias    sql.NullInt32  `json:"anticipacion_maxima_dias"`
+	MaxTurnosActivosCliente   sql.NullInt32  `json:"max_turnos_activos_cliente"`
+	Pais                      sql.NullString `json:"pais"`
+	ID                        int32          `json:"id"`
+}
+
+// Los campos en NULL conservan su valor actual.
+func (q *Queries) UpdateBarberiaConfiguracion(ctx context.Context, arg UpdateBarberiaConfiguracionParams) (Barberia, error) {
+	row := q.db.QueryRowContext(ctx, updateBarberiaConfiguracion,
+		arg.IntervaloMinutos,
+		arg.BufferAntesMinutos,
+		arg.BufferDespuesMinutos,
+		arg.AvisoCambioMinutos,
+		arg.Nombre,
+		arg.Slug,
+		arg.HoraApertura,
+		arg.HoraCierre,
+		arg.Activa,
+		arg.ZonaHoraria,
+		arg.AnticipacionMinimaMinutos,
+		arg.AnticipacionMaximaDias,
+		arg.MaxTurnosActivosCliente,
+		arg.Pais,
+		arg.ID,
+	)
+	var i Barberia
+	err := row.Scan(
+		&i.ID,
+		&i.Nombre,
+		&i.Slug,
+		&i.HoraApertura,
+		&i.HoraCierre,
+		&i.Activa,
+		&i.IntervaloMinutos,
+		&i.BufferAntesMinutos,
+		&i.BufferDespuesMinutos,
+		&i.AvisoCambioMinutos,
+		&i.ZonaHoraria,
+		&i.AnticipacionMinimaMinutos,
+		&i.AnticipacionMaximaDias,
+		&i.MaxTurnosActivosCliente,
+		&i.Pais,
+	)
+	return i, err
+}
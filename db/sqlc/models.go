@@ -10,21 +10,105 @@ import (
 )
 
 type Barberia struct {
-	ID           int32        `json:"id"`
-	Nombre       string       `json:"nombre"`
-	Slug         string       `json:"slug"`
-	HoraApertura time.Time    `json:"hora_apertura"`
-	HoraCierre   time.Time    `json:"hora_cierre"`
-	Activa       sql.NullBool `json:"activa"`
+	ID                        int32        `json:"id"`
+	Nombre                    string       `json:"nombre"`
+	Slug                      string       `json:"slug"`
+	HoraApertura              time.Time    `json:"hora_apertura"`
+	HoraCierre                time.Time    `json:"hora_cierre"`
+	Activa                    sql.NullBool `json:"activa"`
+	IntervaloMinutos          int32        `json:"intervalo_minutos"`
+	BufferAntesMinutos        int32        `json:"buffer_antes_minutos"`
+	BufferDespuesMinutos      int32        `json:"buffer_despues_minutos"`
+	AvisoCambioMinutos        int32        `json:"aviso_cambio_minutos"`
+	ZonaHoraria               string       `json:"zona_horaria"`
+	AnticipacionMinimaMinutos int32        `json:"anticipacion_minima_minutos"`
+	AnticipacionMaximaDias    int32        `json:"anticipacion_maxima_dias"`
+	MaxTurnosActivosCliente   int32        `json:"max_turnos_activos_cliente"`
+	Pais                      string       `json:"pais"`
+}
+
+type BarberoServicio struct {
+	BarberoID       int32          `json:"barbero_id"`
+	ServicioID      int32          `json:"servicio_id"`
+	DuracionMinutos sql.NullInt32  `json:"duracion_minutos"`
+	Precio          sql.NullString `json:"precio"`
+}
+
+type Bloqueo struct {
+	ID              int32         `json:"id"`
+	BarberiaID      int32         `json:"barberia_id"`
+	BarberoID       sql.NullInt32 `json:"barbero_id"`
+	FechaDesde      time.Time     `json:"fecha_desde"`
+	FechaHasta      time.Time     `json:"fecha_hasta"`
+	HoraInicio      sql.NullTime  `json:"hora_inicio"`
+	HoraFin         sql.NullTime  `json:"hora_fin"`
+	Motivo          string        `json:"motivo"`
+	RecurrenteAnual bool          `json:"recurrente_anual"`
+	CreadoEn        sql.NullTime  `json:"creado_en"`
+}
+
+type Cliente struct {
+	ID         int32          `json:"id"`
+	BarberiaID int32          `json:"barberia_id"`
+	Nombre     string         `json:"nombre"`
+	Telefono   sql.NullString `json:"telefono"`
+	Email      sql.NullString `json:"email"`
+	CreadoEn   time.Time      `json:"creado_en"`
+}
+
+type Horario struct {
+	ID         int32         `json:"id"`
+	BarberiaID int32         `json:"barberia_id"`
+	BarberoID  sql.NullInt32 `json:"barbero_id"`
+	DiaSemana  int32         `json:"dia_semana"`
+	HoraInicio time.Time     `json:"hora_inicio"`
+	HoraFin    time.Time     `json:"hora_fin"`
+}
+
+type LoginFallido struct {
+	ID       int32     `json:"id"`
+	Username string    `json:"username"`
+	Ip       string    `json:"ip"`
+	Motivo   string    `json:"motivo"`
+	CreadoEn time.Time `json:"creado_en"`
+}
+
+type LoginLimite struct {
+	Clave          string       `json:"clave"`
+	Fallos         int32        `json:"fallos"`
+	BloqueadoHasta sql.NullTime `json:"bloqueado_hasta"`
+	ActualizadoEn  time.Time    `json:"actualizado_en"`
+}
+
+type PasswordReset struct {
+	ID        int32        `json:"id"`
+	UsuarioID int32        `json:"usuario_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiraEn  time.Time    `json:"expira_en"`
+	UsadoEn   sql.NullTime `json:"usado_en"`
+	CreadoEn  time.Time    `json:"creado_en"`
+}
+
+type RefreshToken struct {
+	ID         int32        `json:"id"`
+	UsuarioID  int32        `json:"usuario_id"`
+	TokenHash  string       `json:"token_hash"`
+	ExpiraEn   time.Time    `json:"expira_en"`
+	RevocadoEn sql.NullTime `json:"revocado_en"`
+	CreadoEn   time.Time    `json:"creado_en"`
 }
 
 type Servicio struct {
-	ID              int32        `json:"id"`
-	BarberiaID      int32        `json:"barberia_id"`
-	Nombre          string       `json:"nombre"`
-	DuracionMinutos int32        `json:"duracion_minutos"`
-	Precio          string       `json:"precio"`
-	Activo          sql.NullBool `json:"activo"`
+	ID                   int32         `json:"id"`
+	BarberiaID           int32         `json:"barberia_id"`
+	Nombre               string        `json:"nombre"`
+	DuracionMinutos      int32         `json:"duracion_minutos"`
+	Precio               string        `json:"precio"`
+	Activo               sql.NullBool  `json:"activo"`
+	BufferAntesMinutos   sql.NullInt32 `json:"buffer_antes_minutos"`
+	BufferDespuesMinutos sql.NullInt32 `json:"buffer_despues_minutos"`
+	Orden                int32         `json:"orden"`
+	Restringido          bool          `json:"restringido"`
 }
 
 type Turno struct {
@@ -39,6 +123,15 @@ type Turno struct {
 	ClienteTelefono sql.NullString `json:"cliente_telefono"`
 	Estado          sql.NullString `json:"estado"`
 	CreadoEn        sql.NullTime   `json:"creado_en"`
+	OcupadoInicio   time.Time      `json:"ocupado_inicio"`
+	OcupadoFin      time.Time      `json:"ocupado_fin"`
+	ConfirmadoEn    sql.NullTime   `json:"confirmado_en"`
+	CompletadoEn    sql.NullTime   `json:"completado_en"`
+	NoAsistioEn     sql.NullTime   `json:"no_asistio_en"`
+	CanceladoEn     sql.NullTime   `json:"cancelado_en"`
+	TokenHash       sql.NullString `json:"-"`
+	ClienteID       sql.NullInt32  `json:"cliente_id"`
+	Precio          sql.NullString `json:"precio"`
 }
 
 type Usuario struct {
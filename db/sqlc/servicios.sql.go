@@ -7,29 +7,39 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createServicio = `-- name: CreateServicio :one
 INSERT INTO servicios (
-  barberia_id, nombre, duracion_minutos, precio
+  barberia_id, nombre, duracion_minutos, precio,
+  buffer_antes_minutos, buffer_despues_minutos, orden
 )
-VALUES ($1, $2, $3, $4)
-RETURNING id, barberia_id, nombre, duracion_minutos, precio, activo
+VALUES (
+  $1, $2, $3, $4, $5, $6,
+  (SELECT COALESCE(MAX(s.orden) + 1, 0) FROM servicios s WHERE s.barberia_id = $1)
+)
+RETURNING id, barberia_id, nombre, duracion_minutos, precio, activo, buffer_antes_minutos, buffer_despues_minutos, orden, restringido
 `
 
 type CreateServicioParams struct {
-	BarberiaID      int32  `json:"barberia_id"`
-	Nombre          string `json:"nombre"`
-	DuracionMinutos int32  `json:"duracion_minutos"`
-	Precio          string `json:"precio"`
+	BarberiaID           int32         `json:"barberia_id"`
+	Nombre               string        `json:"nombre"`
+	DuracionMinutos      int32         `json:"duracion_minutos"`
+	Precio               string        `json:"precio"`
+	BufferAntesMinutos   sql.NullInt32 `json:"buffer_antes_minutos"`
+	BufferDespuesMinutos sql.NullInt32 `json:"buffer_despues_minutos"`
 }
 
+// Los servicios nuevos van al final del listado.
 func (q *Queries) CreateServicio(ctx context.Context, arg CreateServicioParams) (Servicio, error) {
 	row := q.db.QueryRowContext(ctx, createServicio,
 		arg.BarberiaID,
 		arg.Nombre,
 		arg.DuracionMinutos,
 		arg.Precio,
+		arg.BufferAntesMinutos,
+		arg.BufferDespuesMinutos,
 	)
 	var i Servicio
 	err := row.Scan(
@@ -39,11 +49,15 @@ func (q *Queries) CreateServicio(ctx context.Context, arg CreateServicioParams)
 		&i.DuracionMinutos,
 		&i.Precio,
 		&i.Activo,
+		&i.BufferAntesMinutos,
+		&i.BufferDespuesMinutos,
+		&i.Orden,
+		&i.Restringido,
 	)
 	return i, err
 }
 
-const deactivateServicio = `-- name: DeactivateServicio :exec
+const deactivateServicio = `-- name: DeactivateServicio :execrows
 UPDATE servicios
 SET activo = false
 WHERE id = $1
@@ -55,13 +69,16 @@ type DeactivateServicioParams struct {
 	BarberiaID int32 `json:"barberia_id"`
 }
 
-func (q *Queries) DeactivateServicio(ctx context.Context, arg DeactivateServicioParams) error {
-	_, err := q.db.ExecContext(ctx, deactivateServicio, arg.ID, arg.BarberiaID)
-	return err
+func (q *Queries) DeactivateServicio(ctx context.Context, arg DeactivateServicioParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deactivateServicio, arg.ID, arg.BarberiaID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getServicioByID = `-- name: GetServicioByID :one
-SELECT id, barberia_id, nombre, duracion_minutos, precio, activo
+SELECT id, barberia_id, nombre, duracion_minutos, precio, activo, buffer_antes_minutos, buffer_despues_minutos, orden, restringido
 FROM servicios
 WHERE id = $1
   AND activo = true
@@ -77,16 +94,51 @@ func (q *Queries) GetServicioByID(ctx context.Context, id int32) (Servicio, erro
 		&i.DuracionMinutos,
 		&i.Precio,
 		&i.Activo,
+		&i.BufferAntesMinutos,
+		&i.BufferDespuesMinutos,
+		&i.Orden,
+		&i.Restringido,
+	)
+	return i, err
+}
+
+const getServicioDeBarberia = `-- name: GetServicioDeBarberia :one
+SELECT id, barberia_id, nombre, duracion_minutos, precio, activo, buffer_antes_minutos, buffer_despues_minutos, orden, restringido
+FROM servicios
+WHERE id = $1
+  AND barberia_id = $2
+`
+
+type GetServicioDeBarberiaParams struct {
+	ID         int32 `json:"id"`
+	BarberiaID int32 `json:"barberia_id"`
+}
+
+// Incluye los inactivos: el admin puede editarlos y reactivarlos.
+func (q *Queries) GetServicioDeBarberia(ctx context.Context, arg GetServicioDeBarberiaParams) (Servicio, error) {
+	row := q.db.QueryRowContext(ctx, getServicioDeBarberia, arg.ID, arg.BarberiaID)
+	var i Servicio
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.Nombre,
+		&i.DuracionMinutos,
+		&i.Precio,
+		&i.Activo,
+		&i.BufferAntesMinutos,
+		&i.BufferDespuesMinutos,
+		&i.Orden,
+		&i.Restringido,
 	)
 	return i, err
 }
 
 const listServicios = `-- name: ListServicios :many
-SELECT id, barberia_id, nombre, duracion_minutos, precio, activo
+SELECT id, barberia_id, nombre, duracion_minutos, precio, activo, buffer_antes_minutos, buffer_despues_minutos, orden, restringido
 FROM servicios
 WHERE barberia_id = $1
   AND activo = true
-ORDER BY nombre
+ORDER BY orden, nombre
 `
 
 func (q *Queries) ListServicios(ctx context.Context, barberiaID int32) ([]Servicio, error) {
@@ -105,6 +157,10 @@ func (q *Queries) ListServicios(ctx context.Context, barberiaID int32) ([]Servic
 			&i.DuracionMinutos,
 			&i.Precio,
 			&i.Activo,
+			&i.BufferAntesMinutos,
+			&i.BufferDespuesMinutos,
+			&i.Orden,
+			&i.Restringido,
 		); err != nil {
 			return nil, err
 		}
@@ -124,7 +180,7 @@ SELECT id, nombre, duracion_minutos, precio
 FROM servicios
 WHERE barberia_id = $1
   AND activo = true
-ORDER BY nombre
+ORDER BY orden, nombre
 `
 
 type ListServiciosByBarberiaRow struct {
@@ -161,3 +217,95 @@ func (q *Queries) ListServiciosByBarberia(ctx context.Context, barberiaID int32)
 	}
 	return items, nil
 }
+
+const restringirServicio = `-- name: RestringirServicio :exec
+UPDATE servicios
+SET restringido = true
+WHERE id = $1
+  AND NOT EXISTS (SELECT 1 FROM barbero_servicios WHERE servicio_id = $1)
+`
+
+// La primera asignación del servicio lo restringe a los asignados; después
+// sólo lo cambia el admin.
+func (q *Queries) RestringirServicio(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, restringirServicio, id)
+	return err
+}
+
+const updateServicio = `-- name: UpdateServicio :one
+UPDATE servicios
+SET nombre = $3,
+    duracion_minutos = $4,
+    precio = $5,
+    buffer_antes_minutos = $6,
+    buffer_despues_minutos = $7,
+    orden = $8,
+    activo = $9,
+    restringido = $10
+WHERE id = $1
+  AND barberia_id = $2
+RETURNING id, barberia_id, nombre, duracion_minutos, precio, activo, buffer_antes_minutos, buffer_despues_minutos, orden, restringido
+`
+
+type UpdateServicioParams struct {
+	ID                   int32         `json:"id"`
+	BarberiaID           int32         `json:"barberia_id"`
+	Nombre               string        `json:"nombre"`
+	DuracionMinutos      int32         `json:"duracion_minutos"`
+	Precio               string        `json:"precio"`
+	BufferAntesMinutos   sql.NullInt32 `json:"buffer_antes_minutos"`
+	BufferDespuesMinutos sql.NullInt32 `json:"buffer_despues_minutos"`
+	Orden                int32         `json:"orden"`
+	Activo               sql.NullBool  `json:"activo"`
+	Restringido          bool          `json:"restringido"`
+}
+
+func (q *Queries) UpdateServicio(ctx context.Context, arg UpdateServicioParams) (Servicio, error) {
+	row := q.db.QueryRowContext(ctx, updateServicio,
+		arg.ID,
+		arg.BarberiaID,
+		arg.Nombre,
+		arg.DuracionMinutos,
+		arg.Precio,
+		arg.BufferAntesMinutos,
+		arg.BufferDespuesMinutos,
+		arg.Orden,
+		arg.Activo,
+		arg.Restringido,
+	)
+	var i Servicio
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.Nombre,
+		&i.DuracionMinutos,
+		&i.Precio,
+		&i.Activo,
+		&i.BufferAntesMinutos,
+		&i.BufferDespuesMinutos,
+		&i.Orden,
+		&i.Restringido,
+	)
+	return i, err
+}
+
+const updateServicioOrden = `-- name: UpdateServicioOrden :execrows
+UPDATE servicios
+SET orden = $3
+WHERE id = $1
+  AND barberia_id = $2
+`
+
+type UpdateServicioOrdenParams struct {
+	ID         int32 `json:"id"`
+	BarberiaID int32 `json:"barberia_id"`
+	Orden      int32 `json:"orden"`
+}
+
+func (q *Queries) UpdateServicioOrden(ctx context.Context, arg UpdateServicioOrdenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateServicioOrden, arg.ID, arg.BarberiaID, arg.Orden)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -11,21 +11,36 @@ import (
 	"time"
 )
 
-const cancelTurno = `-- name: CancelTurno :exec
-UPDATE turnos
-SET estado = 'cancelado'
-WHERE id = $1
-  AND barberia_id = $2
+const countTurnosActivosCliente = `-- name: CountTurnosActivosCliente :one
+SELECT COUNT(*)
+FROM turnos
+WHERE barberia_id = $1
+  AND cliente_telefono = $2
+  AND estado IN ('pendiente', 'confirmado')
+  AND (
+    fecha > $3
+    OR (fecha = $3 AND hora_inicio >= $4)
+  )
 `
 
-type CancelTurnoParams struct {
-	ID         int32 `json:"id"`
-	BarberiaID int32 `json:"barberia_id"`
+type CountTurnosActivosClienteParams struct {
+	BarberiaID      int32          `json:"barberia_id"`
+	ClienteTelefono sql.NullString `json:"cliente_telefono"`
+	DesdeFecha      time.Time      `json:"desde_fecha"`
+	DesdeHora       time.Time      `json:"desde_hora"`
 }
 
-func (q *Queries) CancelTurno(ctx context.Context, arg CancelTurnoParams) error {
-	_, err := q.db.ExecContext(ctx, cancelTurno, arg.ID, arg.BarberiaID)
-	return err
+// Turnos todavía activos del cliente (por teléfono) a partir del momento indicado.
+func (q *Queries) CountTurnosActivosCliente(ctx context.Context, arg CountTurnosActivosClienteParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTurnosActivosCliente,
+		arg.BarberiaID,
+		arg.ClienteTelefono,
+		arg.DesdeFecha,
+		arg.DesdeHora,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const createTurno = `-- name: CreateTurno :one
@@ -38,12 +53,17 @@ INSERT INTO turnos (
   hora_fin,
   cliente_nombre,
   cliente_telefono,
-  estado
+  estado,
+  ocupado_inicio,
+  ocupado_fin,
+  token_hash,
+  cliente_id,
+  precio
 )
 VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 )
-RETURNING id, barberia_id, barbero_id, servicio_id, fecha, hora_inicio, hora_fin, cliente_nombre, cliente_telefono, estado, creado_en
+RETURNING id, barberia_id, barbero_id, servicio_id, fecha, hora_inicio, hora_fin, cliente_nombre, cliente_telefono, estado, creado_en, ocupado_inicio, ocupado_fin, confirmado_en, completado_en, no_asistio_en, cancelado_en, token_hash, cliente_id, precio
 `
 
 type CreateTurnoParams struct {
@@ -56,6 +76,11 @@ type CreateTurnoParams struct {
 	ClienteNombre   string         `json:"cliente_nombre"`
 	ClienteTelefono sql.NullString `json:"cliente_telefono"`
 	Estado          sql.NullString `json:"estado"`
+	OcupadoInicio   time.Time      `json:"ocupado_inicio"`
+	OcupadoFin      time.Time      `json:"ocupado_fin"`
+	TokenHash       sql.NullString `json:"-"`
+	ClienteID       sql.NullInt32  `json:"cliente_id"`
+	Precio          sql.NullString `json:"precio"`
 }
 
 func (q *Queries) CreateTurno(ctx context.Context, arg CreateTurnoParams) (Turno, error) {
@@ -69,7 +94,52 @@ func (q *Queries) CreateTurno(ctx context.Context, arg CreateTurnoParams) (Turno
 		arg.ClienteNombre,
 		arg.ClienteTelefono,
 		arg.Estado,
+		arg.OcupadoInicio,
+		arg.OcupadoFin,
+		arg.TokenHash,
+		arg.ClienteID,
+		arg.Precio,
+	)
+	var i Turno
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.BarberoID,
+		&i.ServicioID,
+		&i.Fecha,
+		&i.HoraInicio,
+		&i.HoraFin,
+		&i.ClienteNombre,
+		&i.ClienteTelefono,
+		&i.Estado,
+		&i.CreadoEn,
+		&i.OcupadoInicio,
+		&i.OcupadoFin,
+		&i.ConfirmadoEn,
+		&i.CompletadoEn,
+		&i.NoAsistioEn,
+		&i.CanceladoEn,
+		&i.TokenHash,
+		&i.ClienteID,
+		&i.Precio,
 	)
+	return i, err
+}
+
+const getTurnoByID = `-- name: GetTurnoByID :one
+SELECT id, barberia_id, barbero_id, servicio_id, fecha, hora_inicio, hora_fin, cliente_nombre, cliente_telefono, estado, creado_en, ocupado_inicio, ocupado_fin, confirmado_en, completado_en, no_asistio_en, cancelado_en, token_hash, cliente_id, precio
+FROM turnos
+WHERE id = $1
+  AND barberia_id = $2
+`
+
+type GetTurnoByIDParams struct {
+	ID         int32 `json:"id"`
+	BarberiaID int32 `json:"barberia_id"`
+}
+
+func (q *Queries) GetTurnoByID(ctx context.Context, arg GetTurnoByIDParams) (Turno, error) {
+	row := q.db.QueryRowContext(ctx, getTurnoByID, arg.ID, arg.BarberiaID)
 	var i Turno
 	err := row.Scan(
 		&i.ID,
@@ -83,6 +153,55 @@ func (q *Queries) CreateTurno(ctx context.Context, arg CreateTurnoParams) (Turno
 		&i.ClienteTelefono,
 		&i.Estado,
 		&i.CreadoEn,
+		&i.OcupadoInicio,
+		&i.OcupadoFin,
+		&i.ConfirmadoEn,
+		&i.CompletadoEn,
+		&i.NoAsistioEn,
+		&i.CanceladoEn,
+		&i.TokenHash,
+		&i.ClienteID,
+		&i.Precio,
+	)
+	return i, err
+}
+
+const getTurnoByToken = `-- name: GetTurnoByToken :one
+SELECT id, barberia_id, barbero_id, servicio_id, fecha, hora_inicio, hora_fin, cliente_nombre, cliente_telefono, estado, creado_en, ocupado_inicio, ocupado_fin, confirmado_en, completado_en, no_asistio_en, cancelado_en, token_hash, cliente_id, precio
+FROM turnos
+WHERE barberia_id = $1
+  AND token_hash = $2
+`
+
+type GetTurnoByTokenParams struct {
+	BarberiaID int32          `json:"barberia_id"`
+	TokenHash  sql.NullString `json:"-"`
+}
+
+func (q *Queries) GetTurnoByToken(ctx context.Context, arg GetTurnoByTokenParams) (Turno, error) {
+	row := q.db.QueryRowContext(ctx, getTurnoByToken, arg.BarberiaID, arg.TokenHash)
+	var i Turno
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.BarberoID,
+		&i.ServicioID,
+		&i.Fecha,
+		&i.HoraInicio,
+		&i.HoraFin,
+		&i.ClienteNombre,
+		&i.ClienteTelefono,
+		&i.Estado,
+		&i.CreadoEn,
+		&i.OcupadoInicio,
+		&i.OcupadoFin,
+		&i.ConfirmadoEn,
+		&i.CompletadoEn,
+		&i.NoAsistioEn,
+		&i.CanceladoEn,
+		&i.TokenHash,
+		&i.ClienteID,
+		&i.Precio,
 	)
 	return i, err
 }
@@ -96,20 +215,23 @@ SELECT EXISTS (
     AND fecha = $3
     AND estado != 'cancelado'
     AND (
-      -- Lógica correcta de intersección:
+      -- Lógica correcta de intersección (con buffers incluidos):
       -- (Inicio_Existente < Fin_Nuevo) Y (Fin_Existente > Inicio_Nuevo)
-      hora_inicio < $4 
-      AND hora_fin > $5
+      ocupado_inicio < $4
+      AND ocupado_fin > $5
     )
+    -- Al reprogramar, el propio turno no cuenta (0 = turno nuevo)
+    AND id != $6
 )
 `
 
 type HasTurnoOverlapParams struct {
-	BarberiaID int32     `json:"barberia_id"`
-	BarberoID  int32     `json:"barbero_id"`
-	Fecha      time.Time `json:"fecha"`
-	HoraFin    time.Time `json:"hora_fin"`
-	HoraInicio time.Time `json:"hora_inicio"`
+	BarberiaID    int32     `json:"barberia_id"`
+	BarberoID     int32     `json:"barbero_id"`
+	Fecha         time.Time `json:"fecha"`
+	OcupadoFin    time.Time `json:"ocupado_fin"`
+	OcupadoInicio time.Time `json:"ocupado_inicio"`
+	ExcluirID     int32     `json:"excluir_id"`
 }
 
 func (q *Queries) HasTurnoOverlap(ctx context.Context, arg HasTurnoOverlapParams) (bool, error) {
@@ -117,8 +239,9 @@ func (q *Queries) HasTurnoOverlap(ctx context.Context, arg HasTurnoOverlapParams
 		arg.BarberiaID,
 		arg.BarberoID,
 		arg.Fecha,
-		arg.HoraFin,
-		arg.HoraInicio,
+		arg.OcupadoFin,
+		arg.OcupadoInicio,
+		arg.ExcluirID,
 	)
 	var exists bool
 	err := row.Scan(&exists)
@@ -126,19 +249,21 @@ func (q *Queries) HasTurnoOverlap(ctx context.Context, arg HasTurnoOverlapParams
 }
 
 const listTurnosByFecha = `-- name: ListTurnosByFecha :many
-SELECT t.id, t.barberia_id, t.barbero_id, t.servicio_id, t.fecha, t.hora_inicio, t.hora_fin, t.cliente_nombre, t.cliente_telefono, t.estado, t.creado_en, s.nombre AS servicio_nombre, u.nombre AS barbero_nombre
+SELECT t.id, t.barberia_id, t.barbero_id, t.servicio_id, t.fecha, t.hora_inicio, t.hora_fin, t.cliente_nombre, t.cliente_telefono, t.estado, t.creado_en, t.ocupado_inicio, t.ocupado_fin, t.confirmado_en, t.completado_en, t.no_asistio_en, t.cancelado_en, t.token_hash, t.cliente_id, t.precio, s.nombre AS servicio_nombre, u.nombre AS barbero_nombre
 FROM turnos t
 JOIN servicios s ON s.id = t.servicio_id
 JOIN usuarios u ON u.id = t.barbero_id
 WHERE t.barberia_id = $1
   AND t.fecha = $2
+  AND ($3::int IS NULL OR t.barbero_id = $3)
   AND t.estado != 'cancelado'
-ORDER BY t.hora_inicio
+ORDER BY t.hora_inicio, t.barbero_id
 `
 
 type ListTurnosByFechaParams struct {
-	BarberiaID int32     `json:"barberia_id"`
-	Fecha      time.Time `json:"fecha"`
+	BarberiaID int32         `json:"barberia_id"`
+	Fecha      time.Time     `json:"fecha"`
+	BarberoID  sql.NullInt32 `json:"barbero_id"`
 }
 
 type ListTurnosByFechaRow struct {
@@ -153,12 +278,22 @@ type ListTurnosByFechaRow struct {
 	ClienteTelefono sql.NullString `json:"cliente_telefono"`
 	Estado          sql.NullString `json:"estado"`
 	CreadoEn        sql.NullTime   `json:"creado_en"`
+	OcupadoInicio   time.Time      `json:"ocupado_inicio"`
+	OcupadoFin      time.Time      `json:"ocupado_fin"`
+	ConfirmadoEn    sql.NullTime   `json:"confirmado_en"`
+	CompletadoEn    sql.NullTime   `json:"completado_en"`
+	NoAsistioEn     sql.NullTime   `json:"no_asistio_en"`
+	CanceladoEn     sql.NullTime   `json:"cancelado_en"`
+	TokenHash       sql.NullString `json:"-"`
+	ClienteID       sql.NullInt32  `json:"cliente_id"`
+	Precio          sql.NullString `json:"precio"`
 	ServicioNombre  string         `json:"servicio_nombre"`
 	BarberoNombre   string         `json:"barbero_nombre"`
 }
 
+// Agenda del día para el staff; barbero_id NULL trae la de todos.
 func (q *Queries) ListTurnosByFecha(ctx context.Context, arg ListTurnosByFechaParams) ([]ListTurnosByFechaRow, error) {
-	rows, err := q.db.QueryContext(ctx, listTurnosByFecha, arg.BarberiaID, arg.Fecha)
+	rows, err := q.db.QueryContext(ctx, listTurnosByFecha, arg.BarberiaID, arg.Fecha, arg.BarberoID)
 	if err != nil {
 		return nil, err
 	}
@@ -178,6 +313,15 @@ func (q *Queries) ListTurnosByFecha(ctx context.Context, arg ListTurnosByFechaPa
 			&i.ClienteTelefono,
 			&i.Estado,
 			&i.CreadoEn,
+			&i.OcupadoInicio,
+			&i.OcupadoFin,
+			&i.ConfirmadoEn,
+			&i.CompletadoEn,
+			&i.NoAsistioEn,
+			&i.CanceladoEn,
+			&i.TokenHash,
+			&i.ClienteID,
+			&i.Precio,
 			&i.ServicioNombre,
 			&i.BarberoNombre,
 		); err != nil {
@@ -195,7 +339,7 @@ func (q *Queries) ListTurnosByFecha(ctx context.Context, arg ListTurnosByFechaPa
 }
 
 const listTurnosByFechaAndBarbero = `-- name: ListTurnosByFechaAndBarbero :many
-SELECT t.id, t.barberia_id, t.barbero_id, t.servicio_id, t.fecha, t.hora_inicio, t.hora_fin, t.cliente_nombre, t.cliente_telefono, t.estado, t.creado_en, s.nombre AS servicio_nombre
+SELECT t.id, t.barberia_id, t.barbero_id, t.servicio_id, t.fecha, t.hora_inicio, t.hora_fin, t.cliente_nombre, t.cliente_telefono, t.estado, t.creado_en, t.ocupado_inicio, t.ocupado_fin, t.confirmado_en, t.completado_en, t.no_asistio_en, t.cancelado_en, t.token_hash, t.cliente_id, t.precio, s.nombre AS servicio_nombre
 FROM turnos t
 JOIN servicios s ON s.id = t.servicio_id
 WHERE t.barberia_id = $1
@@ -223,6 +367,15 @@ type ListTurnosByFechaAndBarberoRow struct {
 	ClienteTelefono sql.NullString `json:"cliente_telefono"`
 	Estado          sql.NullString `json:"estado"`
 	CreadoEn        sql.NullTime   `json:"creado_en"`
+	OcupadoInicio   time.Time      `json:"ocupado_inicio"`
+	OcupadoFin      time.Time      `json:"ocupado_fin"`
+	ConfirmadoEn    sql.NullTime   `json:"confirmado_en"`
+	CompletadoEn    sql.NullTime   `json:"completado_en"`
+	NoAsistioEn     sql.NullTime   `json:"no_asistio_en"`
+	CanceladoEn     sql.NullTime   `json:"cancelado_en"`
+	TokenHash       sql.NullString `json:"-"`
+	ClienteID       sql.NullInt32  `json:"cliente_id"`
+	Precio          sql.NullString `json:"precio"`
 	ServicioNombre  string         `json:"servicio_nombre"`
 }
 
@@ -247,6 +400,15 @@ func (q *Queries) ListTurnosByFechaAndBarbero(ctx context.Context, arg ListTurno
 			&i.ClienteTelefono,
 			&i.Estado,
 			&i.CreadoEn,
+			&i.OcupadoInicio,
+			&i.OcupadoFin,
+			&i.ConfirmadoEn,
+			&i.CompletadoEn,
+			&i.NoAsistioEn,
+			&i.CanceladoEn,
+			&i.TokenHash,
+			&i.ClienteID,
+			&i.Precio,
 			&i.ServicioNombre,
 		); err != nil {
 			return nil, err
@@ -262,8 +424,78 @@ func (q *Queries) ListTurnosByFechaAndBarbero(ctx context.Context, arg ListTurno
 	return items, nil
 }
 
+const listTurnosFuturosBarbero = `-- name: ListTurnosFuturosBarbero :many
+SELECT id, barberia_id, barbero_id, servicio_id, fecha, hora_inicio, hora_fin, cliente_nombre, cliente_telefono, estado, creado_en, ocupado_inicio, ocupado_fin, confirmado_en, completado_en, no_asistio_en, cancelado_en, token_hash, cliente_id, precio
+FROM turnos
+WHERE barberia_id = $1
+  AND barbero_id = $2
+  AND estado IN ('pendiente', 'confirmado')
+  AND (
+    fecha > $3
+    OR (fecha = $3 AND hora_inicio >= $4)
+  )
+ORDER BY fecha, hora_inicio
+`
+
+type ListTurnosFuturosBarberoParams struct {
+	BarberiaID int32     `json:"barberia_id"`
+	BarberoID  int32     `json:"barbero_id"`
+	DesdeFecha time.Time `json:"desde_fecha"`
+	DesdeHora  time.Time `json:"desde_hora"`
+}
+
+// Turnos todavía activos del barbero a partir del momento indicado.
+func (q *Queries) ListTurnosFuturosBarbero(ctx context.Context, arg ListTurnosFuturosBarberoParams) ([]Turno, error) {
+	rows, err := q.db.QueryContext(ctx, listTurnosFuturosBarbero,
+		arg.BarberiaID,
+		arg.BarberoID,
+		arg.DesdeFecha,
+		arg.DesdeHora,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Turno
+	for rows.Next() {
+		var i Turno
+		if err := rows.Scan(
+			&i.ID,
+			&i.BarberiaID,
+			&i.BarberoID,
+			&i.ServicioID,
+			&i.Fecha,
+			&i.HoraInicio,
+			&i.HoraFin,
+			&i.ClienteNombre,
+			&i.ClienteTelefono,
+			&i.Estado,
+			&i.CreadoEn,
+			&i.OcupadoInicio,
+			&i.OcupadoFin,
+			&i.ConfirmadoEn,
+			&i.CompletadoEn,
+			&i.NoAsistioEn,
+			&i.CanceladoEn,
+			&i.TokenHash,
+			&i.ClienteID,
+			&i.Precio,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTurnosOcupados = `-- name: ListTurnosOcupados :many
-SELECT barbero_id, hora_inicio, hora_fin
+SELECT barbero_id, ocupado_inicio AS hora_inicio, ocupado_fin AS hora_fin
 FROM turnos
 WHERE barberia_id = $1
   AND fecha = $2
@@ -282,6 +514,7 @@ type ListTurnosOcupadosRow struct {
 	HoraFin    time.Time `json:"hora_fin"`
 }
 
+// Devuelve el tiempo ocupado (buffers incluidos) con los nombres hora_inicio/hora_fin.
 func (q *Queries) ListTurnosOcupados(ctx context.Context, arg ListTurnosOcupadosParams) ([]ListTurnosOcupadosRow, error) {
 	rows, err := q.db.QueryContext(ctx, listTurnosOcupados, arg.BarberiaID, arg.Fecha)
 	if err != nil {
@@ -304,3 +537,141 @@ func (q *Queries) ListTurnosOcupados(ctx context.Context, arg ListTurnosOcupados
 	}
 	return items, nil
 }
+
+const lockTurnosCliente = `-- name: LockTurnosCliente :exec
+SELECT pg_advisory_xact_lock($1::int, hashtext($2::text))
+`
+
+type LockTurnosClienteParams struct {
+	BarberiaID int32  `json:"barberia_id"`
+	Telefono   string `json:"telefono"`
+}
+
+// Serializa hasta el commit las reservas del mismo cliente (por teléfono).
+func (q *Queries) LockTurnosCliente(ctx context.Context, arg LockTurnosClienteParams) error {
+	_, err := q.db.ExecContext(ctx, lockTurnosCliente, arg.BarberiaID, arg.Telefono)
+	return err
+}
+
+const updateTurnoEstado = `-- name: UpdateTurnoEstado :one
+UPDATE turnos
+SET estado = $1,
+    confirmado_en = CASE WHEN $1 = 'confirmado' THEN now() ELSE confirmado_en END,
+    completado_en = CASE WHEN $1 = 'completado' THEN now() ELSE completado_en END,
+    no_asistio_en = CASE WHEN $1 = 'no_asistio' THEN now() ELSE no_asistio_en END,
+    cancelado_en = CASE WHEN $1 = 'cancelado' THEN now() ELSE cancelado_en END
+WHERE id = $2
+  AND barberia_id = $3
+  AND COALESCE(estado, 'pendiente') = $4::text
+RETURNING id, barberia_id, barbero_id, servicio_id, fecha, hora_inicio, hora_fin, cliente_nombre, cliente_telefono, estado, creado_en, ocupado_inicio, ocupado_fin, confirmado_en, completado_en, no_asistio_en, cancelado_en, token_hash, cliente_id, precio
+`
+
+type UpdateTurnoEstadoParams struct {
+	EstadoNuevo  sql.NullString `json:"estado_nuevo"`
+	ID           int32          `json:"id"`
+	BarberiaID   int32          `json:"barberia_id"`
+	EstadoActual string         `json:"estado_actual"`
+}
+
+// Sólo aplica si el turno sigue en estado_actual, así dos transiciones
+// simultáneas no se pisan. Un estado NULL cuenta como pendiente.
+// Registra el momento de la transición.
+func (q *Queries) UpdateTurnoEstado(ctx context.Context, arg UpdateTurnoEstadoParams) (Turno, error) {
+	row := q.db.QueryRowContext(ctx, updateTurnoEstado,
+		arg.EstadoNuevo,
+		arg.ID,
+		arg.BarberiaID,
+		arg.EstadoActual,
+	)
+	var i Turno
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.BarberoID,
+		&i.ServicioID,
+		&i.Fecha,
+		&i.HoraInicio,
+		&i.HoraFin,
+		&i.ClienteNombre,
+		&i.ClienteTelefono,
+		&i.Estado,
+		&i.CreadoEn,
+		&i.OcupadoInicio,
+		&i.OcupadoFin,
+		&i.ConfirmadoEn,
+		&i.CompletadoEn,
+		&i.NoAsistioEn,
+		&i.CanceladoEn,
+		&i.TokenHash,
+		&i.ClienteID,
+		&i.Precio,
+	)
+	return i, err
+}
+
+const updateTurnoHorario = `-- name: UpdateTurnoHorario :one
+UPDATE turnos
+SET barbero_id = $1,
+    fecha = $2,
+    hora_inicio = $3,
+    hora_fin = $4,
+    ocupado_inicio = $5,
+    ocupado_fin = $6,
+    precio = $7
+WHERE id = $8
+  AND barberia_id = $9
+  AND estado IN ('pendiente', 'confirmado')
+RETURNING id, barberia_id, barbero_id, servicio_id, fecha, hora_inicio, hora_fin, cliente_nombre, cliente_telefono, estado, creado_en, ocupado_inicio, ocupado_fin, confirmado_en, completado_en, no_asistio_en, cancelado_en, token_hash, cliente_id, precio
+`
+
+type UpdateTurnoHorarioParams struct {
+	BarberoID     int32          `json:"barbero_id"`
+	Fecha         time.Time      `json:"fecha"`
+	HoraInicio    time.Time      `json:"hora_inicio"`
+	HoraFin       time.Time      `json:"hora_fin"`
+	OcupadoInicio time.Time      `json:"ocupado_inicio"`
+	OcupadoFin    time.Time      `json:"ocupado_fin"`
+	Precio        sql.NullString `json:"precio"`
+	ID            int32          `json:"id"`
+	BarberiaID    int32          `json:"barberia_id"`
+}
+
+// Mueve un turno activo a otro horario (y eventualmente otro barbero). La
+// duración y el precio son los del barbero que lo va a atender.
+func (q *Queries) UpdateTurnoHorario(ctx context.Context, arg UpdateTurnoHorarioParams) (Turno, error) {
+	row := q.db.QueryRowContext(ctx, updateTurnoHorario,
+		arg.BarberoID,
+		arg.Fecha,
+		arg.HoraInicio,
+		arg.HoraFin,
+		arg.OcupadoInicio,
+		arg.OcupadoFin,
+		arg.Precio,
+		arg.ID,
+		arg.BarberiaID,
+	)
+	var i Turno
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.BarberoID,
+		&i.ServicioID,
+		&i.Fecha,
+		&i.HoraInicio,
+		&i.HoraFin,
+		&i.ClienteNombre,
+		&i.ClienteTelefono,
+		&i.Estado,
+		&i.CreadoEn,
+		&i.OcupadoInicio,
+		&i.OcupadoFin,
+		&i.ConfirmadoEn,
+		&i.CompletadoEn,
+		&i.NoAsistioEn,
+		&i.CanceladoEn,
+		&i.TokenHash,
+		&i.ClienteID,
+		&i.Precio,
+	)
+	return i, err
+}
@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createUsuario = `-- name: CreateUsuario :one
@@ -52,6 +54,37 @@ func (q *Queries) CreateUsuario(ctx context.Context, arg CreateUsuarioParams) (U
 	return i, err
 }
 
+const getBarbero = `-- name: GetBarbero :one
+SELECT id, barberia_id, nombre, apellido, username, email, password_hash, rol, activo
+FROM usuarios
+WHERE id = $1
+  AND barberia_id = $2
+  AND rol = 'barbero'
+`
+
+type GetBarberoParams struct {
+	ID         int32 `json:"id"`
+	BarberiaID int32 `json:"barberia_id"`
+}
+
+// Incluye los inactivos, para poder editarlos y reactivarlos.
+func (q *Queries) GetBarbero(ctx context.Context, arg GetBarberoParams) (Usuario, error) {
+	row := q.db.QueryRowContext(ctx, getBarbero, arg.ID, arg.BarberiaID)
+	var i Usuario
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.Nombre,
+		&i.Apellido,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Rol,
+		&i.Activo,
+	)
+	return i, err
+}
+
 const getUsuarioByEmail = `-- name: GetUsuarioByEmail :one
 SELECT id, barberia_id, nombre, apellido, username, email, password_hash, rol, activo
 FROM usuarios
@@ -76,6 +109,30 @@ func (q *Queries) GetUsuarioByEmail(ctx context.Context, email string) (Usuario,
 	return i, err
 }
 
+const getUsuarioByID = `-- name: GetUsuarioByID :one
+SELECT id, barberia_id, nombre, apellido, username, email, password_hash, rol, activo
+FROM usuarios
+WHERE id = $1
+  AND activo = true
+`
+
+func (q *Queries) GetUsuarioByID(ctx context.Context, id int32) (Usuario, error) {
+	row := q.db.QueryRowContext(ctx, getUsuarioByID, id)
+	var i Usuario
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.Nombre,
+		&i.Apellido,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Rol,
+		&i.Activo,
+	)
+	return i, err
+}
+
 const getUsuarioByUsername = `-- name: GetUsuarioByUsername :one
 SELECT id, barberia_id, nombre, apellido, username, email, password_hash, rol, activo
 FROM usuarios
@@ -137,3 +194,170 @@ func (q *Queries) ListBarberos(ctx context.Context, barberiaID int32) ([]ListBar
 	}
 	return items, nil
 }
+
+const listBarberosPorCarga = `-- name: ListBarberosPorCarga :many
+SELECT u.id, u.nombre, COUNT(t.id) AS turnos_del_dia
+FROM usuarios u
+LEFT JOIN turnos t
+  ON t.barbero_id = u.id
+  AND t.fecha = $2
+  AND t.estado != 'cancelado'
+WHERE u.barberia_id = $1
+  AND u.rol = 'barbero'
+  AND u.activo = true
+GROUP BY u.id, u.nombre
+ORDER BY turnos_del_dia,
+  (SELECT MAX(creado_en) FROM turnos WHERE barbero_id = u.id) NULLS FIRST,
+  u.id
+`
+
+type ListBarberosPorCargaParams struct {
+	BarberiaID int32     `json:"barberia_id"`
+	Fecha      time.Time `json:"fecha"`
+}
+
+type ListBarberosPorCargaRow struct {
+	ID           int32  `json:"id"`
+	Nombre       string `json:"nombre"`
+	TurnosDelDia int64  `json:"turnos_del_dia"`
+}
+
+// Barberos activos ordenados por carga del día y, a igual carga, por quién
+// recibió un turno hace más tiempo (round-robin).
+func (q *Queries) ListBarberosPorCarga(ctx context.Context, arg ListBarberosPorCargaParams) ([]ListBarberosPorCargaRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBarberosPorCarga, arg.BarberiaID, arg.Fecha)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBarberosPorCargaRow
+	for rows.Next() {
+		var i ListBarberosPorCargaRow
+		if err := rows.Scan(&i.ID, &i.Nombre, &i.TurnosDelDia); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockBarbero = `-- name: LockBarbero :one
+SELECT id
+FROM usuarios
+WHERE id = $1
+  AND barberia_id = $2
+  AND rol = 'barbero'
+  AND activo = true
+FOR UPDATE
+`
+
+type LockBarberoParams struct {
+	ID         int32 `json:"id"`
+	BarberiaID int32 `json:"barberia_id"`
+}
+
+func (q *Queries) LockBarbero(ctx context.Context, arg LockBarberoParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, lockBarbero, arg.ID, arg.BarberiaID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setBarberoActivo = `-- name: SetBarberoActivo :one
+UPDATE usuarios
+SET activo = $3
+WHERE id = $1
+  AND barberia_id = $2
+  AND rol = 'barbero'
+RETURNING id, barberia_id, nombre, apellido, username, email, password_hash, rol, activo
+`
+
+type SetBarberoActivoParams struct {
+	ID         int32        `json:"id"`
+	BarberiaID int32        `json:"barberia_id"`
+	Activo     sql.NullBool `json:"activo"`
+}
+
+func (q *Queries) SetBarberoActivo(ctx context.Context, arg SetBarberoActivoParams) (Usuario, error) {
+	row := q.db.QueryRowContext(ctx, setBarberoActivo, arg.ID, arg.BarberiaID, arg.Activo)
+	var i Usuario
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.Nombre,
+		&i.Apellido,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Rol,
+		&i.Activo,
+	)
+	return i, err
+}
+
+const updateBarbero = `-- name: UpdateBarbero :one
+UPDATE usuarios
+SET nombre = $3,
+    apellido = $4,
+    username = $5,
+    email = $6
+WHERE id = $1
+  AND barberia_id = $2
+  AND rol = 'barbero'
+RETURNING id, barberia_id, nombre, apellido, username, email, password_hash, rol, activo
+`
+
+type UpdateBarberoParams struct {
+	ID         int32  `json:"id"`
+	BarberiaID int32  `json:"barberia_id"`
+	Nombre     string `json:"nombre"`
+	Apellido   string `json:"apellido"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+}
+
+func (q *Queries) UpdateBarbero(ctx context.Context, arg UpdateBarberoParams) (Usuario, error) {
+	row := q.db.QueryRowContext(ctx, updateBarbero,
+		arg.ID,
+		arg.BarberiaID,
+		arg.Nombre,
+		arg.Apellido,
+		arg.Username,
+		arg.Email,
+	)
+	var i Usuario
+	err := row.Scan(
+		&i.ID,
+		&i.BarberiaID,
+		&i.Nombre,
+		&i.Apellido,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Rol,
+		&i.Activo,
+	)
+	return i, err
+}
+
+const updateUsuarioPassword = `-- name: UpdateUsuarioPassword :exec
+UPDATE usuarios
+SET password_hash = $2
+WHERE id = $1
+`
+
+type UpdateUsuarioPasswordParams struct {
+	ID           int32  `json:"id"`
+	PasswordHash string `json:"password_hash"`
+}
+
+func (q *Queries) UpdateUsuarioPassword(ctx context.Context, arg UpdateUsuarioPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUsuarioPassword, arg.ID, arg.PasswordHash)
+	return err
+}
@@ -1,84 +1,272 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/mail"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Recuerda: En producción usa os.Getenv("JWT_SECRET")
-var jwtKey = []byte("mi_clave_super_secreta_123")
+// Duración de las sesiones: el access token es corto y se renueva con el
+// refresh token, que vive en la DB y se puede revocar.
+const (
+	duracionAccessToken  = 15 * time.Minute
+	duracionRefreshToken = 30 * 24 * time.Hour
+)
 
 type Credentials struct {
-	Username string `json:"username"` // <-- Ahora pedimos Username
-	Password string `json:"password"`
+	Username string `json:"username" validar:"requerido,max=50"` // <-- Ahora pedimos Username
+	Password string `json:"password" validar:"requerido,max=72"`
 }
 
 type Claims struct {
-	UserID int32  `json:"user_id"`
-	Rol    string `json:"rol"`
+	UserID     int32  `json:"user_id"`
+	BarberiaID int32  `json:"barberia_id"`
+	Rol        string `json:"rol"`
 	jwt.RegisteredClaims
 }
 
 type AuthHandler struct {
 	Queries *db.Queries
+	DB      *sql.DB
+	Mail    mail.Sender
+	URLBase string // para armar el link de recuperación (ej. https://agendafacil.com)
 }
 
-func NewAuthHandler(q *db.Queries) *AuthHandler {
-	return &AuthHandler{Queries: q}
+func NewAuthHandler(q *db.Queries, conn *sql.DB, sender mail.Sender) *AuthHandler {
+	return &AuthHandler{Queries: q, DB: conn, Mail: sender}
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	// 1. Decodificar JSON
 	var creds Credentials
-	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
+	if !decodificarJSON(w, r, &creds) {
+		return
+	}
+
+	// 2. Contar el intento antes de verificarlo, y frenar si el usuario o la
+	// IP acumulan demasiados fallos
+	ctx := r.Context()
+	ip := clientIP(r)
+	espera, reserva, err := h.reservarIntento(ctx, map[string]politicaLogin{
+		claveUsuario(creds.Username): politicaUsuario,
+		claveIP(ip):                  politicaIP,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error verificando credenciales")
+		return
+	}
+	if espera > 0 {
+		h.auditarLogin(ctx, creds.Username, ip, "bloqueado")
+		responderDemasiadosIntentos(w, r, espera)
+		return
+	}
+
+	// 3. Buscar usuario en DB (Usando la nueva query por Username) y verificar contraseña
+	motivo := ""
+	usuario, err := h.Queries.GetUsuarioByUsername(ctx, creds.Username)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		motivo = "usuario_inexistente"
+	case err != nil:
+		errorInterno(w, r, err, "Error verificando credenciales")
+		return
+	case bcrypt.CompareHashAndPassword([]byte(usuario.PasswordHash), []byte(creds.Password)) != nil:
+		motivo = "password_incorrecto"
+	}
+	if motivo != "" {
+		// El intento ya quedó contado al reservarlo
+		h.auditarLogin(ctx, creds.Username, ip, motivo)
+		responderError(w, r, http.StatusUnauthorized, CodigoCredenciales, "Usuario o contraseña incorrectos")
+		return
+	}
+
+	// El usuario entró: se limpian sus fallos y a la IP se le devuelve el
+	// intento reservado (sus fallos anteriores siguen contando)
+	if err := h.Queries.DeleteLoginLimite(ctx, claveUsuario(creds.Username)); err != nil {
+		log.Println("Error limpiando fallos de login:", err)
+	}
+	if err := h.liberarIntento(ctx, reserva, claveIP(ip)); err != nil {
+		log.Println("Error liberando intento de login:", err)
+	}
+
+	// 4. Generar access token y refresh token
+	h.emitirSesion(w, r, usuario)
+}
+
+// SesionResponse es lo que devuelven /login y /refresh
+type SesionResponse struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiraEn     time.Time `json:"expira_en"` // vencimiento del access token
+	Rol          string    `json:"rol"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validar:"requerido"`
+}
+
+// Refresh canjea un refresh token por una sesión nueva. El token usado queda
+// revocado (rotación); si alguien presenta uno ya revocado se asume que fue
+// robado y se cierran todas las sesiones del usuario.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req RefreshRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	rt, err := h.Queries.GetRefreshToken(ctx, hashToken(req.RefreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Refresh token inválido")
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error renovando sesión")
+		return
+	}
+	if ahora().UTC().After(rt.ExpiraEn) {
+		responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Refresh token vencido")
 		return
 	}
 
-	// 2. Buscar usuario en DB (Usando la nueva query por Username)
-	usuario, err := h.Queries.GetUsuarioByUsername(r.Context(), creds.Username)
+	n, err := h.Queries.RevokeRefreshToken(ctx, rt.ID)
 	if err != nil {
-		http.Error(w, "Usuario o contraseña incorrectos", http.StatusUnauthorized)
+		errorInterno(w, r, err, "Error renovando sesión")
+		return
+	}
+	if n == 0 {
+		// Reuso de un token ya rotado o revocado
+		if err := h.Queries.RevokeRefreshTokensUsuario(ctx, rt.UsuarioID); err != nil {
+			errorInterno(w, r, err, "Error renovando sesión")
+			return
+		}
+		responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Refresh token inválido")
+		return
+	}
+
+	// Se vuelve a leer el usuario: rol, barbería o baja pueden haber cambiado
+	usuario, err := h.Queries.GetUsuarioByID(ctx, rt.UsuarioID)
+	if err != nil {
+		responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Usuario no disponible")
+		return
+	}
+
+	h.emitirSesion(w, r, usuario)
+}
+
+// Logout revoca el refresh token. Responde 204 aunque el token no exista,
+// para no revelar nada; el access token vence solo en pocos minutos.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if !decodificarJSON(w, r, &req) {
 		return
 	}
 
-	// 3. Verificar Contraseña
-	err = bcrypt.CompareHashAndPassword([]byte(usuario.PasswordHash), []byte(creds.Password))
+	rt, err := h.Queries.GetRefreshToken(r.Context(), hashToken(req.RefreshToken))
+	if err == nil {
+		_, err = h.Queries.RevokeRefreshToken(r.Context(), rt.ID)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		errorInterno(w, r, err, "Error cerrando sesión")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// emitirSesion genera el par access/refresh y lo responde
+func (h *AuthHandler) emitirSesion(w http.ResponseWriter, r *http.Request, usuario db.Usuario) {
+	sesion, err := h.crearSesion(r.Context(), usuario)
 	if err != nil {
-		http.Error(w, "Usuario o contraseña incorrectos", http.StatusUnauthorized)
+		errorInterno(w, r, err, "Error generando token")
 		return
 	}
 
-	// 4. Generar Token
-	expirationTime := time.Now().Add(24 * time.Hour)
+	writeJSON(w, sesion)
+}
+
+// crearSesion firma el access token y guarda un refresh token nuevo
+func (h *AuthHandler) crearSesion(ctx context.Context, usuario db.Usuario) (SesionResponse, error) {
+	tokenString, expira, err := generarToken(usuario)
+	if err != nil {
+		return SesionResponse{}, err
+	}
+
+	refresh, refreshHash, err := nuevoToken()
+	if err != nil {
+		return SesionResponse{}, err
+	}
+	_, err = h.Queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		UsuarioID: usuario.ID,
+		TokenHash: refreshHash,
+		ExpiraEn:  ahora().UTC().Add(duracionRefreshToken), // la columna es TIMESTAMP sin zona: siempre UTC
+	})
+	if err != nil {
+		return SesionResponse{}, err
+	}
+
+	return SesionResponse{
+		Token:        tokenString,
+		RefreshToken: refresh,
+		ExpiraEn:     expira,
+		Rol:          usuario.Rol,
+	}, nil
+}
+
+// generarToken firma el access token con la clave activa, indicando su kid
+// en el header para poder verificarlo después de una rotación.
+func generarToken(usuario db.Usuario) (string, time.Time, error) {
+	expirationTime := time.Now().Add(duracionAccessToken)
 	claims := &Claims{
-		UserID: usuario.ID,
-		Rol:    usuario.Rol,
+		UserID:     usuario.ID,
+		BarberiaID: usuario.BarberiaID,
+		Rol:        usuario.Rol,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
 	}
 
+	clave, ok := clavesJWT.Claves[clavesJWT.Activa]
+	if !ok {
+		return "", time.Time{}, ErrSinClavesJWT
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
-	if err != nil {
-		http.Error(w, "Error generando token", http.StatusInternalServerError)
-		return
+	token.Header["kid"] = clavesJWT.Activa
+	firmado, err := token.SignedString(clave)
+	return firmado, expirationTime, err
+}
+
+// claveDeToken elige la clave de verificación según el kid del token
+func claveDeToken(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	clave, ok := clavesJWT.Claves[kid]
+	if !ok {
+		return nil, fmt.Errorf("kid desconocido: %q", kid)
 	}
+	return clave, nil
+}
 
-	// 5. Responder
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"token": tokenString,
-		"rol":   usuario.Rol,
-	})
+// claimsKey es la clave privada del contexto donde viajan los Claims
+type claimsKey struct{}
+
+// ClaimsFromContext devuelve los Claims que dejó AuthMiddleware en el request
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
 }
 
 // AuthMiddleware verifica que el usuario tenga un token válido
@@ -87,13 +275,13 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// 1. Leer el header Authorization: "Bearer <token>"
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Se requiere autenticación", http.StatusUnauthorized)
+			responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Se requiere autenticación")
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Formato de token inválido", http.StatusUnauthorized)
+			responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Formato de token inválido")
 			return
 		}
 
@@ -101,19 +289,58 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		// 2. Parsear y validar el token
 		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
+		token, err := jwt.ParseWithClaims(tokenStr, claims, claveDeToken, jwt.WithValidMethods([]string{"HS256"}))
 
 		if err != nil || !token.Valid {
-			http.Error(w, "Token inválido o expirado", http.StatusUnauthorized)
+			responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Token inválido o expirado")
 			return
 		}
 
-		// (Opcional) Podrías guardar el UserID en el contexto aquí si lo necesitas luego
-		// ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-		// next.ServeHTTP(w, r.WithContext(ctx))
-
-		next.ServeHTTP(w, r)
+		// 3. Dejar los claims en el contexto para los handlers y TenantGuard
+		ctx := context.WithValue(r.Context(), claimsKey{}, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// BarberiaResolver traduce el {slug} de la URL al id de la barbería
+type BarberiaResolver func(ctx context.Context, slug string) (int32, error)
+
+// ResolverBarberia resuelve el slug contra la DB
+func ResolverBarberia(q *db.Queries) BarberiaResolver {
+	return func(ctx context.Context, slug string) (int32, error) {
+		// Incluye inactivas, para que el admin pueda volver a abrirla
+		barberia, err := q.GetBarberiaBySlugAdmin(ctx, slug)
+		return barberia.ID, err
+	}
+}
+
+// TenantGuard corta con 403 cualquier request autenticado cuyo {slug} no sea
+// la barbería del usuario. Va después de AuthMiddleware.
+func TenantGuard(resolver BarberiaResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Se requiere autenticación")
+				return
+			}
+
+			barberiaID, err := resolver(r.Context(), chi.URLParam(r, "slug"))
+			if errors.Is(err, sql.ErrNoRows) {
+				responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+				return
+			}
+			if err != nil {
+				errorInterno(w, r, err, "Error verificando permisos")
+				return
+			}
+
+			if claims.BarberiaID != barberiaID {
+				responderError(w, r, http.StatusForbidden, CodigoSinPermiso, "No tenés permisos sobre esta barbería")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/mail"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestMain configura una clave JWT: sin ella no se firma ningún token
+func TestMain(m *testing.M) {
+	ConfigurarClavesJWT(ClavesDesarrollo())
+	os.Exit(m.Run())
+}
+
+// resolverTest simula la DB con dos barberías: "a" (id 1) y "b" (id 2)
+func resolverTest(_ context.Context, slug string) (int32, error) {
+	ids := map[string]int32{"a": 1, "b": 2}
+	id, ok := ids[slug]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+// routerProtegidoTest arma una ruta protegida igual que en main.go
+func routerProtegidoTest(t *testing.T) http.Handler {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(AuthMiddleware)
+		r.Use(TenantGuard(resolverTest))
+		r.Post("/b/{slug}/servicios", func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok || claims.UserID == 0 {
+				t.Error("El handler debería recibir los claims en el contexto")
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+	return r
+}
+
+// tokenTest firma un token para un usuario de la barbería indicada
+func tokenTest(t *testing.T, barberiaID int32, rol string) string {
+	t.Helper()
+
+	token, _, err := generarToken(db.Usuario{ID: 7, BarberiaID: barberiaID, Rol: rol})
+	if err != nil {
+		t.Fatalf("Error generando token: %v", err)
+	}
+	return token
+}
+
+// TestTenantGuard tests que un usuario sólo pueda operar sobre su barbería
+func TestTenantGuard(t *testing.T) {
+	r := routerProtegidoTest(t)
+
+	casos := []struct {
+		nombre string
+		slug   string
+		token  string
+		want   int
+	}{
+		{"sin token", "a", "", http.StatusUnauthorized},
+		{"token inválido", "a", "basura", http.StatusUnauthorized},
+		{"su propia barbería", "a", tokenTest(t, 1, "admin"), http.StatusNoContent},
+		{"otra barbería", "b", tokenTest(t, 1, "admin"), http.StatusForbidden},
+		{"barbero de otra barbería", "a", tokenTest(t, 2, "barbero"), http.StatusForbidden},
+		{"token sin barbería (viejo)", "a", tokenTest(t, 0, "admin"), http.StatusForbidden},
+		{"barbería inexistente", "zzz", tokenTest(t, 1, "admin"), http.StatusNotFound},
+	}
+	for _, c := range casos {
+		req := httptest.NewRequest(http.MethodPost, "/b/"+c.slug+"/servicios", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != c.want {
+			t.Errorf("%s: se esperaba %d, pero se obtuvo %d", c.nombre, c.want, rec.Code)
+		}
+	}
+}
+
+// TestClaimsFromContext_SinAuth tests que sin AuthMiddleware no haya claims
+func TestClaimsFromContext_SinAuth(t *testing.T) {
+	if _, ok := ClaimsFromContext(context.Background()); ok {
+		t.Error("Un contexto vacío no debería tener claims")
+	}
+}
+
+// entornoTest simula os.Getenv con un mapa
+func entornoTest(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+// usarClavesTest reemplaza las claves JWT durante el test
+func usarClavesTest(t *testing.T, c ClavesJWT) {
+	t.Helper()
+
+	anteriores := clavesJWT
+	ConfigurarClavesJWT(c)
+	t.Cleanup(func() { ConfigurarClavesJWT(anteriores) })
+}
+
+const (
+	secretoViejo = "secreto-viejo-de-al-menos-32-caracteres"
+	secretoNuevo = "secreto-nuevo-de-al-menos-32-caracteres"
+)
+
+// TestCargarClavesJWT tests la lectura de claves desde el entorno
+func TestCargarClavesJWT(t *testing.T) {
+	c, err := CargarClavesJWT(entornoTest(map[string]string{
+		"JWT_KEYS": "v1:" + secretoViejo + ", v2:" + secretoNuevo,
+	}))
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if c.Activa != "v2" || len(c.Claves) != 2 {
+		t.Errorf("Se esperaba v2 activa entre 2 claves, se obtuvo %q con %d", c.Activa, len(c.Claves))
+	}
+
+	c, err = CargarClavesJWT(entornoTest(map[string]string{
+		"JWT_KEYS":       "v1:" + secretoViejo + ",v2:" + secretoNuevo,
+		"JWT_ACTIVE_KID": "v1",
+	}))
+	if err != nil || c.Activa != "v1" {
+		t.Errorf("JWT_ACTIVE_KID debería elegir v1, se obtuvo %q (%v)", c.Activa, err)
+	}
+
+	c, err = CargarClavesJWT(entornoTest(map[string]string{"JWT_SECRET": secretoNuevo}))
+	if err != nil || c.Activa != "default" {
+		t.Errorf("JWT_SECRET debería cargar la clave default, se obtuvo %q (%v)", c.Activa, err)
+	}
+
+	if _, err := CargarClavesJWT(entornoTest(nil)); !errors.Is(err, ErrSinClavesJWT) {
+		t.Errorf("Sin variables se esperaba ErrSinClavesJWT, se obtuvo %v", err)
+	}
+
+	invalidos := []map[string]string{
+		{"JWT_SECRET": "corto"},
+		{"JWT_KEYS": "sin-separador"},
+		{"JWT_KEYS": "v1:" + secretoViejo + ",v1:" + secretoNuevo},
+		{"JWT_KEYS": "v1:" + secretoViejo, "JWT_ACTIVE_KID": "v9"},
+	}
+	for _, vars := range invalidos {
+		if _, err := CargarClavesJWT(entornoTest(vars)); err == nil || errors.Is(err, ErrSinClavesJWT) {
+			t.Errorf("Se esperaba un error de configuración para %v", vars)
+		}
+	}
+}
+
+// TestSinClavesJWT tests que sin claves configuradas no haya ninguna clave
+// conocida firmando ni aceptando tokens
+func TestSinClavesJWT(t *testing.T) {
+	token := tokenTest(t, 1, RolAdmin)
+	usarClavesTest(t, ClavesJWT{})
+
+	if _, _, err := generarToken(db.Usuario{ID: 7, BarberiaID: 1, Rol: RolAdmin}); !errors.Is(err, ErrSinClavesJWT) {
+		t.Errorf("Sin claves se esperaba ErrSinClavesJWT, se obtuvo %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/b/a/servicios", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	routerProtegidoTest(t).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Sin claves el token debería rechazarse, se obtuvo %d", rec.Code)
+	}
+
+	if a, b := ClavesDesarrollo(), ClavesDesarrollo(); string(a.Claves["dev"]) == string(b.Claves["dev"]) || len(a.Claves["dev"]) < largoMinimoClave {
+		t.Error("La clave de desarrollo debería ser al azar y de al menos 32 bytes")
+	}
+}
+
+// TestRotacionClavesJWT tests que los tokens firmados con una clave anterior
+// sigan valiendo mientras la clave se mantenga en JWT_KEYS.
+func TestRotacionClavesJWT(t *testing.T) {
+	r := routerProtegidoTest(t)
+	llamar := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/b/a/servicios", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	usarClavesTest(t, ClavesJWT{Activa: "v1", Claves: map[string][]byte{"v1": []byte(secretoViejo)}})
+	viejo := tokenTest(t, 1, RolAdmin)
+
+	// Rotación: v2 firma, v1 sigue aceptándose
+	ConfigurarClavesJWT(ClavesJWT{Activa: "v2", Claves: map[string][]byte{
+		"v1": []byte(secretoViejo),
+		"v2": []byte(secretoNuevo),
+	}})
+	nuevo := tokenTest(t, 1, RolAdmin)
+
+	if code := llamar(viejo); code != http.StatusNoContent {
+		t.Errorf("El token con la clave anterior debería seguir valiendo, se obtuvo %d", code)
+	}
+	if code := llamar(nuevo); code != http.StatusNoContent {
+		t.Errorf("El token con la clave nueva debería valer, se obtuvo %d", code)
+	}
+
+	// Retiro de v1: sus tokens dejan de valer
+	ConfigurarClavesJWT(ClavesJWT{Activa: "v2", Claves: map[string][]byte{"v2": []byte(secretoNuevo)}})
+	if code := llamar(viejo); code != http.StatusUnauthorized {
+		t.Errorf("El token con una clave retirada debería dar 401, se obtuvo %d", code)
+	}
+	if code := llamar(nuevo); code != http.StatusNoContent {
+		t.Errorf("El token con la clave activa debería valer, se obtuvo %d", code)
+	}
+}
+
+// TestRefresh_RotacionYLogout cubre el ciclo de sesión contra la DB: login,
+// refresh con rotación, detección de reuso y logout.
+func TestRefresh_RotacionYLogout(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, _, _ := fixtureBarberia(t, conn)
+	q := db.New(conn)
+	h := NewAuthHandler(q, conn, mail.LogSender{})
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("clave123"), bcrypt.MinCost)
+	usuario, err := q.CreateUsuario(context.Background(), db.CreateUsuarioParams{
+		BarberiaID:   barberia.ID,
+		Nombre:       "Admin",
+		Apellido:     "Test",
+		Username:     "admin" + barberia.Slug,
+		Email:        "admin" + barberia.Slug + "@test.com",
+		PasswordHash: string(hash),
+		Rol:          RolAdmin,
+	})
+	if err != nil {
+		t.Fatalf("Error creando usuario: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Post("/login", h.Login)
+	r.Post("/refresh", h.Refresh)
+	r.Post("/logout", h.Logout)
+	post := func(ruta string, body any) (*httptest.ResponseRecorder, SesionResponse) {
+		b, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ruta, bytes.NewReader(b)))
+		var sesion SesionResponse
+		json.NewDecoder(bytes.NewReader(rec.Body.Bytes())).Decode(&sesion)
+		return rec, sesion
+	}
+
+	rec, login := post("/login", Credentials{Username: usuario.Username, Password: "clave123"})
+	if rec.Code != http.StatusOK || login.RefreshToken == "" {
+		t.Fatalf("Login: se esperaba 200 con refresh token, se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	rec, renovada := post("/refresh", RefreshRequest{RefreshToken: login.RefreshToken})
+	if rec.Code != http.StatusOK || renovada.RefreshToken == login.RefreshToken {
+		t.Fatalf("Refresh: se esperaba 200 con un refresh token nuevo, se obtuvo %d", rec.Code)
+	}
+
+	// Reusar el token rotado revoca todas las sesiones del usuario
+	if rec, _ := post("/refresh", RefreshRequest{RefreshToken: login.RefreshToken}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Reuso: se esperaba 401, se obtuvo %d", rec.Code)
+	}
+	if rec, _ := post("/refresh", RefreshRequest{RefreshToken: renovada.RefreshToken}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Tras detectar reuso la sesión renovada también debería quedar revocada, se obtuvo %d", rec.Code)
+	}
+
+	// Logout revoca el refresh token de una sesión nueva
+	_, otra := post("/login", Credentials{Username: usuario.Username, Password: "clave123"})
+	if rec, _ := post("/logout", RefreshRequest{RefreshToken: otra.RefreshToken}); rec.Code != http.StatusNoContent {
+		t.Errorf("Logout: se esperaba 204, se obtuvo %d", rec.Code)
+	}
+	if rec, _ := post("/refresh", RefreshRequest{RefreshToken: otra.RefreshToken}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Refresh tras logout: se esperaba 401, se obtuvo %d", rec.Code)
+	}
+}
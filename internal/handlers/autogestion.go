@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ahora es el reloj de la autogestión; los tests lo reemplazan
+var ahora = time.Now
+
+// nuevoToken genera un token opaco (32 bytes aleatorios en base64
+// URL-safe) y el hash que se guarda en la DB.
+func nuevoToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken es el SHA-256 en hex del token. Alcanza con un hash rápido porque
+// el token ya tiene 256 bits de entropía.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// avisoCumplido indica si todavía se respeta la anticipación mínima de la
+// barbería para cambiar un turno que empieza en inicio.
+func avisoCumplido(barberia db.Barberia, inicio, now time.Time) bool {
+	limite := inicio.Add(-time.Duration(barberia.AvisoCambioMinutos) * time.Minute)
+	return !now.After(limite)
+}
+
+// turnoActivo indica si el cliente todavía puede cancelar o mover el turno
+func turnoActivo(estado string) bool {
+	return estado == EstadoPendiente || estado == EstadoConfirmado
+}
+
+// TurnoClienteResponse es lo que ve el cliente de su turno (sin datos internos)
+type TurnoClienteResponse struct {
+	ID               int32  `json:"id"`
+	Estado           string `json:"estado"`
+	Fecha            string `json:"fecha"`       // YYYY-MM-DD
+	HoraInicio       string `json:"hora_inicio"` // HH:MM
+	HoraFin          string `json:"hora_fin"`    // HH:MM
+	ServicioID       int32  `json:"servicio_id"`
+	ServicioNombre   string `json:"servicio_nombre"`
+	BarberoID        int32  `json:"barbero_id"`
+	BarberoNombre    string `json:"barbero_nombre"`
+	ClienteNombre    string `json:"cliente_nombre"`
+	PuedeModificar   bool   `json:"puede_modificar"`
+	ModificableHasta string `json:"modificable_hasta"` // RFC 3339
+}
+
+// ReprogramarRequest pide mover el turno a otro horario. Sin barbero_id se
+// mantiene el barbero actual; con 0 se asigna cualquiera libre.
+type ReprogramarRequest struct {
+	Fecha      string `json:"fecha" validar:"requerido,fecha"`      // YYYY-MM-DD
+	HoraInicio string `json:"hora_inicio" validar:"requerido,hora"` // HH:MM
+	BarberoID  *int32 `json:"barbero_id"`
+}
+
+// GetReserva muestra el turno asociado al token
+func (h *BarberiaHandler) GetReserva(w http.ResponseWriter, r *http.Request) {
+	barberia, turno, ok := h.turnoDeToken(w, r)
+	if !ok {
+		return
+	}
+
+	h.responderTurnoCliente(w, r, barberia, turno)
+}
+
+// CancelarReserva cancela el turno si se respeta la anticipación mínima
+func (h *BarberiaHandler) CancelarReserva(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, turno, ok := h.turnoDeToken(w, r)
+	if !ok {
+		return
+	}
+
+	actual := estadoTurno(turno)
+	if !transicionValida(actual, EstadoCancelado) {
+		responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "No se puede cancelar un turno "+actual)
+		return
+	}
+	if !avisoCumplido(barberia, momentoTurno(barberia, turno.Fecha, turno.HoraInicio), ahora()) {
+		responderError(w, r, http.StatusConflict, CodigoFueraDePlazo, "Los cambios deben hacerse con al menos "+strconv.Itoa(int(barberia.AvisoCambioMinutos))+" minutos de anticipación")
+		return
+	}
+
+	cancelado, err := h.Queries.UpdateTurnoEstado(ctx, db.UpdateTurnoEstadoParams{
+		EstadoNuevo:  toNullString(EstadoCancelado),
+		ID:           turno.ID,
+		BarberiaID:   barberia.ID,
+		EstadoActual: actual,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "El turno cambió de estado, volvé a intentar")
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error cancelando turno")
+		return
+	}
+
+	h.responderTurnoCliente(w, r, barberia, cancelado)
+}
+
+// ReprogramarReserva mueve el turno a otro horario disponible, con las mismas
+// validaciones y protección contra superposición que una reserva nueva.
+// Duración y precio se recalculan como en una reserva nueva.
+func (h *BarberiaHandler) ReprogramarReserva(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, turno, ok := h.turnoDeToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req ReprogramarRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+	fecha, _ := time.Parse("2006-01-02", req.Fecha)
+	horaInicio, _ := time.Parse("15:04", req.HoraInicio)
+
+	actual := estadoTurno(turno)
+	if !turnoActivo(actual) {
+		responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "No se puede reprogramar un turno "+actual)
+		return
+	}
+	// El aviso rige tanto para el turno actual como para el nuevo horario
+	now := ahora()
+	if !avisoCumplido(barberia, momentoTurno(barberia, turno.Fecha, turno.HoraInicio), now) ||
+		!avisoCumplido(barberia, momentoTurno(barberia, fecha, horaInicio), now) {
+		responderError(w, r, http.StatusConflict, CodigoFueraDePlazo, "Los cambios deben hacerse con al menos "+strconv.Itoa(int(barberia.AvisoCambioMinutos))+" minutos de anticipación")
+		return
+	}
+	// El nuevo horario tiene que respetar la ventana de reserva, como uno nuevo
+	if err := ventanaReserva(barberia, fecha, horaInicio, now); err != nil {
+		responderErrorAgenda(w, r, err)
+		return
+	}
+
+	// El servicio puede haberse dado de baja después de reservar: el turno
+	// se mueve igual, con el servicio que el cliente reservó
+	servicio, err := h.Queries.GetServicioDeBarberia(ctx, db.GetServicioDeBarberiaParams{
+		ID:         turno.ServicioID,
+		BarberiaID: barberia.ID,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo servicio")
+		return
+	}
+
+	barberoID := turno.BarberoID
+	if req.BarberoID != nil {
+		barberoID = *req.BarberoID
+	}
+
+	movido, _, err := h.agendar(ctx, barberia, servicio, barberoID, fecha, horaInicio, turno.ID,
+		func(qtx *db.Queries, u ubicacion) (db.Turno, error) {
+			t, err := qtx.UpdateTurnoHorario(ctx, db.UpdateTurnoHorarioParams{
+				BarberoID:     u.Barbero.ID,
+				Fecha:         fecha,
+				HoraInicio:    horaInicio,
+				HoraFin:       u.HoraFin,
+				OcupadoInicio: u.OcupadoInicio,
+				OcupadoFin:    u.OcupadoFin,
+				Precio:        toNullString(u.Precio),
+				ID:            turno.ID,
+				BarberiaID:    barberia.ID,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.Turno{}, errTurnoInactivo
+			}
+			return t, err
+		})
+	if errors.Is(err, errTurnoInactivo) {
+		responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "El turno cambió de estado, volvé a intentar")
+		return
+	}
+	if err != nil {
+		responderErrorAgenda(w, r, err)
+		return
+	}
+
+	h.responderTurnoCliente(w, r, barberia, movido)
+}
+
+// errTurnoInactivo: el turno se canceló o cerró mientras se reprogramaba
+var errTurnoInactivo = errors.New("turno inactivo")
+
+// turnoDeToken resuelve {slug} y {token}. Un token inexistente o de otra
+// barbería responde 404 igual, sin distinguir cuál de los dos falló.
+func (h *BarberiaHandler) turnoDeToken(w http.ResponseWriter, r *http.Request) (db.Barberia, db.Turno, bool) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return db.Barberia{}, db.Turno{}, false
+	}
+
+	turno, err := h.Queries.GetTurnoByToken(r.Context(), db.GetTurnoByTokenParams{
+		BarberiaID: barberia.ID,
+		TokenHash:  toNullString(hashToken(chi.URLParam(r, "token"))),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoTurnoNoEncontrado, "Turno no encontrado")
+		return db.Barberia{}, db.Turno{}, false
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo turno")
+		return db.Barberia{}, db.Turno{}, false
+	}
+
+	return barberia, turno, true
+}
+
+// responderTurnoCliente completa los nombres de servicio y barbero y escribe el turno
+func (h *BarberiaHandler) responderTurnoCliente(w http.ResponseWriter, r *http.Request, barberia db.Barberia, turno db.Turno) {
+	ctx := r.Context()
+
+	// Incluye los servicios dados de baja después de reservar
+	servicio, err := h.Queries.GetServicioDeBarberia(ctx, db.GetServicioDeBarberiaParams{
+		ID:         turno.ServicioID,
+		BarberiaID: barberia.ID,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo servicio")
+		return
+	}
+
+	barberos, err := h.Queries.ListBarberosByBarberia(ctx, barberia.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo barberos")
+		return
+	}
+	var barberoNombre string
+	for _, b := range barberos {
+		if b.ID == turno.BarberoID {
+			barberoNombre = b.Nombre
+		}
+	}
+
+	inicio := momentoTurno(barberia, turno.Fecha, turno.HoraInicio)
+	limite := inicio.Add(-time.Duration(barberia.AvisoCambioMinutos) * time.Minute)
+	estado := estadoTurno(turno)
+
+	writeJSON(w, TurnoClienteResponse{
+		ID:               turno.ID,
+		Estado:           estado,
+		Fecha:            turno.Fecha.Format("2006-01-02"),
+		HoraInicio:       turno.HoraInicio.Format("15:04"),
+		HoraFin:          turno.HoraFin.Format("15:04"),
+		ServicioID:       servicio.ID,
+		ServicioNombre:   servicio.Nombre,
+		BarberoID:        turno.BarberoID,
+		BarberoNombre:    barberoNombre,
+		ClienteNombre:    turno.ClienteNombre,
+		PuedeModificar:   turnoActivo(estado) && avisoCumplido(barberia, inicio, ahora()),
+		ModificableHasta: limite.Format(time.RFC3339),
+	})
+}
@@ -3,9 +3,10 @@ package handlers
 import (
 	db "agendaFacil/db/sqlc"
 	"context"
+	"database/sql"
 	"encoding/json"
-	"log"
 	"net/http"
+	"strings"
 	"text/template"
 	"time"
 
@@ -14,10 +15,11 @@ import (
 
 type BarberiaHandler struct {
 	Queries *db.Queries
+	DB      *sql.DB // Necesario para abrir transacciones (reservas)
 }
 
-func NewBarberiaHandler(q *db.Queries) *BarberiaHandler {
-	return &BarberiaHandler{Queries: q}
+func NewBarberiaHandler(q *db.Queries, conn *sql.DB) *BarberiaHandler {
+	return &BarberiaHandler{Queries: q, DB: conn}
 }
 
 func (h *BarberiaHandler) GetBarberiaPublic(w http.ResponseWriter, r *http.Request) {
@@ -26,19 +28,19 @@ func (h *BarberiaHandler) GetBarberiaPublic(w http.ResponseWriter, r *http.Reque
 
 	barberia, err := h.Queries.GetBarberiaBySlug(ctx, slug)
 	if err != nil {
-		http.Error(w, "barbería no encontrada", http.StatusNotFound)
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "barbería no encontrada")
 		return
 	}
 
 	servicios, err := h.Queries.ListServicios(ctx, barberia.ID)
 	if err != nil {
-		http.Error(w, "error servicios", http.StatusInternalServerError)
+		errorInterno(w, r, err, "error servicios")
 		return
 	}
 
 	barberos, err := h.Queries.ListBarberos(ctx, barberia.ID)
 	if err != nil {
-		http.Error(w, "error barberos", http.StatusInternalServerError)
+		errorInterno(w, r, err, "error barberos")
 		return
 	}
 
@@ -58,19 +60,19 @@ func (h *BarberiaHandler) AgendaHTML(w http.ResponseWriter, r *http.Request) {
 
 	fechaStr := r.URL.Query().Get("fecha")
 	if fechaStr == "" {
-		http.Error(w, "falta fecha", http.StatusBadRequest)
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "falta fecha")
 		return
 	}
 
 	fecha, err := time.Parse("2006-01-02", fechaStr)
 	if err != nil {
-		http.Error(w, "fecha invalida", http.StatusBadRequest)
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "fecha invalida")
 		return
 	}
 
 	barberia, err := h.Queries.GetBarberiaBySlug(ctx, slug)
 	if err != nil {
-		http.Error(w, "barberia no encontrada", http.StatusNotFound)
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "barberia no encontrada")
 		return
 	}
 
@@ -82,7 +84,7 @@ func (h *BarberiaHandler) AgendaHTML(w http.ResponseWriter, r *http.Request) {
 		},
 	)
 	if err != nil {
-		http.Error(w, "error obteniendo turnos", http.StatusInternalServerError)
+		errorInterno(w, r, err, "error obteniendo turnos")
 		return
 	}
 
@@ -128,44 +130,209 @@ func (h *BarberiaHandler) AgendaHTML(w http.ResponseWriter, r *http.Request) {
 	tmpl.Execute(w, data)
 }
 
+// OcupadoPublico es un rango ocupado de la agenda pública. No lleva datos
+// del cliente ni del servicio: la agenda la puede ver cualquiera.
+type OcupadoPublico struct {
+	BarberoID int32  `json:"barbero_id"`
+	Inicio    string `json:"inicio"` // HH:MM, buffers incluidos
+	Fin       string `json:"fin"`
+}
+
+// GetAgendaPublic devuelve los horarios ocupados del día, por barbero
 func (h *BarberiaHandler) GetAgendaPublic(w http.ResponseWriter, r *http.Request) {
-	log.Println("Entro handler a agenda")
 	ctx := r.Context()
 
 	slug := chi.URLParam(r, "slug")
-	log.Println("slug:", slug)
 	fechaStr := r.URL.Query().Get("fecha")
-	log.Println("fechaStr:", fechaStr)
 
 	if fechaStr == "" {
-		http.Error(w, "fecha requerida (YYYY-MM-DD)", http.StatusBadRequest)
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "fecha requerida (YYYY-MM-DD)")
 		return
 	}
 
 	fecha, err := time.Parse("2006-01-02", fechaStr)
 	if err != nil {
-		http.Error(w, "formato de fecha inválido", http.StatusBadRequest)
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "formato de fecha inválido")
 		return
 	}
 
 	// 1️⃣ Buscar barbería
 	barberia, err := h.Queries.GetBarberiaBySlug(ctx, slug)
 	if err != nil {
-		http.Error(w, "barbería no encontrada", http.StatusNotFound)
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "barbería no encontrada")
 		return
 	}
 
-	// 2️⃣ Buscar turnos (ESTO PUEDE DEVOLVER [])
-	turnos, err := h.Queries.ListTurnosByFecha(ctx, db.ListTurnosByFechaParams{
+	// 2️⃣ Buscar lo ocupado (ESTO PUEDE DEVOLVER [])
+	ocupados, err := h.Queries.ListTurnosOcupados(ctx, db.ListTurnosOcupadosParams{
 		BarberiaID: barberia.ID,
 		Fecha:      fecha,
 	})
 	if err != nil {
-		http.Error(w, "error al obtener agenda", http.StatusInternalServerError)
+		errorInterno(w, r, err, "error al obtener agenda")
 		return
 	}
 
+	agenda := make([]OcupadoPublico, 0, len(ocupados))
+	for _, o := range ocupados {
+		agenda = append(agenda, OcupadoPublico{
+			BarberoID: o.BarberoID,
+			Inicio:    o.HoraInicio.Format("15:04"),
+			Fin:       o.HoraFin.Format("15:04"),
+		})
+	}
+
 	// 3️⃣ SIEMPRE responder 200
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(turnos)
+	writeJSON(w, agenda)
+}
+
+// ConfiguracionRequest admite cambios parciales: los campos omitidos no se tocan
+type ConfiguracionRequest struct {
+	Nombre               *string `json:"nombre" validar:"max=100"`
+	Slug                 *string `json:"slug" validar:"slug"`
+	HoraApertura         *string `json:"hora_apertura" validar:"hora"`                   // HH:MM
+	HoraCierre           *string `json:"hora_cierre" validar:"hora"`                     // HH:MM
+	Activa               *bool   `json:"activa"`                                         // false cierra la barbería al público
+	ZonaHoraria          *string `json:"zona_horaria" validar:"zona"`                    // IANA, ej. America/Argentina/Buenos_Aires
+	Pais                 *string `json:"pais" validar:"pais"`                            // ISO 3166-1, para los teléfonos sin código internacional
+	IntervaloMinutos     *int32  `json:"intervalo_minutos" validar:"min=5,max=240"`      // grilla de inicios de turno
+	BufferAntesMinutos   *int32  `json:"buffer_antes_minutos" validar:"min=0,max=120"`   // default para servicios sin buffer propio
+	BufferDespuesMinutos *int32  `json:"buffer_despues_minutos" validar:"min=0,max=120"` // default para servicios sin buffer propio
+	AvisoCambioMinutos   *int32  `json:"aviso_cambio_minutos" validar:"min=0,max=10080"` // anticipación mínima para que el cliente cancele o reprograme (hasta una semana)
+
+	// Ventana de reserva y límite por cliente (0 = sin restricción)
+	AnticipacionMinimaMinutos *int32 `json:"anticipacion_minima_minutos" validar:"min=0,max=10080"`
+	AnticipacionMaximaDias    *int32 `json:"anticipacion_maxima_dias" validar:"min=0,max=365"`
+	MaxTurnosActivosCliente   *int32 `json:"max_turnos_activos_cliente" validar:"min=0,max=20"`
+}
+
+// normalizar recorta los textos y trata los vacíos como omitidos (el
+// formulario del panel manda "" en los campos que no se completaron).
+func (req *ConfiguracionRequest) normalizar() {
+	for _, campo := range []**string{&req.Nombre, &req.Slug, &req.HoraApertura, &req.HoraCierre, &req.ZonaHoraria, &req.Pais} {
+		if *campo == nil {
+			continue
+		}
+		v := strings.TrimSpace(**campo)
+		if v == "" {
+			*campo = nil
+			continue
+		}
+		*campo = &v
+	}
+	if req.Pais != nil {
+		v := strings.ToUpper(*req.Pais)
+		req.Pais = &v
+	}
+}
+
+func (req *ConfiguracionRequest) validarCampos() erroresCampos {
+	if v := req.IntervaloMinutos; v != nil && *v%5 != 0 {
+		return erroresCampos{"intervalo_minutos": "debe ser múltiplo de 5"}
+	}
+	return nil
+}
+
+// toNullHora convierte una hora HH:MM ya validada al tipo de SQLC
+func toNullHora(v *string) sql.NullTime {
+	if v == nil {
+		return sql.NullTime{}
+	}
+	t, _ := time.Parse("15:04", *v)
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+// toNullInt32 convierte un campo opcional del JSON al tipo de SQLC
+func toNullInt32(v *int32) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *v, Valid: true}
+}
+
+// UpdateConfiguracion actualiza los datos y los ajustes de agenda de la
+// barbería. También responde en POST /b/{slug}, que es lo que usa el panel.
+func (h *BarberiaHandler) UpdateConfiguracion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	slug := chi.URLParam(r, "slug")
+
+	// Incluye inactivas: es la única forma de volver a abrirla
+	barberia, err := h.Queries.GetBarberiaBySlugAdmin(ctx, slug)
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	var req ConfiguracionRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	params := db.UpdateBarberiaConfiguracionParams{
+		ID:                        barberia.ID,
+		IntervaloMinutos:          toNullInt32(req.IntervaloMinutos),
+		BufferAntesMinutos:        toNullInt32(req.BufferAntesMinutos),
+		BufferDespuesMinutos:      toNullInt32(req.BufferDespuesMinutos),
+		AvisoCambioMinutos:        toNullInt32(req.AvisoCambioMinutos),
+		HoraApertura:              toNullHora(req.HoraApertura),
+		HoraCierre:                toNullHora(req.HoraCierre),
+		AnticipacionMinimaMinutos: toNullInt32(req.AnticipacionMinimaMinutos),
+		AnticipacionMaximaDias:    toNullInt32(req.AnticipacionMaximaDias),
+		MaxTurnosActivosCliente:   toNullInt32(req.MaxTurnosActivosCliente),
+	}
+	if req.Nombre != nil {
+		params.Nombre = toNullString(*req.Nombre)
+	}
+	if req.ZonaHoraria != nil {
+		params.ZonaHoraria = toNullString(*req.ZonaHoraria)
+	}
+	if req.Pais != nil {
+		params.Pais = toNullString(*req.Pais)
+	}
+	if req.Activa != nil {
+		params.Activa = sql.NullBool{Bool: *req.Activa, Valid: true}
+	}
+
+	// La apertura tiene que quedar antes del cierre, con lo nuevo o lo actual
+	apertura, cierre := barberia.HoraApertura, barberia.HoraCierre
+	if params.HoraApertura.Valid {
+		apertura = params.HoraApertura.Time
+	}
+	if params.HoraCierre.Valid {
+		cierre = params.HoraCierre.Time
+	}
+	if !horaAntes(apertura, cierre) {
+		responderCampos(w, r, erroresCampos{"hora_apertura": "debe ser anterior a hora_cierre"})
+		return
+	}
+
+	if req.Slug != nil && *req.Slug != barberia.Slug {
+		existe, err := h.Queries.ExisteSlug(ctx, *req.Slug)
+		if err != nil {
+			errorInterno(w, r, err, "Error guardando configuración")
+			return
+		}
+		if existe {
+			responderError(w, r, http.StatusConflict, CodigoSlugOcupado, errSlugOcupado.Error())
+			return
+		}
+		params.Slug = toNullString(*req.Slug)
+	}
+
+	actualizada, err := h.Queries.UpdateBarberiaConfiguracion(ctx, params)
+	if esViolacionUnica(err) {
+		responderError(w, r, http.StatusConflict, CodigoSlugOcupado, errSlugOcupado.Error())
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error guardando configuración")
+		return
+	}
+
+	writeJSON(w, actualizada)
+}
+
+// horaAntes compara sólo hora y minuto (las columnas TIME vuelven con fecha 0000-01-01)
+func horaAntes(a, b time.Time) bool {
+	return a.Hour()*60+a.Minute() < b.Hour()*60+b.Minute()
 }
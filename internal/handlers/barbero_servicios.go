@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	db "agendaFacil/db/sqlc"
+)
+
+// condicion es cuánto tarda y cobra un barbero un servicio
+type condicion struct {
+	Duracion int32
+	Precio   string
+}
+
+// condicionBarbero resuelve si el barbero hace el servicio y con qué
+// duración y precio. Un servicio sin restringir lo hacen todos, con los
+// valores propios de quien tenga asignación; uno restringido, sólo los
+// asignados. La restricción es explícita: que no quede ninguna asignación, o
+// sólo las de barberos dados de baja, deja el servicio sin quien lo haga
+// pero nunca lo abre a todos por su cuenta.
+func condicionBarbero(servicio db.Servicio, asignaciones []db.BarberoServicio, barberoID int32) (condicion, bool) {
+	c := condicion{Duracion: servicio.DuracionMinutos, Precio: servicio.Precio}
+	for _, a := range asignaciones {
+		if a.BarberoID != barberoID {
+			continue
+		}
+		if a.DuracionMinutos.Valid {
+			c.Duracion = a.DuracionMinutos.Int32
+		}
+		if a.Precio.Valid {
+			c.Precio = a.Precio.String
+		}
+		return c, true
+	}
+	return c, !servicio.Restringido
+}
+
+// condicionesServicio devuelve las condiciones de cada barbero habilitado
+// para el servicio, entre los ids indicados.
+func condicionesServicio(ctx context.Context, q *db.Queries, servicio db.Servicio, ids []int32) (map[int32]condicion, error) {
+	asignaciones, err := q.ListBarberoServiciosByServicio(ctx, servicio.ID)
+	if err != nil {
+		return nil, err
+	}
+	condiciones := make(map[int32]condicion, len(ids))
+	for _, id := range ids {
+		if c, ok := condicionBarbero(servicio, asignaciones, id); ok {
+			condiciones[id] = c
+		}
+	}
+	return condiciones, nil
+}
+
+// errBarberoNoHabilitado: el barbero pedido no hace ese servicio
+var errBarberoNoHabilitado = errors.New("el barbero no realiza ese servicio")
+
+// BarberoServicioItem asigna un servicio al barbero. Duración y precio son
+// opcionales: si faltan rigen los del servicio.
+type BarberoServicioItem struct {
+	ServicioID      int32   `json:"servicio_id" validar:"requerido"`
+	DuracionMinutos *int32  `json:"duracion_minutos,omitempty" validar:"min=5,max=480"`
+	Precio          *string `json:"precio,omitempty" validar:"precio"`
+}
+
+// normalizar recorta el precio; vacío es lo mismo que omitido
+func (item *BarberoServicioItem) normalizar() {
+	if item.Precio == nil {
+		return
+	}
+	p := strings.TrimSpace(*item.Precio)
+	if p == "" {
+		item.Precio = nil
+		return
+	}
+	item.Precio = &p
+}
+
+// UpdateBarberoServiciosRequest reemplaza todos los servicios del barbero
+type UpdateBarberoServiciosRequest struct {
+	Servicios []BarberoServicioItem `json:"servicios"`
+}
+
+func (req *UpdateBarberoServiciosRequest) validarCampos() erroresCampos {
+	errs := erroresCampos{}
+	vistos := map[int32]bool{}
+	for i, item := range req.Servicios {
+		if vistos[item.ServicioID] {
+			errs["servicios["+strconv.Itoa(i)+"].servicio_id"] = "servicio repetido"
+		}
+		vistos[item.ServicioID] = true
+	}
+	return errs
+}
+
+// GetBarberoServicios lista los servicios asignados al barbero
+func (h *BarberosHandler) GetBarberoServicios(w http.ResponseWriter, r *http.Request) {
+	_, barbero, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	asignaciones, err := h.Queries.ListBarberoServiciosByBarbero(r.Context(), barbero.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo servicios del barbero")
+		return
+	}
+
+	writeJSON(w, toBarberoServicioItems(asignaciones))
+}
+
+// PutBarberoServicios reemplaza los servicios del barbero en una sola
+// transacción. La primera asignación de un servicio lo restringe a sus
+// barberos; después la restricción sólo la cambia el admin con el PATCH del
+// servicio, y sacar un servicio no lo libera (ver condicionBarbero).
+func (h *BarberosHandler) PutBarberoServicios(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, barbero, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateBarberoServiciosRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	for _, item := range req.Servicios {
+		_, err := h.Queries.GetServicioDeBarberia(ctx, db.GetServicioDeBarberiaParams{
+			ID:         item.ServicioID,
+			BarberiaID: barberia.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			responderError(w, r, http.StatusNotFound, CodigoServicioNoEncontrado, "Servicio no encontrado: "+strconv.Itoa(int(item.ServicioID)))
+			return
+		}
+		if err != nil {
+			errorInterno(w, r, err, "Error obteniendo servicio")
+			return
+		}
+	}
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		errorInterno(w, r, err, "Error guardando servicios del barbero")
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	// Antes de borrar, para que las asignaciones que ya tenía el barbero
+	// cuenten y no se vuelva a restringir un servicio que el admin abrió
+	for _, item := range req.Servicios {
+		if err := qtx.RestringirServicio(ctx, item.ServicioID); err != nil {
+			errorInterno(w, r, err, "Error guardando servicios del barbero")
+			return
+		}
+	}
+
+	if err := qtx.DeleteBarberoServicios(ctx, barbero.ID); err != nil {
+		errorInterno(w, r, err, "Error guardando servicios del barbero")
+		return
+	}
+
+	creados := []db.BarberoServicio{}
+	for _, item := range req.Servicios {
+		var precio sql.NullString
+		if item.Precio != nil {
+			precio = sql.NullString{String: *item.Precio, Valid: true}
+		}
+		a, err := qtx.CreateBarberoServicio(ctx, db.CreateBarberoServicioParams{
+			BarberoID:       barbero.ID,
+			ServicioID:      item.ServicioID,
+			DuracionMinutos: toNullInt32(item.DuracionMinutos),
+			Precio:          precio,
+		})
+		if err != nil {
+			errorInterno(w, r, err, "Error guardando servicios del barbero")
+			return
+		}
+		creados = append(creados, a)
+	}
+
+	if err := tx.Commit(); err != nil {
+		errorInterno(w, r, err, "Error guardando servicios del barbero")
+		return
+	}
+
+	writeJSON(w, toBarberoServicioItems(creados))
+}
+
+func toBarberoServicioItems(asignaciones []db.BarberoServicio) []BarberoServicioItem {
+	items := make([]BarberoServicioItem, 0, len(asignaciones))
+	for _, a := range asignaciones {
+		item := BarberoServicioItem{ServicioID: a.ServicioID}
+		if a.DuracionMinutos.Valid {
+			item.DuracionMinutos = &a.DuracionMinutos.Int32
+		}
+		if a.Precio.Valid {
+			item.Precio = &a.Precio.String
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestCondicionBarbero tests la habilitación y los valores propios de cada barbero
+func TestCondicionBarbero(t *testing.T) {
+	servicio := db.Servicio{ID: 1, DuracionMinutos: 30, Precio: "10.00"}
+
+	// Sin restringir lo hacen todos con los valores del servicio
+	c, ok := condicionBarbero(servicio, nil, 5)
+	if !ok || c.Duracion != 30 || c.Precio != "10.00" {
+		t.Errorf("Sin restringir: se esperaba habilitado con 30/10.00, pero se obtuvo %v %+v", ok, c)
+	}
+
+	asignaciones := []db.BarberoServicio{
+		{BarberoID: 5, ServicioID: 1},
+		{BarberoID: 6, ServicioID: 1, DuracionMinutos: sql.NullInt32{Int32: 45, Valid: true}, Precio: sql.NullString{String: "15.00", Valid: true}},
+	}
+	if c, ok := condicionBarbero(servicio, asignaciones, 7); !ok || c.Duracion != 30 {
+		t.Errorf("Sin restringir, un barbero sin asignación lo hace igual: se obtuvo %v %+v", ok, c)
+	}
+
+	servicio.Restringido = true
+	if c, ok := condicionBarbero(servicio, asignaciones, 5); !ok || c.Duracion != 30 || c.Precio != "10.00" {
+		t.Errorf("Barbero sin valores propios: se obtuvo %v %+v", ok, c)
+	}
+	if c, ok := condicionBarbero(servicio, asignaciones, 6); !ok || c.Duracion != 45 || c.Precio != "15.00" {
+		t.Errorf("Barbero con valores propios: se obtuvo %v %+v", ok, c)
+	}
+	if _, ok := condicionBarbero(servicio, asignaciones, 7); ok {
+		t.Error("Un barbero sin asignación no debería estar habilitado")
+	}
+
+	// Restringido y sin asignaciones no lo hace nadie
+	if _, ok := condicionBarbero(servicio, nil, 5); ok {
+		t.Error("Un servicio restringido sin asignaciones no debería abrirse a todos")
+	}
+}
+
+// TestCalcularSlotsPorDuracion tests que cada barbero use su propia duración
+func TestCalcularSlotsPorDuracion(t *testing.T) {
+	rangos := map[int32][]rango{
+		1: {{Inicio: hora(9, 0), Fin: hora(10, 0)}},
+		2: {{Inicio: hora(9, 0), Fin: hora(10, 0)}},
+	}
+	duraciones := map[int32]int32{1: 30, 2: 60}
+
+	slots := calcularSlotsPorDuracion([]int32{1, 2}, duraciones, rangos, 30, nil)
+
+	// 9:00-9:30 (1), 9:00-10:00 (2), 9:30-10:00 (1)
+	want := []Slot{
+		{Inicio: "09:00", Fin: "09:30", Barberos: []int32{1}},
+		{Inicio: "09:00", Fin: "10:00", Barberos: []int32{2}},
+		{Inicio: "09:30", Fin: "10:00", Barberos: []int32{1}},
+	}
+	if len(slots) != len(want) {
+		t.Fatalf("Se esperaban %d slots, pero se obtuvieron %d: %+v", len(want), len(slots), slots)
+	}
+	for i := range want {
+		if slots[i].Inicio != want[i].Inicio || slots[i].Fin != want[i].Fin || len(slots[i].Barberos) != 1 || slots[i].Barberos[0] != want[i].Barberos[0] {
+			t.Errorf("Slot %d: se esperaba %+v, pero se obtuvo %+v", i, want[i], slots[i])
+		}
+	}
+}
+
+// TestBarberoServicios_Reserva cubre la asignación de servicios: el listado
+// filtrado, la duración propia del barbero y el rechazo de un barbero que no
+// hace el servicio.
+func TestBarberoServicios_Reserva(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	otroID := crearBarberoTest(t, conn, barberia.ID, "Otro")
+	q := db.New(conn)
+	h := NewBarberosHandler(q, conn, &notificadorTest{})
+	bh := NewBarberiaHandler(q, conn)
+
+	duracion := int32(45)
+	rec := staffTest(t, h, bh, http.MethodPut, "/b/"+barberia.Slug+"/barberos/"+strconv.Itoa(int(barberoID))+"/servicios",
+		UpdateBarberoServiciosRequest{Servicios: []BarberoServicioItem{{ServicioID: servicio.ID, DuracionMinutos: &duracion}}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Asignar servicios: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// El listado filtrado sólo muestra al barbero asignado, con su duración
+	r := chi.NewRouter()
+	r.Get("/b/{slug}/barberos", h.ListBarberos)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/b/"+barberia.Slug+"/barberos?servicio_id="+strconv.Itoa(int(servicio.ID)), nil))
+	var barberos []BarberoPublico
+	json.Unmarshal(rec.Body.Bytes(), &barberos)
+	if len(barberos) != 1 || barberos[0].ID != barberoID || barberos[0].DuracionMinutos != 45 {
+		t.Errorf("Listado filtrado inesperado: %+v", barberos)
+	}
+
+	reserva := CreateReservaRequest{
+		ServicioID:    servicio.ID,
+		BarberoID:     otroID,
+		Fecha:         diaReservaTest(),
+		HoraInicio:    "10:00",
+		ClienteNombre: "Pedro",
+	}
+	if rec := reservarTest(t, bh, barberia.Slug, reserva); rec.Code != http.StatusBadRequest {
+		t.Errorf("Barbero no habilitado: se esperaba 400, pero se obtuvo %d", rec.Code)
+	}
+
+	reserva.BarberoID = 0
+	rec = reservarTest(t, bh, barberia.Slug, reserva)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Reserva: se esperaba 201, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp ReservaResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.BarberoID != barberoID || resp.HoraFin.Format("15:04") != "10:45" {
+		t.Errorf("Se esperaba el barbero habilitado hasta las 10:45, pero se obtuvo %d hasta %s", resp.BarberoID, resp.HoraFin.Format("15:04"))
+	}
+}
+
+// TestBarberoServicios_Restriccion tests que la restricción no cambie sola:
+// ni al sacar la última asignación ni al dar de baja al único barbero
+// asignado el servicio pasa a ser de todos. Sólo el admin lo abre, y asignarlo
+// después no lo vuelve a restringir.
+func TestBarberoServicios_Restriccion(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	otroID := crearBarberoTest(t, conn, barberia.ID, "Otro")
+	q := db.New(conn)
+	h := NewBarberosHandler(q, conn, &notificadorTest{})
+	bh := NewBarberiaHandler(q, conn)
+	sh := NewServiciosHandler(q, conn)
+
+	asignar := func(servicios []BarberoServicioItem) {
+		t.Helper()
+		rec := staffTest(t, h, bh, http.MethodPut, "/b/"+barberia.Slug+"/barberos/"+strconv.Itoa(int(barberoID))+"/servicios",
+			UpdateBarberoServiciosRequest{Servicios: servicios})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Asignar servicios: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	reservarOtro := func(hora string) int {
+		return reservarTest(t, bh, barberia.Slug, CreateReservaRequest{
+			ServicioID:    servicio.ID,
+			BarberoID:     otroID,
+			Fecha:         diaReservaTest(),
+			HoraInicio:    hora,
+			ClienteNombre: "Pedro",
+		}).Code
+	}
+
+	// Sacar la última asignación no lo abre a todos
+	asignar([]BarberoServicioItem{{ServicioID: servicio.ID}})
+	asignar(nil)
+	if code := reservarOtro("10:00"); code != http.StatusBadRequest {
+		t.Errorf("Sin asignaciones: se esperaba 400, pero se obtuvo %d", code)
+	}
+
+	// Con el único asignado dado de baja tampoco
+	asignar([]BarberoServicioItem{{ServicioID: servicio.ID}})
+	rec := staffTest(t, h, bh, http.MethodPost, "/b/"+barberia.Slug+"/barberos/"+strconv.Itoa(int(barberoID))+"/desactivar", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Desactivar: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	if code := reservarOtro("10:00"); code != http.StatusBadRequest {
+		t.Errorf("Asignado dado de baja: se esperaba 400, pero se obtuvo %d", code)
+	}
+
+	// El admin lo abre y ya lo hace cualquiera
+	rec = serviciosTest(t, sh, http.MethodPatch, "/b/"+barberia.Slug+"/servicios/"+strconv.Itoa(int(servicio.ID)), map[string]any{"restringido": false})
+	var abierto db.Servicio
+	json.Unmarshal(rec.Body.Bytes(), &abierto)
+	if rec.Code != http.StatusOK || abierto.Restringido {
+		t.Fatalf("Abrir servicio: se esperaba 200 sin restricción, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	if code := reservarOtro("10:00"); code != http.StatusCreated {
+		t.Errorf("Servicio abierto: se esperaba 201, pero se obtuvo %d", code)
+	}
+
+	// Cargarle valores propios a un barbero no lo vuelve a restringir
+	precio := "15.00"
+	rec = staffTest(t, h, bh, http.MethodPut, "/b/"+barberia.Slug+"/barberos/"+strconv.Itoa(int(otroID))+"/servicios",
+		UpdateBarberoServiciosRequest{Servicios: []BarberoServicioItem{{ServicioID: servicio.ID, Precio: &precio}}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Valores propios: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	actual, err := q.GetServicioDeBarberia(context.Background(), db.GetServicioDeBarberiaParams{ID: servicio.ID, BarberiaID: barberia.ID})
+	if err != nil {
+		t.Fatalf("Error obteniendo servicio: %v", err)
+	}
+	if actual.Restringido {
+		t.Error("El servicio abierto por el admin no debería volver a quedar restringido")
+	}
+}
+
+// TestReasignarTurno_Precio tests que al reasignar el turno pase a tener el
+// precio y la duración del barbero nuevo
+func TestReasignarTurno_Precio(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	otroID := crearBarberoTest(t, conn, barberia.ID, "Otro")
+	q := db.New(conn)
+	h := NewBarberosHandler(q, conn, &notificadorTest{})
+	bh := NewBarberiaHandler(q, conn)
+
+	precio, duracion := "15.00", int32(45)
+	rec := staffTest(t, h, bh, http.MethodPut, "/b/"+barberia.Slug+"/barberos/"+strconv.Itoa(int(otroID))+"/servicios",
+		UpdateBarberoServiciosRequest{Servicios: []BarberoServicioItem{{ServicioID: servicio.ID, DuracionMinutos: &duracion, Precio: &precio}}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Asignar servicios: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = staffTest(t, h, bh, http.MethodPut, "/b/"+barberia.Slug+"/barberos/"+strconv.Itoa(int(barberoID))+"/servicios",
+		UpdateBarberoServiciosRequest{Servicios: []BarberoServicioItem{{ServicioID: servicio.ID}}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Asignar servicios: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = reservarTest(t, bh, barberia.Slug, CreateReservaRequest{
+		ServicioID:    servicio.ID,
+		BarberoID:     barberoID,
+		Fecha:         diaReservaTest(),
+		HoraInicio:    "10:00",
+		ClienteNombre: "Pedro",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Reserva: se esperaba 201, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var reserva ReservaResponse
+	json.Unmarshal(rec.Body.Bytes(), &reserva)
+
+	rec = staffTest(t, h, bh, http.MethodPost, "/b/"+barberia.Slug+"/turnos/"+strconv.Itoa(int(reserva.ID))+"/reasignar", ReasignarTurnoRequest{BarberoID: otroID})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Reasignar: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+
+	turno, err := q.GetTurnoByID(context.Background(), db.GetTurnoByIDParams{ID: reserva.ID, BarberiaID: barberia.ID})
+	if err != nil {
+		t.Fatalf("Error obteniendo turno: %v", err)
+	}
+	if turno.BarberoID != otroID || turno.Precio.String != "15.00" || turno.HoraFin.Format("15:04") != "10:45" {
+		t.Errorf("Se esperaba el turno del otro barbero a 15.00 hasta 10:45, se obtuvo %d a %q hasta %s", turno.BarberoID, turno.Precio.String, turno.HoraFin.Format("15:04"))
+	}
+}
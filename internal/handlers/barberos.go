@@ -1,22 +1,31 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
 	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/notificacion"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type BarberosHandler struct {
-	Queries *db.Queries
+	Queries     *db.Queries
+	DB          *sql.DB
+	Notificador notificacion.Notificador
 }
 
-func NewBarberosHandler(q *db.Queries) *BarberosHandler {
-	return &BarberosHandler{Queries: q}
+func NewBarberosHandler(q *db.Queries, conn *sql.DB, n notificacion.Notificador) *BarberosHandler {
+	return &BarberosHandler{Queries: q, DB: conn, Notificador: n}
 }
 
 func (h *BarberosHandler) ListBarberos(w http.ResponseWriter, r *http.Request) {
@@ -25,28 +34,76 @@ func (h *BarberosHandler) ListBarberos(w http.ResponseWriter, r *http.Request) {
 	// 1️⃣ Buscar barbería
 	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), slug)
 	if err != nil {
-		http.Error(w, "barbería no encontrada", http.StatusNotFound)
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "barbería no encontrada")
 		return
 	}
 
 	// 2️⃣ Obtener barberos activos
 	barberos, err := h.Queries.ListBarberos(r.Context(), barberia.ID)
 	if err != nil {
-		http.Error(w, "error obteniendo barberos", http.StatusInternalServerError)
+		errorInterno(w, r, err, "error obteniendo barberos")
 		return
 	}
 
+	// Filtro opcional: sólo quienes hacen el servicio, con su duración y precio
+	respuesta := make([]BarberoPublico, 0, len(barberos))
+	if servicioIDStr := r.URL.Query().Get("servicio_id"); servicioIDStr != "" {
+		servicioID, err := strconv.Atoi(servicioIDStr)
+		if err != nil {
+			responderError(w, r, http.StatusBadRequest, CodigoValidacion, "servicio_id invalido")
+			return
+		}
+		servicio, err := h.Queries.GetServicioByID(r.Context(), int32(servicioID))
+		if err != nil || servicio.BarberiaID != barberia.ID {
+			responderError(w, r, http.StatusNotFound, CodigoServicioNoEncontrado, "servicio no encontrado")
+			return
+		}
+
+		ids := make([]int32, 0, len(barberos))
+		for _, b := range barberos {
+			ids = append(ids, b.ID)
+		}
+		condiciones, err := condicionesServicio(r.Context(), h.Queries, servicio, ids)
+		if err != nil {
+			errorInterno(w, r, err, "error obteniendo barberos")
+			return
+		}
+		for _, b := range barberos {
+			if c, ok := condiciones[b.ID]; ok {
+				respuesta = append(respuesta, BarberoPublico{ID: b.ID, Nombre: b.Nombre, Apellido: b.Apellido, DuracionMinutos: c.Duracion, Precio: c.Precio})
+			}
+		}
+	} else {
+		for _, b := range barberos {
+			respuesta = append(respuesta, BarberoPublico{ID: b.ID, Nombre: b.Nombre, Apellido: b.Apellido})
+		}
+	}
+
 	// 3️⃣ Responder JSON
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(barberos)
+	json.NewEncoder(w).Encode(respuesta)
+}
+
+// BarberoPublico es lo que ve el cliente de cada barbero. Duración y precio
+// sólo vienen cuando se filtra por servicio_id.
+type BarberoPublico struct {
+	ID              int32  `json:"id"`
+	Nombre          string `json:"nombre"`
+	Apellido        string `json:"apellido"`
+	DuracionMinutos int32  `json:"duracion_minutos,omitempty"`
+	Precio          string `json:"precio,omitempty"`
 }
 
 type CreateBarberoRequest struct {
-	Nombre   string `json:"nombre"`
-	Apellido string `json:"apellido"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Nombre   string `json:"nombre" validar:"requerido,max=50"`
+	Apellido string `json:"apellido" validar:"requerido,max=50"`
+	Email    string `json:"email" validar:"requerido,max=100,email"`
+	Username string `json:"username" validar:"requerido,max=50"`
+	Password string `json:"password" validar:"requerido,password"`
+}
+
+func (req *CreateBarberoRequest) normalizar() {
+	recortar(&req.Nombre, &req.Apellido, &req.Email, &req.Username)
 }
 
 func (h *BarberosHandler) CreateBarbero(w http.ResponseWriter, r *http.Request) {
@@ -55,22 +112,20 @@ func (h *BarberosHandler) CreateBarbero(w http.ResponseWriter, r *http.Request)
 	// 1. Buscar Barbería
 	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), slug)
 	if err != nil {
-		http.Error(w, "Barbería no encontrada", http.StatusNotFound)
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
 		return
 	}
 
 	// 2. Decodificar JSON
 	var req CreateBarberoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Println("CreateBarbero: error decoding JSON:", err)
-		http.Error(w, "JSON inválido: "+err.Error(), http.StatusBadRequest)
+	if !decodificarJSON(w, r, &req) {
 		return
 	}
 
 	// 3. Encriptar contraseña (Nunca guardar texto plano)
 	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, "Error procesando contraseña", http.StatusInternalServerError)
+		errorInterno(w, r, err, "Error procesando contraseña")
 		return
 	}
 
@@ -82,15 +137,297 @@ func (h *BarberosHandler) CreateBarbero(w http.ResponseWriter, r *http.Request)
 		Username:     req.Username,
 		Email:        req.Email,
 		PasswordHash: string(hashedPwd),
-		Rol:          "barbero", // Forzamos el rol para que no creen otro admin
+		Rol:          RolBarbero, // Forzamos el rol para que no creen otro admin
 	})
-
+	if esViolacionUnica(err) {
+		responderError(w, r, http.StatusConflict, CodigoUsuarioExistente, errUsuarioDuplicado.Error())
+		return
+	}
 	if err != nil {
-		// Tip: Si el error es por "username duplicado", aquí podrías manejarlo mejor
-		http.Error(w, "Error guardando barbero: "+err.Error(), http.StatusInternalServerError)
+		errorInterno(w, r, err, "Error guardando barbero")
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(nuevoBarbero)
+	json.NewEncoder(w).Encode(toBarberoResponse(nuevoBarbero))
+}
+
+// BarberoResponse es el barbero tal como lo ve el admin. Nunca incluye el hash
+// de la contraseña: los handlers no deben serializar db.Usuario directamente.
+type BarberoResponse struct {
+	ID       int32  `json:"id"`
+	Nombre   string `json:"nombre"`
+	Apellido string `json:"apellido"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Rol      string `json:"rol"`
+	Activo   bool   `json:"activo"`
+}
+
+func toBarberoResponse(u db.Usuario) BarberoResponse {
+	return BarberoResponse{
+		ID:       u.ID,
+		Nombre:   u.Nombre,
+		Apellido: u.Apellido,
+		Username: u.Username,
+		Email:    u.Email,
+		Rol:      u.Rol,
+		Activo:   !u.Activo.Valid || u.Activo.Bool, // NULL se trata como activo, igual que el DEFAULT
+	}
+}
+
+// BarberoRequest son los datos editables del barbero. La contraseña se cambia
+// por su propio flujo (/password/...).
+type BarberoRequest struct {
+	Nombre   string `json:"nombre" validar:"requerido,max=50"`
+	Apellido string `json:"apellido" validar:"requerido,max=50"`
+	Email    string `json:"email" validar:"requerido,max=100,email"`
+	Username string `json:"username" validar:"requerido,max=50"`
+}
+
+func (req *BarberoRequest) normalizar() {
+	recortar(&req.Nombre, &req.Apellido, &req.Email, &req.Username)
+}
+
+var errUsuarioDuplicado = errors.New("ya existe un usuario con ese username o email")
+
+// esViolacionUnica indica si el error es una violación de UNIQUE (23505)
+func esViolacionUnica(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// UpdateBarbero reemplaza los datos del barbero (activo o no)
+func (h *BarberosHandler) UpdateBarbero(w http.ResponseWriter, r *http.Request) {
+	barberia, barbero, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req BarberoRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	actualizado, err := h.Queries.UpdateBarbero(r.Context(), db.UpdateBarberoParams{
+		ID:         barbero.ID,
+		BarberiaID: barberia.ID,
+		Nombre:     req.Nombre,
+		Apellido:   req.Apellido,
+		Username:   req.Username,
+		Email:      req.Email,
+	})
+	if esViolacionUnica(err) {
+		responderError(w, r, http.StatusConflict, CodigoUsuarioExistente, errUsuarioDuplicado.Error())
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error actualizando barbero")
+		return
+	}
+
+	writeJSON(w, toBarberoResponse(actualizado))
+}
+
+// Qué hacer con los turnos futuros de un barbero que se da de baja
+const (
+	TurnosReasignar = "reasignar" // quedan listados para que el admin los reasigne
+	TurnosCancelar  = "cancelar"  // se cancelan y se avisa a cada cliente
+)
+
+// DesactivarBarberoRequest es opcional: sin body los turnos quedan para reasignar
+type DesactivarBarberoRequest struct {
+	TurnosFuturos string `json:"turnos_futuros" validar:"uno_de=reasignar|cancelar"`
+}
+
+func (req *DesactivarBarberoRequest) normalizar() {
+	if req.TurnosFuturos == "" {
+		req.TurnosFuturos = TurnosReasignar
+	}
+}
+
+// DesactivarBarberoResponse devuelve el barbero y el destino de sus turnos futuros
+type DesactivarBarberoResponse struct {
+	Barbero          BarberoResponse `json:"barbero"`
+	TurnosAReasignar []db.Turno      `json:"turnos_a_reasignar"`
+	TurnosCancelados []db.Turno      `json:"turnos_cancelados"`
+}
+
+// DesactivarBarbero da de baja al barbero: deja de recibir reservas, no puede
+// iniciar sesión y se revocan sus refresh tokens (el access token vigente
+// expira solo). Sus turnos futuros se listan para reasignar o se cancelan.
+func (h *BarberosHandler) DesactivarBarbero(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, barbero, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req DesactivarBarberoRequest
+	if !decodificarJSONOpcional(w, r, &req) {
+		return
+	}
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		errorInterno(w, r, err, "Error desactivando barbero")
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	desactivado, err := qtx.SetBarberoActivo(ctx, db.SetBarberoActivoParams{
+		ID:         barbero.ID,
+		BarberiaID: barberia.ID,
+		Activo:     sql.NullBool{Bool: false, Valid: true},
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error desactivando barbero")
+		return
+	}
+	if err := qtx.RevokeRefreshTokensUsuario(ctx, barbero.ID); err != nil {
+		errorInterno(w, r, err, "Error desactivando barbero")
+		return
+	}
+
+	futuros, err := turnosFuturos(ctx, qtx, barberia, barbero.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo turnos futuros")
+		return
+	}
+
+	resp := DesactivarBarberoResponse{
+		Barbero:          toBarberoResponse(desactivado),
+		TurnosAReasignar: []db.Turno{},
+		TurnosCancelados: []db.Turno{},
+	}
+	if req.TurnosFuturos == TurnosReasignar {
+		resp.TurnosAReasignar = append(resp.TurnosAReasignar, futuros...)
+	} else {
+		for _, t := range futuros {
+			cancelado, err := qtx.UpdateTurnoEstado(ctx, db.UpdateTurnoEstadoParams{
+				EstadoNuevo:  toNullString(EstadoCancelado),
+				ID:           t.ID,
+				BarberiaID:   barberia.ID,
+				EstadoActual: estadoTurno(t),
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "Un turno cambió de estado, volvé a intentar")
+				return
+			}
+			if err != nil {
+				errorInterno(w, r, err, "Error cancelando turnos")
+				return
+			}
+			resp.TurnosCancelados = append(resp.TurnosCancelados, cancelado)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		errorInterno(w, r, err, "Error desactivando barbero")
+		return
+	}
+
+	// Los avisos van después del commit: un fallo al avisar no deshace la baja
+	for _, t := range resp.TurnosCancelados {
+		if err := h.Notificador.Notificar(ctx, avisoCancelacion(barberia, t)); err != nil {
+			log.Printf("DesactivarBarbero: no se pudo avisar del turno %d: %v", t.ID, err)
+		}
+	}
+
+	writeJSON(w, resp)
+}
+
+// ReactivarBarbero vuelve a habilitar al barbero para reservas y login
+func (h *BarberosHandler) ReactivarBarbero(w http.ResponseWriter, r *http.Request) {
+	barberia, barbero, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	reactivado, err := h.Queries.SetBarberoActivo(r.Context(), db.SetBarberoActivoParams{
+		ID:         barbero.ID,
+		BarberiaID: barberia.ID,
+		Activo:     sql.NullBool{Bool: true, Valid: true},
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error reactivando barbero")
+		return
+	}
+
+	writeJSON(w, toBarberoResponse(reactivado))
+}
+
+// ListTurnosFuturos lista los turnos activos del barbero desde ahora, para
+// reasignarlos (típicamente después de desactivarlo).
+func (h *BarberosHandler) ListTurnosFuturos(w http.ResponseWriter, r *http.Request) {
+	barberia, barbero, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	futuros, err := turnosFuturos(r.Context(), h.Queries, barberia, barbero.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo turnos futuros")
+		return
+	}
+	if futuros == nil {
+		futuros = []db.Turno{}
+	}
+
+	writeJSON(w, futuros)
+}
+
+// turnosFuturos busca los turnos pendientes o confirmados desde ahora, en
+// hora local de la barbería
+func turnosFuturos(ctx context.Context, q *db.Queries, barberia db.Barberia, barberoID int32) ([]db.Turno, error) {
+	fecha, hora := relojLocal(barberia, ahora())
+	return q.ListTurnosFuturosBarbero(ctx, db.ListTurnosFuturosBarberoParams{
+		BarberiaID: barberia.ID,
+		BarberoID:  barberoID,
+		DesdeFecha: fecha,
+		DesdeHora:  hora,
+	})
+}
+
+// avisoCancelacion arma el mensaje para el cliente de un turno cancelado por la barbería
+func avisoCancelacion(barberia db.Barberia, t db.Turno) notificacion.Aviso {
+	return notificacion.Aviso{
+		Telefono: t.ClienteTelefono.String,
+		Nombre:   t.ClienteNombre,
+		Texto: fmt.Sprintf("Hola %s, tu turno del %s a las %s en %s fue cancelado porque el barbero ya no atiende. Podés reservar otro horario cuando quieras.",
+			t.ClienteNombre, t.Fecha.Format("02/01/2006"), t.HoraInicio.Format("15:04"), barberia.Nombre),
+	}
+}
+
+// barberoDeURL resuelve {slug} y {id}, incluidos los barberos inactivos
+func (h *BarberosHandler) barberoDeURL(w http.ResponseWriter, r *http.Request) (db.Barberia, db.Usuario, bool) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return db.Barberia{}, db.Usuario{}, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de barbero inválido")
+		return db.Barberia{}, db.Usuario{}, false
+	}
+
+	barbero, err := h.Queries.GetBarbero(r.Context(), db.GetBarberoParams{
+		ID:         int32(id),
+		BarberiaID: barberia.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoBarberoNoEncontrado, "Barbero no encontrado")
+		return db.Barberia{}, db.Usuario{}, false
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo barbero")
+		return db.Barberia{}, db.Usuario{}, false
+	}
+
+	return barberia, barbero, true
 }
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/notificacion"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// notificadorTest guarda los avisos en memoria
+type notificadorTest struct {
+	mu     sync.Mutex
+	avisos []notificacion.Aviso
+}
+
+func (n *notificadorTest) Notificar(_ context.Context, a notificacion.Aviso) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.avisos = append(n.avisos, a)
+	return nil
+}
+
+// TestValidarBarbero tests los campos obligatorios y la normalización
+func TestValidarBarbero(t *testing.T) {
+	req := BarberoRequest{Nombre: " Juan ", Apellido: "Pérez", Email: "juan@correo.com", Username: "juanp"}
+	if errs := validar(&req); len(errs) > 0 {
+		t.Fatalf("Datos válidos rechazados: %v", errs)
+	}
+	if req.Nombre != "Juan" {
+		t.Errorf("No se recortó el nombre: %q", req.Nombre)
+	}
+
+	invalidos := []BarberoRequest{
+		{Nombre: "", Apellido: "Pérez", Email: "juan@correo.com", Username: "juanp"},
+		{Nombre: "Juan", Apellido: "Pérez", Email: "juan@correo.com", Username: "   "},
+		{Nombre: "Juan", Apellido: "Pérez", Email: "sin-arroba", Username: "juanp"},
+		{Nombre: "Juan", Apellido: "Pérez", Email: "juan@correo", Username: "juanp"},
+		{Nombre: strings.Repeat("a", 51), Apellido: "Pérez", Email: "juan@correo.com", Username: "juanp"},
+	}
+	for _, r := range invalidos {
+		if errs := validar(&r); len(errs) == 0 {
+			t.Errorf("Se esperaba error para %+v", r)
+		}
+	}
+}
+
+// TestBarberoResponse_SinHash tests que la respuesta nunca exponga el hash
+func TestBarberoResponse_SinHash(t *testing.T) {
+	u := db.Usuario{ID: 1, Nombre: "Juan", PasswordHash: "$2a$10$secreto", Rol: RolBarbero}
+
+	body, _ := json.Marshal(toBarberoResponse(u))
+	if strings.Contains(string(body), "password") || strings.Contains(string(body), "secreto") {
+		t.Errorf("La respuesta expone el hash: %s", body)
+	}
+
+	var resp BarberoResponse
+	json.Unmarshal(body, &resp)
+	if !resp.Activo {
+		t.Error("activo NULL debería mostrarse como activo")
+	}
+}
+
+// staffTest llama a los endpoints de gestión de barberos como los monta rutasAPI
+func staffTest(t *testing.T, h *BarberosHandler, bh *BarberiaHandler, metodo, ruta string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	return pedirTest(t, func(r chi.Router) {
+		r.Put("/b/{slug}/barberos/{id}", h.UpdateBarbero)
+		r.Post("/b/{slug}/barberos/{id}/desactivar", h.DesactivarBarbero)
+		r.Post("/b/{slug}/barberos/{id}/reactivar", h.ReactivarBarbero)
+		r.Get("/b/{slug}/barberos/{id}/turnos-futuros", h.ListTurnosFuturos)
+		r.Get("/b/{slug}/barberos/{id}/servicios", h.GetBarberoServicios)
+		r.Put("/b/{slug}/barberos/{id}/servicios", h.PutBarberoServicios)
+		r.Post("/b/{slug}/turnos/{id}/reasignar", bh.ReasignarTurno)
+	}, metodo, ruta, body)
+}
+
+// TestDesactivarBarbero_ReasignarYCancelar cubre la baja de un barbero con
+// turnos futuros: primero se reasignan a otro y después, al dar de baja al
+// segundo, se cancelan avisando al cliente.
+func TestDesactivarBarbero_ReasignarYCancelar(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	otroID := crearBarberoTest(t, conn, barberia.ID, "Otro")
+	q := db.New(conn)
+	notificador := &notificadorTest{}
+	h := NewBarberosHandler(q, conn, notificador)
+	bh := NewBarberiaHandler(q, conn)
+	base := "/b/" + barberia.Slug
+
+	rec := reservarTest(t, bh, barberia.Slug, CreateReservaRequest{
+		ServicioID:      servicio.ID,
+		BarberoID:       barberoID,
+		Fecha:           diaReservaTest(),
+		HoraInicio:      "10:00",
+		ClienteNombre:   "Pedro",
+		ClienteTelefono: "11 4444-5555",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Reserva: se esperaba 201, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Baja sin body: los turnos quedan para reasignar
+	rec = staffTest(t, h, bh, http.MethodPost, base+"/barberos/"+strconv.Itoa(int(barberoID))+"/desactivar", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Desactivar: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var baja DesactivarBarberoResponse
+	json.Unmarshal(rec.Body.Bytes(), &baja)
+	if baja.Barbero.Activo || len(baja.TurnosAReasignar) != 1 || len(baja.TurnosCancelados) != 0 {
+		t.Fatalf("Baja inesperada: %+v", baja)
+	}
+
+	rec = staffTest(t, h, bh, http.MethodPost, base+"/turnos/"+strconv.Itoa(int(baja.TurnosAReasignar[0].ID))+"/reasignar", ReasignarTurnoRequest{})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Reasignar: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var reasignado db.Turno
+	json.Unmarshal(rec.Body.Bytes(), &reasignado)
+	if reasignado.BarberoID != otroID {
+		t.Errorf("El turno debería pasar al otro barbero, quedó en %d", reasignado.BarberoID)
+	}
+
+	// Baja cancelando: el cliente recibe el aviso
+	rec = staffTest(t, h, bh, http.MethodPost, base+"/barberos/"+strconv.Itoa(int(otroID))+"/desactivar", DesactivarBarberoRequest{TurnosFuturos: TurnosCancelar})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Desactivar cancelando: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	json.Unmarshal(rec.Body.Bytes(), &baja)
+	if len(baja.TurnosCancelados) != 1 || estadoTurno(baja.TurnosCancelados[0]) != EstadoCancelado {
+		t.Errorf("Se esperaba un turno cancelado: %+v", baja.TurnosCancelados)
+	}
+	if len(notificador.avisos) != 1 || notificador.avisos[0].Telefono != "+541144445555" {
+		t.Errorf("Se esperaba un aviso al cliente: %+v", notificador.avisos)
+	}
+
+	rec = staffTest(t, h, bh, http.MethodPost, base+"/barberos/"+strconv.Itoa(int(barberoID))+"/reactivar", nil)
+	var reactivado BarberoResponse
+	json.Unmarshal(rec.Body.Bytes(), &reactivado)
+	if rec.Code != http.StatusOK || !reactivado.Activo {
+		t.Errorf("Reactivar: se esperaba 200 y activo, pero se obtuvo %d %+v", rec.Code, reactivado)
+	}
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// diaCompleto es el rango que ocupa un bloqueo sin horas (00:00 a 24:00)
+var diaCompleto = rango{
+	Inicio: time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC),
+	Fin:    time.Date(0, 1, 2, 0, 0, 0, 0, time.UTC),
+}
+
+// bloqueoAplica indica si el bloqueo cubre la fecha. Los recurrentes comparan
+// sólo día y mes (y contemplan períodos que cruzan el fin de año).
+func bloqueoAplica(b db.Bloqueo, fecha time.Time) bool {
+	if fecha.Before(b.FechaDesde) {
+		return false
+	}
+	if !b.RecurrenteAnual {
+		return !fecha.After(b.FechaHasta)
+	}
+
+	mesDia := func(t time.Time) int { return int(t.Month())*100 + t.Day() }
+	desde, hasta, f := mesDia(b.FechaDesde), mesDia(b.FechaHasta), mesDia(fecha)
+	if b.FechaHasta.Year() > b.FechaDesde.Year() {
+		return f >= desde || f <= hasta
+	}
+	return f >= desde && f <= hasta
+}
+
+// rangoBloqueado devuelve el rango horario que el bloqueo ocupa en un día
+func rangoBloqueado(b db.Bloqueo) rango {
+	if !b.HoraInicio.Valid {
+		return diaCompleto
+	}
+	return rango{Inicio: b.HoraInicio.Time, Fin: b.HoraFin.Time}
+}
+
+// bloqueosComoOcupados traduce los bloqueos vigentes en la fecha a rangos
+// ocupados por barbero, para que calcularSlots los trate como turnos.
+func bloqueosComoOcupados(bloqueos []db.Bloqueo, fecha time.Time, barberos []int32) []db.ListTurnosOcupadosRow {
+	var ocupados []db.ListTurnosOcupadosRow
+	for _, b := range bloqueos {
+		if !bloqueoAplica(b, fecha) {
+			continue
+		}
+		r := rangoBloqueado(b)
+		for _, id := range barberos {
+			if b.BarberoID.Valid && b.BarberoID.Int32 != id {
+				continue
+			}
+			ocupados = append(ocupados, db.ListTurnosOcupadosRow{
+				BarberoID:  id,
+				HoraInicio: r.Inicio,
+				HoraFin:    r.Fin,
+			})
+		}
+	}
+	return ocupados
+}
+
+// bloqueoQueChoca busca un bloqueo del barbero (o de la barbería) que se
+// superponga con [inicio, fin] en la fecha.
+func bloqueoQueChoca(bloqueos []db.Bloqueo, barberoID int32, fecha, inicio, fin time.Time) (db.Bloqueo, bool) {
+	for _, b := range bloqueos {
+		if b.BarberoID.Valid && b.BarberoID.Int32 != barberoID {
+			continue
+		}
+		if !bloqueoAplica(b, fecha) {
+			continue
+		}
+		r := rangoBloqueado(b)
+		if inicio.Before(r.Fin) && fin.After(r.Inicio) {
+			return b, true
+		}
+	}
+	return db.Bloqueo{}, false
+}
+
+type BloqueosHandler struct {
+	Queries *db.Queries
+}
+
+func NewBloqueosHandler(q *db.Queries) *BloqueosHandler {
+	return &BloqueosHandler{Queries: q}
+}
+
+type BloqueoRequest struct {
+	BarberoID       int32  `json:"barbero_id" validar:"min=0"`            // 0 = toda la barbería
+	FechaDesde      string `json:"fecha_desde" validar:"requerido,fecha"` // YYYY-MM-DD
+	FechaHasta      string `json:"fecha_hasta" validar:"fecha"`           // YYYY-MM-DD, vacío = mismo día
+	HoraInicio      string `json:"hora_inicio" validar:"hora"`            // HH:MM, vacío = día completo
+	HoraFin         string `json:"hora_fin" validar:"hora"`               // HH:MM
+	Motivo          string `json:"motivo" validar:"requerido,max=100"`
+	RecurrenteAnual bool   `json:"recurrente_anual"`
+}
+
+func (req *BloqueoRequest) normalizar() {
+	recortar(&req.FechaDesde, &req.FechaHasta, &req.HoraInicio, &req.HoraFin, &req.Motivo)
+	if req.FechaHasta == "" {
+		req.FechaHasta = req.FechaDesde
+	}
+}
+
+func (req *BloqueoRequest) validarCampos() erroresCampos {
+	errs := erroresCampos{}
+	desde, _ := time.Parse("2006-01-02", req.FechaDesde)
+	hasta, _ := time.Parse("2006-01-02", req.FechaHasta)
+	if hasta.Before(desde) {
+		errs["fecha_hasta"] = "es anterior a fecha_desde"
+	} else if req.RecurrenteAnual && hasta.Sub(desde) >= 365*24*time.Hour {
+		errs["fecha_hasta"] = "un bloqueo recurrente no puede durar un año o más"
+	}
+
+	switch {
+	case req.HoraInicio != "" && req.HoraFin == "":
+		errs["hora_fin"] = "va junto con hora_inicio (o ninguna para el día completo)"
+	case req.HoraInicio == "" && req.HoraFin != "":
+		errs["hora_inicio"] = "va junto con hora_fin (o ninguna para el día completo)"
+	case req.HoraInicio != "":
+		inicio, _ := time.Parse("15:04", req.HoraInicio)
+		fin, _ := time.Parse("15:04", req.HoraFin)
+		if !inicio.Before(fin) {
+			errs["hora_fin"] = "debe ser posterior a hora_inicio"
+		}
+	}
+	return errs
+}
+
+type BloqueoResponse struct {
+	ID              int32  `json:"id"`
+	BarberoID       int32  `json:"barbero_id,omitempty"`
+	FechaDesde      string `json:"fecha_desde"`
+	FechaHasta      string `json:"fecha_hasta"`
+	HoraInicio      string `json:"hora_inicio,omitempty"`
+	HoraFin         string `json:"hora_fin,omitempty"`
+	Motivo          string `json:"motivo"`
+	RecurrenteAnual bool   `json:"recurrente_anual"`
+}
+
+func toBloqueoResponse(b db.Bloqueo) BloqueoResponse {
+	resp := BloqueoResponse{
+		ID:              b.ID,
+		BarberoID:       b.BarberoID.Int32,
+		FechaDesde:      b.FechaDesde.Format("2006-01-02"),
+		FechaHasta:      b.FechaHasta.Format("2006-01-02"),
+		Motivo:          b.Motivo,
+		RecurrenteAnual: b.RecurrenteAnual,
+	}
+	if b.HoraInicio.Valid {
+		resp.HoraInicio = b.HoraInicio.Time.Format("15:04")
+		resp.HoraFin = b.HoraFin.Time.Format("15:04")
+	}
+	return resp
+}
+
+// bloqueoParams convierte un request ya validado a los parámetros de SQLC
+func bloqueoParams(req BloqueoRequest) db.CreateBloqueoParams {
+	p := db.CreateBloqueoParams{
+		BarberoID:       sql.NullInt32{Int32: req.BarberoID, Valid: req.BarberoID != 0},
+		Motivo:          req.Motivo,
+		RecurrenteAnual: req.RecurrenteAnual,
+	}
+	p.FechaDesde, _ = time.Parse("2006-01-02", req.FechaDesde)
+	p.FechaHasta, _ = time.Parse("2006-01-02", req.FechaHasta)
+	if req.HoraInicio != "" {
+		inicio, _ := time.Parse("15:04", req.HoraInicio)
+		fin, _ := time.Parse("15:04", req.HoraFin)
+		p.HoraInicio = sql.NullTime{Time: inicio, Valid: true}
+		p.HoraFin = sql.NullTime{Time: fin, Valid: true}
+	}
+	return p
+}
+
+func (h *BloqueosHandler) ListBloqueos(w http.ResponseWriter, r *http.Request) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	bloqueos, err := h.Queries.ListBloqueos(r.Context(), barberia.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo bloqueos")
+		return
+	}
+
+	resp := make([]BloqueoResponse, 0, len(bloqueos))
+	for _, b := range bloqueos {
+		resp = append(resp, toBloqueoResponse(b))
+	}
+	writeJSON(w, resp)
+}
+
+func (h *BloqueosHandler) CreateBloqueo(w http.ResponseWriter, r *http.Request) {
+	barberia, params, ok := h.leerBloqueo(w, r)
+	if !ok {
+		return
+	}
+
+	params.BarberiaID = barberia.ID
+	bloqueo, err := h.Queries.CreateBloqueo(r.Context(), params)
+	if err != nil {
+		errorInterno(w, r, err, "Error creando bloqueo")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toBloqueoResponse(bloqueo))
+}
+
+func (h *BloqueosHandler) UpdateBloqueo(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de bloqueo inválido")
+		return
+	}
+
+	barberia, params, ok := h.leerBloqueo(w, r)
+	if !ok {
+		return
+	}
+	if !h.bloqueoPropio(w, r, barberia.ID, int32(id)) {
+		return
+	}
+
+	bloqueo, err := h.Queries.UpdateBloqueo(r.Context(), db.UpdateBloqueoParams{
+		ID:              int32(id),
+		BarberiaID:      barberia.ID,
+		BarberoID:       params.BarberoID,
+		FechaDesde:      params.FechaDesde,
+		FechaHasta:      params.FechaHasta,
+		HoraInicio:      params.HoraInicio,
+		HoraFin:         params.HoraFin,
+		Motivo:          params.Motivo,
+		RecurrenteAnual: params.RecurrenteAnual,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoBloqueoNoEncontrado, "Bloqueo no encontrado")
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error actualizando bloqueo")
+		return
+	}
+
+	writeJSON(w, toBloqueoResponse(bloqueo))
+}
+
+func (h *BloqueosHandler) DeleteBloqueo(w http.ResponseWriter, r *http.Request) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de bloqueo inválido")
+		return
+	}
+	if !h.bloqueoPropio(w, r, barberia.ID, int32(id)) {
+		return
+	}
+
+	n, err := h.Queries.DeleteBloqueo(r.Context(), db.DeleteBloqueoParams{
+		ID:         int32(id),
+		BarberiaID: barberia.ID,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error borrando bloqueo")
+		return
+	}
+	if n == 0 {
+		responderError(w, r, http.StatusNotFound, CodigoBloqueoNoEncontrado, "Bloqueo no encontrado")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// leerBloqueo resuelve la barbería, decodifica y valida el body, y verifica
+// que el barbero (si se indicó) pertenezca a la barbería.
+func (h *BloqueosHandler) leerBloqueo(w http.ResponseWriter, r *http.Request) (db.Barberia, db.CreateBloqueoParams, bool) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return db.Barberia{}, db.CreateBloqueoParams{}, false
+	}
+
+	var req BloqueoRequest
+	if !decodificarJSON(w, r, &req) {
+		return db.Barberia{}, db.CreateBloqueoParams{}, false
+	}
+	params := bloqueoParams(req)
+
+	// Un barbero sólo bloquea su propia agenda, nunca la barbería entera
+	if !esAdmin(r) && !(params.BarberoID.Valid && puedeGestionarBarbero(r, params.BarberoID.Int32)) {
+		responderError(w, r, http.StatusForbidden, CodigoSinPermiso, "Sólo podés bloquear tu propia agenda")
+		return db.Barberia{}, db.CreateBloqueoParams{}, false
+	}
+
+	if params.BarberoID.Valid {
+		barberos, err := h.Queries.ListBarberosByBarberia(r.Context(), barberia.ID)
+		if err != nil {
+			errorInterno(w, r, err, "Error obteniendo barberos")
+			return db.Barberia{}, db.CreateBloqueoParams{}, false
+		}
+		encontrado := false
+		for _, b := range barberos {
+			encontrado = encontrado || b.ID == params.BarberoID.Int32
+		}
+		if !encontrado {
+			responderError(w, r, http.StatusNotFound, CodigoBarberoNoEncontrado, "Barbero no encontrado")
+			return db.Barberia{}, db.CreateBloqueoParams{}, false
+		}
+	}
+
+	return barberia, params, true
+}
+
+// bloqueoPropio verifica que un barbero no toque bloqueos ajenos ni los de
+// toda la barbería. Para el admin no hace falta consultar.
+func (h *BloqueosHandler) bloqueoPropio(w http.ResponseWriter, r *http.Request, barberiaID, id int32) bool {
+	if esAdmin(r) {
+		return true
+	}
+
+	bloqueo, err := h.Queries.GetBloqueo(r.Context(), db.GetBloqueoParams{ID: id, BarberiaID: barberiaID})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoBloqueoNoEncontrado, "Bloqueo no encontrado")
+		return false
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo bloqueo")
+		return false
+	}
+	if !bloqueo.BarberoID.Valid || !puedeGestionarBarbero(r, bloqueo.BarberoID.Int32) {
+		responderError(w, r, http.StatusForbidden, CodigoSinPermiso, "Sólo podés gestionar tus propios bloqueos")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ClavesJWT son las claves HMAC de firma identificadas por kid. Los tokens
+// nuevos se firman con la Activa; el resto sólo se acepta para verificar, así
+// se puede rotar la clave sin cerrar las sesiones que ya están abiertas.
+type ClavesJWT struct {
+	Activa string
+	Claves map[string][]byte
+}
+
+// clavesJWT es la configuración en uso. Empieza vacía: hasta que main llame
+// a ConfigurarClavesJWT no se firma ni se acepta ningún token.
+var clavesJWT ClavesJWT
+
+// ErrSinClavesJWT indica que no hay ninguna clave en el entorno
+var ErrSinClavesJWT = errors.New("no hay claves JWT configuradas")
+
+// largoMinimoClave evita secretos triviales en producción
+const largoMinimoClave = 32
+
+// CargarClavesJWT lee la configuración del entorno:
+//
+//	JWT_KEYS="2024a:secreto1,2025a:secreto2"  claves aceptadas por kid
+//	JWT_ACTIVE_KID="2025a"                    la que firma (por defecto la última)
+//	JWT_SECRET="secreto"                      atajo para una sola clave (kid "default")
+func CargarClavesJWT(getenv func(string) string) (ClavesJWT, error) {
+	c := ClavesJWT{Claves: map[string][]byte{}}
+
+	if lista := strings.TrimSpace(getenv("JWT_KEYS")); lista != "" {
+		for _, par := range strings.Split(lista, ",") {
+			kid, secreto, ok := strings.Cut(strings.TrimSpace(par), ":")
+			if !ok || kid == "" {
+				return ClavesJWT{}, fmt.Errorf("JWT_KEYS: entrada inválida %q, se espera kid:secreto", par)
+			}
+			if _, repetido := c.Claves[kid]; repetido {
+				return ClavesJWT{}, fmt.Errorf("JWT_KEYS: kid repetido %q", kid)
+			}
+			c.Claves[kid] = []byte(secreto)
+			c.Activa = kid
+		}
+	} else if secreto := getenv("JWT_SECRET"); secreto != "" {
+		c.Claves["default"] = []byte(secreto)
+		c.Activa = "default"
+	} else {
+		return ClavesJWT{}, ErrSinClavesJWT
+	}
+
+	if kid := getenv("JWT_ACTIVE_KID"); kid != "" {
+		if _, ok := c.Claves[kid]; !ok {
+			return ClavesJWT{}, fmt.Errorf("JWT_ACTIVE_KID %q no está en JWT_KEYS", kid)
+		}
+		c.Activa = kid
+	}
+
+	for kid, secreto := range c.Claves {
+		if len(secreto) < largoMinimoClave {
+			return ClavesJWT{}, fmt.Errorf("la clave %q debe tener al menos %d caracteres", kid, largoMinimoClave)
+		}
+	}
+
+	return c, nil
+}
+
+// ClavesDesarrollo genera una clave al azar para correr sin JWT_KEYS ni
+// JWT_SECRET en desarrollo. Cambia en cada arranque, así que las sesiones no
+// sobreviven a un reinicio, y nunca hay una clave conocida aceptando tokens.
+func ClavesDesarrollo() ClavesJWT {
+	secreto := make([]byte, largoMinimoClave)
+	rand.Read(secreto)
+	return ClavesJWT{Activa: "dev", Claves: map[string][]byte{"dev": secreto}}
+}
+
+// ConfigurarClavesJWT reemplaza las claves en uso
+func ConfigurarClavesJWT(c ClavesJWT) {
+	clavesJWT = c
+}
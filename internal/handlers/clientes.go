@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/telefono"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ClientesHandler struct {
+	Queries *db.Queries
+	DB      *sql.DB
+}
+
+func NewClientesHandler(q *db.Queries, conn *sql.DB) *ClientesHandler {
+	return &ClientesHandler{Queries: q, DB: conn}
+}
+
+var errPaisNoSoportado = errors.New("pais debe ser uno de: " + strings.Join(telefono.Paises(), ", "))
+
+func normalizarEmail(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// resolverCliente busca al cliente por teléfono y después por email, lo crea
+// si no existe y le completa el dato de contacto que le faltaba. Sin teléfono
+// ni email no hay forma de reconocerlo y devuelve NULL. Recibe los datos ya
+// normalizados. Si otra reserva crea al mismo cliente entre la búsqueda y el
+// alta, el alta no inserta nada y se vuelve a buscar.
+func resolverCliente(ctx context.Context, q *db.Queries, barberiaID int32, nombre, telefono, email string) (sql.NullInt32, error) {
+	tel, mail := toNullString(telefono), toNullString(email)
+	if !tel.Valid && !mail.Valid {
+		return sql.NullInt32{}, nil
+	}
+
+	var cliente db.Cliente
+	encontrado, emailAjeno := false, false
+	if tel.Valid {
+		c, err := q.GetClienteByTelefono(ctx, db.GetClienteByTelefonoParams{BarberiaID: barberiaID, Telefono: tel})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return sql.NullInt32{}, err
+		}
+		cliente, encontrado = c, err == nil
+	}
+	if mail.Valid {
+		c, err := q.GetClienteByEmail(ctx, db.GetClienteByEmailParams{BarberiaID: barberiaID, Email: mail})
+		switch {
+		case err != nil && !errors.Is(err, sql.ErrNoRows):
+			return sql.NullInt32{}, err
+		case err == nil && !encontrado:
+			cliente, encontrado = c, true
+		case err == nil:
+			// El email es de otro cliente: no se fusionan solos, eso lo decide el staff
+			emailAjeno = c.ID != cliente.ID
+		}
+	}
+
+	if !encontrado {
+		c, err := q.CreateCliente(ctx, db.CreateClienteParams{
+			BarberiaID: barberiaID,
+			Nombre:     nombre,
+			Telefono:   tel,
+			Email:      mail,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return resolverCliente(ctx, q, barberiaID, nombre, telefono, email)
+		}
+		if err != nil {
+			return sql.NullInt32{}, err
+		}
+		return sql.NullInt32{Int32: c.ID, Valid: true}, nil
+	}
+
+	completar := db.CompletarContactoClienteParams{ID: cliente.ID, BarberiaID: barberiaID}
+	if tel.Valid && !cliente.Telefono.Valid {
+		completar.Telefono = tel
+	}
+	if mail.Valid && !cliente.Email.Valid && !emailAjeno {
+		completar.Email = mail
+	}
+	if completar.Telefono.Valid || completar.Email.Valid {
+		if _, err := q.CompletarContactoCliente(ctx, completar); err != nil {
+			return sql.NullInt32{}, err
+		}
+	}
+	return sql.NullInt32{Int32: cliente.ID, Valid: true}, nil
+}
+
+// ClienteResponse es el cliente sin los tipos nulos de SQLC
+type ClienteResponse struct {
+	ID       int32     `json:"id"`
+	Nombre   string    `json:"nombre"`
+	Telefono string    `json:"telefono,omitempty"`
+	Email    string    `json:"email,omitempty"`
+	CreadoEn time.Time `json:"creado_en"`
+}
+
+func toClienteResponse(c db.Cliente) ClienteResponse {
+	return ClienteResponse{
+		ID:       c.ID,
+		Nombre:   c.Nombre,
+		Telefono: c.Telefono.String,
+		Email:    c.Email.String,
+		CreadoEn: c.CreadoEn,
+	}
+}
+
+// ClienteDetalle suma al cliente sus números: turnos por estado y gasto
+// total en turnos completados
+type ClienteDetalle struct {
+	ClienteResponse
+	TotalTurnos int64  `json:"total_turnos"`
+	Completados int64  `json:"completados"`
+	NoAsistio   int64  `json:"no_asistio"`
+	Cancelados  int64  `json:"cancelados"`
+	GastoTotal  string `json:"gasto_total"`
+}
+
+// TurnoHistorial es un turno en la historia del cliente
+type TurnoHistorial struct {
+	ID             int32  `json:"id"`
+	Fecha          string `json:"fecha"`       // YYYY-MM-DD
+	HoraInicio     string `json:"hora_inicio"` // HH:MM
+	HoraFin        string `json:"hora_fin"`    // HH:MM
+	Estado         string `json:"estado"`
+	ServicioID     int32  `json:"servicio_id"`
+	ServicioNombre string `json:"servicio_nombre"`
+	BarberoID      int32  `json:"barbero_id"`
+	BarberoNombre  string `json:"barbero_nombre"`
+	Precio         string `json:"precio"`
+}
+
+// FusionarClientesRequest indica el cliente duplicado que se absorbe
+type FusionarClientesRequest struct {
+	DuplicadoID int32 `json:"duplicado_id" validar:"requerido"`
+}
+
+const (
+	limiteBusquedaClientes    = 50
+	limiteBusquedaClientesMax = 200
+)
+
+// ListClientes busca clientes por nombre, teléfono o email (?q=). Sin q
+// lista los primeros por nombre; ?limit= acota la cantidad.
+func (h *ClientesHandler) ListClientes(w http.ResponseWriter, r *http.Request) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	limite := limiteBusquedaClientes
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limite, err = strconv.Atoi(s)
+		if err != nil || limite < 1 || limite > limiteBusquedaClientesMax {
+			responderError(w, r, http.StatusBadRequest, CodigoValidacion, "limit debe estar entre 1 y "+strconv.Itoa(limiteBusquedaClientesMax))
+			return
+		}
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	clientes, err := h.Queries.SearchClientes(r.Context(), db.SearchClientesParams{
+		BarberiaID: barberia.ID,
+		Q:          escaparLike(q),
+		Telefono:   telefonoBuscado(q, barberia.Pais),
+		Limite:     int32(limite),
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error buscando clientes")
+		return
+	}
+
+	resp := make([]ClienteResponse, 0, len(clientes))
+	for _, c := range clientes {
+		resp = append(resp, toClienteResponse(c))
+	}
+	writeJSON(w, resp)
+}
+
+// GetCliente devuelve el cliente con sus estadísticas
+func (h *ClientesHandler) GetCliente(w http.ResponseWriter, r *http.Request) {
+	barberia, cliente, ok := h.clienteDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	detalle, err := detalleCliente(r.Context(), h.Queries, barberia.ID, cliente)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo estadísticas del cliente")
+		return
+	}
+	writeJSON(w, detalle)
+}
+
+// ListTurnosCliente devuelve la historia de turnos del cliente, del más nuevo al más viejo
+func (h *ClientesHandler) ListTurnosCliente(w http.ResponseWriter, r *http.Request) {
+	barberia, cliente, ok := h.clienteDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	turnos, err := h.Queries.ListTurnosCliente(r.Context(), db.ListTurnosClienteParams{
+		BarberiaID: barberia.ID,
+		ClienteID:  sql.NullInt32{Int32: cliente.ID, Valid: true},
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo turnos del cliente")
+		return
+	}
+
+	historial := make([]TurnoHistorial, 0, len(turnos))
+	for _, t := range turnos {
+		historial = append(historial, TurnoHistorial{
+			ID:             t.ID,
+			Fecha:          t.Fecha.Format("2006-01-02"),
+			HoraInicio:     t.HoraInicio.Format("15:04"),
+			HoraFin:        t.HoraFin.Format("15:04"),
+			Estado:         t.Estado.String,
+			ServicioID:     t.ServicioID,
+			ServicioNombre: t.ServicioNombre,
+			BarberoID:      t.BarberoID,
+			BarberoNombre:  t.BarberoNombre,
+			Precio:         t.Precio,
+		})
+	}
+	writeJSON(w, historial)
+}
+
+// FusionarClientes pasa los turnos del duplicado al cliente de la URL, le
+// completa el teléfono o el email que le faltaran y borra el duplicado.
+func (h *ClientesHandler) FusionarClientes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, cliente, ok := h.clienteDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req FusionarClientesRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+	if req.DuplicadoID == cliente.ID {
+		responderCampos(w, r, erroresCampos{"duplicado_id": "no se puede fusionar un cliente consigo mismo"})
+		return
+	}
+
+	duplicado, err := h.Queries.GetCliente(ctx, db.GetClienteParams{ID: req.DuplicadoID, BarberiaID: barberia.ID})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoClienteNoEncontrado, "Cliente duplicado no encontrado")
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo cliente")
+		return
+	}
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		errorInterno(w, r, err, "Error fusionando clientes")
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	_, err = qtx.MoverTurnosCliente(ctx, db.MoverTurnosClienteParams{
+		ClienteID:   sql.NullInt32{Int32: cliente.ID, Valid: true},
+		BarberiaID:  barberia.ID,
+		DuplicadoID: sql.NullInt32{Int32: duplicado.ID, Valid: true},
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error fusionando clientes")
+		return
+	}
+
+	// Primero se borra el duplicado para que su teléfono y email queden libres
+	if err := qtx.DeleteCliente(ctx, db.DeleteClienteParams{ID: duplicado.ID, BarberiaID: barberia.ID}); err != nil {
+		errorInterno(w, r, err, "Error fusionando clientes")
+		return
+	}
+	cliente, err = qtx.CompletarContactoCliente(ctx, db.CompletarContactoClienteParams{
+		Telefono:   duplicado.Telefono,
+		Email:      duplicado.Email,
+		ID:         cliente.ID,
+		BarberiaID: barberia.ID,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error fusionando clientes")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		errorInterno(w, r, err, "Error fusionando clientes")
+		return
+	}
+
+	detalle, err := detalleCliente(ctx, h.Queries, barberia.ID, cliente)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo estadísticas del cliente")
+		return
+	}
+	writeJSON(w, detalle)
+}
+
+func detalleCliente(ctx context.Context, q *db.Queries, barberiaID int32, cliente db.Cliente) (ClienteDetalle, error) {
+	stats, err := q.GetClienteEstadisticas(ctx, db.GetClienteEstadisticasParams{
+		BarberiaID: barberiaID,
+		ClienteID:  sql.NullInt32{Int32: cliente.ID, Valid: true},
+	})
+	if err != nil {
+		return ClienteDetalle{}, err
+	}
+	return ClienteDetalle{
+		ClienteResponse: toClienteResponse(cliente),
+		TotalTurnos:     stats.TotalTurnos,
+		Completados:     stats.Completados,
+		NoAsistio:       stats.NoAsistio,
+		Cancelados:      stats.Cancelados,
+		GastoTotal:      stats.GastoTotal,
+	}, nil
+}
+
+// clienteDeURL resuelve {slug} y {id}. Un cliente de otra barbería responde 404.
+func (h *ClientesHandler) clienteDeURL(w http.ResponseWriter, r *http.Request) (db.Barberia, db.Cliente, bool) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return db.Barberia{}, db.Cliente{}, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de cliente inválido")
+		return db.Barberia{}, db.Cliente{}, false
+	}
+
+	cliente, err := h.Queries.GetCliente(r.Context(), db.GetClienteParams{ID: int32(id), BarberiaID: barberia.ID})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoClienteNoEncontrado, "Cliente no encontrado")
+		return db.Barberia{}, db.Cliente{}, false
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo cliente")
+		return db.Barberia{}, db.Cliente{}, false
+	}
+
+	return barberia, cliente, true
+}
+
+// telefonoBuscado son los dígitos con los que buscar q entre los teléfonos
+// guardados en E.164 ("" si q no parece un teléfono). Si q es un número
+// completo se normaliza, para encontrarlo aunque se busque con el 0 o el 15;
+// si no, se buscan sus dígitos tal cual (una parte del número, por ejemplo).
+func telefonoBuscado(q, pais string) string {
+	if q == "" || strings.Trim(q, "0123456789+-(). ") != "" {
+		return ""
+	}
+	if e164, err := telefono.Normalizar(q, pais); err == nil {
+		return strings.TrimPrefix(e164, "+")
+	}
+	return strings.Map(func(c rune) rune {
+		if c < '0' || c > '9' {
+			return -1
+		}
+		return c
+	}, q)
+}
+
+// escaparLike evita que % y _ del texto buscado actúen como comodines
+var escaparLike = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestTelefonoBuscado tests qué dígitos se buscan entre los teléfonos guardados en E.164
+func TestTelefonoBuscado(t *testing.T) {
+	casos := map[string]string{
+		"011 15 4444-5555": "5491144445555", // número completo: se normaliza
+		"4444-5555":        "44445555",      // parte del número: dígitos tal cual
+		"+54 9 11":         "54911",
+		"Pedro":            "",
+		"":                 "",
+	}
+	for q, want := range casos {
+		if got := telefonoBuscado(q, "AR"); got != want {
+			t.Errorf("telefonoBuscado(%q) = %q, se esperaba %q", q, got, want)
+		}
+	}
+}
+
+// TestEscaparLike tests que los comodines de LIKE se busquen literalmente
+func TestEscaparLike(t *testing.T) {
+	if got := escaparLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("Se obtuvo %q", got)
+	}
+}
+
+// clientesTest llama a los endpoints de clientes como los monta rutasAPI
+func clientesTest(t *testing.T, h *ClientesHandler, metodo, ruta string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	return pedirTest(t, func(r chi.Router) {
+		r.Get("/b/{slug}/clientes", h.ListClientes)
+		r.Get("/b/{slug}/clientes/{id}", h.GetCliente)
+		r.Get("/b/{slug}/clientes/{id}/turnos", h.ListTurnosCliente)
+		r.Post("/b/{slug}/clientes/{id}/fusionar", h.FusionarClientes)
+	}, metodo, ruta, body)
+}
+
+// TestClientes_HistorialYFusion cubre el reconocimiento del cliente al
+// reservar, sus estadísticas, la búsqueda y la fusión de un duplicado
+func TestClientes_HistorialYFusion(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	q := db.New(conn)
+	bh := NewBarberiaHandler(q, conn)
+	h := NewClientesHandler(q, conn)
+	ctx := context.Background()
+	base := "/b/" + barberia.Slug
+
+	reservar := func(hora, telefono, email string) ReservaResponse {
+		t.Helper()
+		rec := reservarTest(t, bh, barberia.Slug, CreateReservaRequest{
+			ServicioID:      servicio.ID,
+			BarberoID:       barberoID,
+			Fecha:           diaReservaTest(),
+			HoraInicio:      hora,
+			ClienteNombre:   "Pedro",
+			ClienteTelefono: telefono,
+			ClienteEmail:    email,
+		})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Reserva %s: se esperaba 201, pero se obtuvo %d: %s", hora, rec.Code, rec.Body.String())
+		}
+		var resp ReservaResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+
+	// El mismo teléfono escrito de dos formas es el mismo cliente
+	primera := reservar("10:00", "11 4444-5555", "")
+	segunda := reservar("11:00", "(11) 4444 5555", "")
+	if !primera.ClienteID.Valid || primera.ClienteID != segunda.ClienteID {
+		t.Fatalf("Se esperaba el mismo cliente, se obtuvo %v y %v", primera.ClienteID, segunda.ClienteID)
+	}
+	if primera.Precio.String != "10.00" {
+		t.Errorf("El turno debería guardar el precio del servicio, se obtuvo %q", primera.Precio.String)
+	}
+	clienteID := strconv.Itoa(int(primera.ClienteID.Int32))
+
+	for turno, estado := range map[int32]string{primera.ID: EstadoCompletado, segunda.ID: EstadoNoAsistio} {
+		_, err := q.UpdateTurnoEstado(ctx, db.UpdateTurnoEstadoParams{
+			EstadoNuevo:  toNullString(estado),
+			ID:           turno,
+			BarberiaID:   barberia.ID,
+			EstadoActual: EstadoPendiente,
+		})
+		if err != nil {
+			t.Fatalf("Error cambiando el estado del turno %d: %v", turno, err)
+		}
+	}
+
+	rec := clientesTest(t, h, http.MethodGet, base+"/clientes/"+clienteID, nil)
+	var detalle ClienteDetalle
+	json.Unmarshal(rec.Body.Bytes(), &detalle)
+	if rec.Code != http.StatusOK || detalle.TotalTurnos != 2 || detalle.Completados != 1 || detalle.NoAsistio != 1 || detalle.GastoTotal != "10.00" {
+		t.Fatalf("Detalle inesperado (%d): %s", rec.Code, rec.Body.String())
+	}
+
+	rec = clientesTest(t, h, http.MethodGet, base+"/clientes?q=4444-5555", nil)
+	var encontrados []ClienteResponse
+	json.Unmarshal(rec.Body.Bytes(), &encontrados)
+	if len(encontrados) != 1 || encontrados[0].ID != primera.ClienteID.Int32 {
+		t.Errorf("La búsqueda por teléfono debería encontrar al cliente: %s", rec.Body.String())
+	}
+
+	// Una reserva sólo con email crea otro cliente; el admin los fusiona
+	duplicado := reservar("12:00", "", "Pedro@Mail.com")
+	if duplicado.ClienteID == primera.ClienteID {
+		t.Fatal("Sin teléfono no hay forma de reconocer al cliente")
+	}
+	rec = clientesTest(t, h, http.MethodPost, base+"/clientes/"+clienteID+"/fusionar", FusionarClientesRequest{DuplicadoID: duplicado.ClienteID.Int32})
+	json.Unmarshal(rec.Body.Bytes(), &detalle)
+	if rec.Code != http.StatusOK || detalle.TotalTurnos != 3 || detalle.Email != "pedro@mail.com" {
+		t.Fatalf("Fusión inesperada (%d): %s", rec.Code, rec.Body.String())
+	}
+
+	rec = clientesTest(t, h, http.MethodGet, base+"/clientes/"+strconv.Itoa(int(duplicado.ClienteID.Int32)), nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("El duplicado debería haberse borrado, se obtuvo %d", rec.Code)
+	}
+
+	rec = clientesTest(t, h, http.MethodGet, base+"/clientes/"+clienteID+"/turnos", nil)
+	var historial []TurnoHistorial
+	json.Unmarshal(rec.Body.Bytes(), &historial)
+	if len(historial) != 3 || historial[0].HoraInicio != "12:00" {
+		t.Errorf("Historial inesperado: %s", rec.Body.String())
+	}
+}
+
+// TestResolverCliente_Concurrente tests que dos reservas que crean al mismo
+// cliente a la vez terminen con un solo cliente y sin error
+func TestResolverCliente_Concurrente(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, _, _ := fixtureBarberia(t, conn)
+	q := db.New(conn)
+	ctx := context.Background()
+
+	resolver := func(tx *sql.Tx) (sql.NullInt32, error) {
+		return resolverCliente(ctx, q.WithTx(tx), barberia.ID, "Pedro", "+5491144445555", "")
+	}
+
+	primera, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Error abriendo transacción: %v", err)
+	}
+	defer primera.Rollback()
+	id, err := resolver(primera)
+	if err != nil {
+		t.Fatalf("Primera: %v", err)
+	}
+
+	// La segunda no ve al cliente sin confirmar y queda esperando en el alta
+	segunda, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Error abriendo transacción: %v", err)
+	}
+	defer segunda.Rollback()
+	type resultado struct {
+		id  sql.NullInt32
+		err error
+	}
+	hecho := make(chan resultado)
+	go func() {
+		id, err := resolver(segunda)
+		hecho <- resultado{id, err}
+	}()
+
+	time.Sleep(100 * time.Millisecond) // que llegue a esperar en el alta
+	if err := primera.Commit(); err != nil {
+		t.Fatalf("Error confirmando: %v", err)
+	}
+	res := <-hecho
+	if res.err != nil || res.id != id {
+		t.Errorf("Se esperaba el cliente %v, pero se obtuvo %v (%v)", id, res.id, res.err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	db "agendaFacil/db/sqlc"
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
@@ -11,8 +12,9 @@ import (
 )
 
 type Slot struct {
-	Inicio string `json:"inicio"`
-	Fin    string `json:"fin"`
+	Inicio   string  `json:"inicio"`
+	Fin      string  `json:"fin"`
+	Barberos []int32 `json:"barberos,omitempty"` // Barberos libres en ese horario
 }
 
 func (h *BarberiaHandler) GetDisponibilidad(w http.ResponseWriter, r *http.Request) {
@@ -23,31 +25,120 @@ func (h *BarberiaHandler) GetDisponibilidad(w http.ResponseWriter, r *http.Reque
 	servicioIDStr := r.URL.Query().Get("servicio_id")
 
 	if fechaStr == "" || servicioIDStr == "" {
-		http.Error(w, "faltan parametros", http.StatusBadRequest)
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "faltan parametros")
 		return
 	}
 
 	fecha, err := time.Parse("2006-01-02", fechaStr)
 	if err != nil {
-		http.Error(w, "fecha invalida", http.StatusBadRequest)
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "fecha invalida")
 		return
 	}
 
 	servicioID, err := strconv.Atoi(servicioIDStr)
 	if err != nil {
-		http.Error(w, "servicio_id invalido", http.StatusBadRequest)
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "servicio_id invalido")
 		return
 	}
 
 	barberia, err := h.Queries.GetBarberiaBySlug(ctx, slug)
 	if err != nil {
-		http.Error(w, "barberia no encontrada", http.StatusNotFound)
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "barberia no encontrada")
 		return
 	}
 
 	servicio, err := h.Queries.GetServicioByID(ctx, int32(servicioID))
+	if err != nil || servicio.BarberiaID != barberia.ID {
+		responderError(w, r, http.StatusNotFound, CodigoServicioNoEncontrado, "servicio no encontrado")
+		return
+	}
+
+	antes, despues := buffersServicio(barberia, servicio)
+
+	barberos, err := h.Queries.ListBarberosByBarberia(ctx, barberia.ID)
 	if err != nil {
-		http.Error(w, "servicio no encontrado", http.StatusNotFound)
+		errorInterno(w, r, err, "error obteniendo barberos")
+		return
+	}
+
+	ids := make([]int32, 0, len(barberos))
+	for _, b := range barberos {
+		ids = append(ids, b.ID)
+	}
+
+	// Sólo los barberos que hacen el servicio, cada uno con su duración
+	condiciones, err := condicionesServicio(ctx, h.Queries, servicio, ids)
+	if err != nil {
+		errorInterno(w, r, err, "error obteniendo barberos")
+		return
+	}
+	duraciones := make(map[int32]int32, len(condiciones))
+	habilitados := make([]int32, 0, len(condiciones))
+	for _, id := range ids {
+		if c, ok := condiciones[id]; ok {
+			duraciones[id] = c.Duracion
+			habilitados = append(habilitados, id)
+		}
+	}
+
+	horarios, err := h.Queries.ListHorarios(ctx, barberia.ID)
+	if err != nil {
+		errorInterno(w, r, err, "error obteniendo horarios")
+		return
+	}
+
+	bloqueos, err := h.Queries.ListBloqueosByFecha(ctx, db.ListBloqueosByFechaParams{
+		BarberiaID: barberia.ID,
+		Fecha:      fecha,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "error obteniendo bloqueos")
+		return
+	}
+
+	// Filtro opcional por barbero: sólo cuentan sus propios turnos
+	if barberoIDStr := r.URL.Query().Get("barbero_id"); barberoIDStr != "" && barberoIDStr != "0" {
+		barberoID, err := strconv.Atoi(barberoIDStr)
+		if err != nil {
+			responderError(w, r, http.StatusBadRequest, CodigoValidacion, "barbero_id invalido")
+			return
+		}
+		if !contiene(ids, int32(barberoID)) {
+			responderError(w, r, http.StatusNotFound, CodigoBarberoNoEncontrado, "barbero no encontrado")
+			return
+		}
+		if !contiene(habilitados, int32(barberoID)) {
+			responderError(w, r, http.StatusBadRequest, CodigoServicioNoHabilitado, "el barbero no realiza ese servicio")
+			return
+		}
+
+		turnos, err := h.Queries.ListTurnosByFechaAndBarbero(ctx, db.ListTurnosByFechaAndBarberoParams{
+			BarberiaID: barberia.ID,
+			Fecha:      fecha,
+			BarberoID:  int32(barberoID),
+		})
+		if err != nil {
+			errorInterno(w, r, err, "error obteniendo turnos")
+			return
+		}
+
+		ocupados := make([]db.ListTurnosOcupadosRow, 0, len(turnos))
+		for _, t := range turnos {
+			ocupados = append(ocupados, db.ListTurnosOcupadosRow{
+				BarberoID:  t.BarberoID,
+				HoraInicio: t.OcupadoInicio,
+				HoraFin:    t.OcupadoFin,
+			})
+		}
+		ocupados = expandirOcupados(ocupados, antes, despues)
+		ocupados = append(ocupados, bloqueosComoOcupados(bloqueos, fecha, []int32{int32(barberoID)})...)
+
+		writeJSON(w, slotsReservables(barberia, fecha, ahora(), calcularSlotsEnRangos(
+			rangosDelDia(barberia, horarios, int32(barberoID), fecha.Weekday()),
+			duraciones[int32(barberoID)],
+			barberia.IntervaloMinutos,
+			ocupados,
+		)))
 		return
 	}
 
@@ -59,18 +150,21 @@ func (h *BarberiaHandler) GetDisponibilidad(w http.ResponseWriter, r *http.Reque
 		},
 	)
 	if err != nil {
-		http.Error(w, "error obteniendo turnos", http.StatusInternalServerError)
+		errorInterno(w, r, err, "error obteniendo turnos")
 		return
 	}
 
-	slots := calcularSlots(
-		barberia.HoraApertura,
-		barberia.HoraCierre,
-		servicio.DuracionMinutos,
-		ocupados,
-	)
+	ocupados = expandirOcupados(ocupados, antes, despues)
+	ocupados = append(ocupados, bloqueosComoOcupados(bloqueos, fecha, habilitados)...)
+
+	rangos := make(map[int32][]rango, len(habilitados))
+	for _, id := range habilitados {
+		rangos[id] = rangosDelDia(barberia, horarios, id, fecha.Weekday())
+	}
+
+	slots := calcularSlotsPorDuracion(habilitados, duraciones, rangos, barberia.IntervaloMinutos, ocupados)
 
-	writeJSON(w, slots)
+	writeJSON(w, slotsReservables(barberia, fecha, ahora(), slots))
 }
 
 func writeJSON(w http.ResponseWriter, data any) {
@@ -78,15 +172,23 @@ func writeJSON(w http.ResponseWriter, data any) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// calcularSlots genera inicios candidatos cada `paso` minutos desde la
+// apertura y se queda con los que tienen libre la duración completa del
+// servicio. Con paso <= 0 se avanza de a una duración de servicio.
 func calcularSlots(
 	apertura time.Time,
 	cierre time.Time,
 	duracion int32,
+	paso int32,
 	ocupados []db.ListTurnosOcupadosRow,
 ) []Slot {
 
 	var disponibles []Slot
 	slotDur := time.Duration(duracion) * time.Minute
+	pasoDur := pasoSlots(duracion, paso)
+	if slotDur <= 0 || pasoDur <= 0 {
+		return disponibles
+	}
 
 	actual := apertura
 
@@ -100,11 +202,21 @@ func calcularSlots(
 			})
 		}
 
-		actual = actual.Add(slotDur)
+		actual = actual.Add(pasoDur)
 	}
 
 	return disponibles
 }
+
+// pasoSlots es la grilla de inicios: el intervalo de la barbería o, si no
+// está configurado, la duración del servicio.
+func pasoSlots(duracion, paso int32) time.Duration {
+	if paso <= 0 {
+		paso = duracion
+	}
+	return time.Duration(paso) * time.Minute
+}
+
 func choca(inicio, fin time.Time, ocupados []db.ListTurnosOcupadosRow) bool {
 	for _, t := range ocupados {
 		if inicio.Before(t.HoraFin) && fin.After(t.HoraInicio) {
@@ -113,3 +225,176 @@ func choca(inicio, fin time.Time, ocupados []db.ListTurnosOcupadosRow) bool {
 	}
 	return false
 }
+
+// buffersServicio devuelve el tiempo de preparación y de limpieza del
+// servicio, o el default de la barbería si el servicio no define el suyo.
+func buffersServicio(barberia db.Barberia, servicio db.Servicio) (antes, despues time.Duration) {
+	antesMin, despuesMin := barberia.BufferAntesMinutos, barberia.BufferDespuesMinutos
+	if servicio.BufferAntesMinutos.Valid {
+		antesMin = servicio.BufferAntesMinutos.Int32
+	}
+	if servicio.BufferDespuesMinutos.Valid {
+		despuesMin = servicio.BufferDespuesMinutos.Int32
+	}
+	return time.Duration(antesMin) * time.Minute, time.Duration(despuesMin) * time.Minute
+}
+
+// rangoOcupado extiende el turno con sus buffers, recortado al mismo día
+// para que siga entrando en una columna TIME.
+func rangoOcupado(inicio, fin time.Time, antes, despues time.Duration) (time.Time, time.Time) {
+	inicioDia := time.Date(inicio.Year(), inicio.Month(), inicio.Day(), 0, 0, 0, 0, inicio.Location())
+	finDia := inicioDia.Add(24*time.Hour - time.Second)
+
+	desde, hasta := inicio.Add(-antes), fin.Add(despues)
+	if desde.Before(inicioDia) {
+		desde = inicioDia
+	}
+	if hasta.After(finDia) {
+		hasta = finDia
+	}
+	return desde, hasta
+}
+
+// expandirOcupados agranda cada rango ocupado con los buffers del servicio a
+// reservar: que [inicio-antes, fin+despues] choque con un turno es lo mismo
+// que [inicio, fin] choque con el turno agrandado en sentido inverso. Así
+// calcularSlots sigue comparando sólo el horario que ve el cliente.
+func expandirOcupados(ocupados []db.ListTurnosOcupadosRow, antes, despues time.Duration) []db.ListTurnosOcupadosRow {
+	if antes == 0 && despues == 0 {
+		return ocupados
+	}
+	expandidos := make([]db.ListTurnosOcupadosRow, 0, len(ocupados))
+	for _, t := range ocupados {
+		expandidos = append(expandidos, db.ListTurnosOcupadosRow{
+			BarberoID:  t.BarberoID,
+			HoraInicio: t.HoraInicio.Add(-despues),
+			HoraFin:    t.HoraFin.Add(antes),
+		})
+	}
+	return expandidos
+}
+
+// calcularSlotsEnRangos aplica calcularSlots a cada rango de atención del día
+func calcularSlotsEnRangos(rangos []rango, duracion, paso int32, ocupados []db.ListTurnosOcupadosRow) []Slot {
+	disponibles := []Slot{}
+	for _, r := range rangos {
+		disponibles = append(disponibles, calcularSlots(r.Inicio, r.Fin, duracion, paso, ocupados)...)
+	}
+	return disponibles
+}
+
+// calcularSlotsPorBarbero evalúa cada horario contra la agenda y los rangos de
+// atención de cada barbero por separado: el slot está disponible si al menos
+// uno está libre, y lleva la lista de los barberos libres.
+func calcularSlotsPorBarbero(
+	barberos []int32,
+	rangos map[int32][]rango,
+	duracion int32,
+	paso int32,
+	ocupados []db.ListTurnosOcupadosRow,
+) []Slot {
+	porBarbero := make(map[int32][]db.ListTurnosOcupadosRow)
+	for _, t := range ocupados {
+		porBarbero[t.BarberoID] = append(porBarbero[t.BarberoID], t)
+	}
+
+	disponibles := []Slot{}
+	slotDur := time.Duration(duracion) * time.Minute
+	pasoDur := pasoSlots(duracion, paso)
+	if slotDur <= 0 || pasoDur <= 0 {
+		return disponibles
+	}
+
+	// Cada barbero puede tener rangos distintos, así que se juntan todos los
+	// inicios posibles y se ordenan al final
+	libres := make(map[time.Time][]int32)
+	var inicios []time.Time
+	for _, id := range barberos {
+		for _, r := range rangos[id] {
+			for actual := r.Inicio; !actual.Add(slotDur).After(r.Fin); actual = actual.Add(pasoDur) {
+				if choca(actual, actual.Add(slotDur), porBarbero[id]) {
+					continue
+				}
+				if _, ok := libres[actual]; !ok {
+					inicios = append(inicios, actual)
+				}
+				libres[actual] = append(libres[actual], id)
+			}
+		}
+	}
+
+	sort.Slice(inicios, func(i, j int) bool { return inicios[i].Before(inicios[j]) })
+	for _, inicio := range inicios {
+		disponibles = append(disponibles, Slot{
+			Inicio:   inicio.Format("15:04"),
+			Fin:      inicio.Add(slotDur).Format("15:04"),
+			Barberos: libres[inicio],
+		})
+	}
+
+	return disponibles
+}
+
+// calcularSlotsPorDuracion agrupa a los barberos según cuánto tardan en el
+// servicio y calcula cada grupo con calcularSlotsPorBarbero. Un mismo inicio
+// puede aparecer con fines distintos si los barberos tienen duraciones distintas.
+func calcularSlotsPorDuracion(
+	barberos []int32,
+	duraciones map[int32]int32,
+	rangos map[int32][]rango,
+	paso int32,
+	ocupados []db.ListTurnosOcupadosRow,
+) []Slot {
+	grupos := make(map[int32][]int32)
+	var orden []int32
+	for _, id := range barberos {
+		d := duraciones[id]
+		if _, ok := grupos[d]; !ok {
+			orden = append(orden, d)
+		}
+		grupos[d] = append(grupos[d], id)
+	}
+	if len(orden) == 1 {
+		return calcularSlotsPorBarbero(barberos, rangos, orden[0], paso, ocupados)
+	}
+
+	disponibles := []Slot{}
+	for _, d := range orden {
+		disponibles = append(disponibles, calcularSlotsPorBarbero(grupos[d], rangos, d, paso, ocupados)...)
+	}
+	// "HH:MM" ordena igual como texto que como hora
+	sort.SliceStable(disponibles, func(i, j int) bool {
+		if disponibles[i].Inicio != disponibles[j].Inicio {
+			return disponibles[i].Inicio < disponibles[j].Inicio
+		}
+		return disponibles[i].Fin < disponibles[j].Fin
+	})
+	return disponibles
+}
+
+// slotsReservables descarta los slots que la reserva rechazaría: los que ya
+// empezaron en hora local de la barbería (todos, si la fecha ya pasó), los
+// que caen en el salto del cambio de horario y los que quedan fuera de la
+// ventana de anticipación mínima y máxima.
+func slotsReservables(barberia db.Barberia, fecha, now time.Time, slots []Slot) []Slot {
+	vigentes := []Slot{}
+	for _, s := range slots {
+		hora, err := time.Parse("15:04", s.Inicio)
+		if err != nil {
+			continue
+		}
+		if ventanaReserva(barberia, fecha, hora, now) == nil {
+			vigentes = append(vigentes, s)
+		}
+	}
+	return vigentes
+}
+
+func contiene(ids []int32, id int32) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Códigos de error de la API. Son parte del contrato: el front y las
+// integraciones deciden con ellos qué hacer (el mensaje es para mostrar y
+// puede cambiar), así que no se renombran.
+const (
+	CodigoValidacion            = "VALIDATION_FAILED"
+	CodigoJSONInvalido          = "INVALID_JSON"
+	CodigoCuerpoDemasiadoGrande = "PAYLOAD_TOO_LARGE"
+	CodigoNoAutenticado         = "UNAUTHORIZED"
+	CodigoCredenciales          = "INVALID_CREDENTIALS"
+	CodigoSinPermiso            = "FORBIDDEN"
+	CodigoBarberiaNoEncontrada  = "BARBERIA_NOT_FOUND"
+	CodigoBarberoNoEncontrado   = "BARBERO_NOT_FOUND"
+	CodigoServicioNoEncontrado  = "SERVICIO_NOT_FOUND"
+	CodigoTurnoNoEncontrado     = "TURNO_NOT_FOUND"
+	CodigoBloqueoNoEncontrado   = "BLOQUEO_NOT_FOUND"
+	CodigoClienteNoEncontrado   = "CLIENTE_NOT_FOUND"
+	CodigoUsuarioNoEncontrado   = "USUARIO_NOT_FOUND"
+	CodigoSlotOcupado           = "SLOT_TAKEN"
+	CodigoSlotBloqueado         = "SLOT_BLOCKED"
+	CodigoFueraDeHorario        = "OUTSIDE_BUSINESS_HOURS"
+	CodigoServicioNoHabilitado  = "SERVICE_NOT_OFFERED"
+	CodigoPolitica              = "BOOKING_POLICY" // el detalle va en motivo
+	CodigoFueraDePlazo          = "CHANGE_TOO_LATE"
+	CodigoEstadoTurno           = "INVALID_STATE"
+	CodigoSlugOcupado           = "SLUG_TAKEN"
+	CodigoUsuarioExistente      = "USER_EXISTS"
+	CodigoDuplicado             = "CONFLICT"
+	CodigoEnUso                 = "IN_USE"
+	CodigoReferenciaInvalida    = "INVALID_REFERENCE"
+	CodigoDemasiadosIntentos    = "TOO_MANY_REQUESTS"
+	CodigoInterno               = "INTERNAL_ERROR"
+)
+
+// ErrorAPI es el cuerpo de toda respuesta de error, dentro de {"error": ...}.
+// Campos trae los errores de validación por campo del JSON; Motivo y Limite,
+// el detalle de un rechazo por políticas de la barbería.
+type ErrorAPI struct {
+	Codigo    string            `json:"code"`
+	Mensaje   string            `json:"message"`
+	Campos    map[string]string `json:"fields,omitempty"`
+	Motivo    string            `json:"motivo,omitempty"`
+	Limite    int32             `json:"limite,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// RespuestaError es el sobre de las respuestas de error
+type RespuestaError struct {
+	Error ErrorAPI `json:"error"`
+}
+
+func escribirError(w http.ResponseWriter, r *http.Request, status int, e ErrorAPI) {
+	e.RequestID = requestID(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(RespuestaError{Error: e})
+}
+
+// responderError es el reemplazo de http.Error: mismo mensaje, con código
+func responderError(w http.ResponseWriter, r *http.Request, status int, codigo, mensaje string) {
+	escribirError(w, r, status, ErrorAPI{Codigo: codigo, Mensaje: mensaje})
+}
+
+// errorInterno responde un error inesperado. Los de Postgres que son culpa
+// del request (un duplicado, una referencia que no existe) se traducen a
+// 409/422; el resto se registra con el request id y el cliente sólo ve el
+// mensaje genérico, nunca el texto del error.
+func errorInterno(w http.ResponseWriter, r *http.Request, err error, mensaje string) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			responderError(w, r, http.StatusConflict, CodigoDuplicado, "Ya existe un registro con esos datos")
+			return
+		case "23P01": // exclusion_violation: la única EXCLUDE es la de superposición de turnos
+			responderError(w, r, http.StatusConflict, CodigoSlotOcupado, "El turno seleccionado ya no está disponible")
+			return
+		case "23503": // foreign_key_violation
+			if strings.HasPrefix(pqErr.Message, "update or delete") {
+				responderError(w, r, http.StatusConflict, CodigoEnUso, "El registro está en uso y no se puede borrar")
+			} else {
+				responderError(w, r, http.StatusUnprocessableEntity, CodigoReferenciaInvalida, "Se hace referencia a un registro que no existe")
+			}
+			return
+		case "23514", "22001", "22003": // check_violation, string_data_right_truncation, numeric_value_out_of_range
+			responderError(w, r, http.StatusUnprocessableEntity, CodigoValidacion, "Algún dato está fuera de los valores permitidos")
+			return
+		}
+	}
+
+	log.Printf("[%s] %s %s: %s: %v", requestID(r.Context()), r.Method, r.URL.Path, mensaje, err)
+	responderError(w, r, http.StatusInternalServerError, CodigoInterno, mensaje)
+}
+
+type claveRequestID struct{}
+
+// RequestID identifica cada request para cruzar la respuesta de error con el
+// log. Respeta el X-Request-Id que manda un proxy si es razonable; si no,
+// genera uno. Se devuelve en el header X-Request-Id y en los errores.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if !requestIDValido(id) {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-Id", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claveRequestID{}, id)))
+	})
+}
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(claveRequestID{}).(string)
+	return id
+}
+
+// requestIDValido acepta hasta 64 letras, dígitos, guiones, puntos y guiones bajos
+func requestIDValido(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
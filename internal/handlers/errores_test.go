@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// TestErrorInterno tests la traducción de los errores de Postgres y que el
+// texto del error nunca llegue al cliente
+func TestErrorInterno(t *testing.T) {
+	casos := []struct {
+		nombre string
+		err    error
+		status int
+		codigo string
+	}{
+		{"unique", &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint \"x\""}, http.StatusConflict, CodigoDuplicado},
+		{"exclude", &pq.Error{Code: "23P01", Message: "conflicting key value violates exclusion constraint"}, http.StatusConflict, CodigoSlotOcupado},
+		{"FK al borrar", &pq.Error{Code: "23503", Message: "update or delete on table \"servicios\" violates foreign key constraint"}, http.StatusConflict, CodigoEnUso},
+		{"FK al insertar", &pq.Error{Code: "23503", Message: "insert or update on table \"turnos\" violates foreign key constraint"}, http.StatusUnprocessableEntity, CodigoReferenciaInvalida},
+		{"check", &pq.Error{Code: "23514", Message: "new row violates check constraint"}, http.StatusUnprocessableEntity, CodigoValidacion},
+		{"otro error de Postgres", &pq.Error{Code: "42P01", Message: "relation \"secreta\" does not exist"}, http.StatusInternalServerError, CodigoInterno},
+		{"error cualquiera", errors.New("dial tcp 10.0.0.5:5432: connection refused"), http.StatusInternalServerError, CodigoInterno},
+	}
+	for _, c := range casos {
+		rec := httptest.NewRecorder()
+		errorInterno(rec, httptest.NewRequest(http.MethodGet, "/b/a/servicios", nil), c.err, "Error obteniendo servicios")
+
+		var body RespuestaError
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if rec.Code != c.status || body.Error.Codigo != c.codigo {
+			t.Errorf("%s: se esperaba %d %s, se obtuvo %d %s", c.nombre, c.status, c.codigo, rec.Code, body.Error.Codigo)
+		}
+		for _, secreto := range []string{"secreta", "10.0.0.5", "constraint"} {
+			if strings.Contains(rec.Body.String(), secreto) {
+				t.Errorf("%s: la respuesta expone el error: %s", c.nombre, rec.Body.String())
+			}
+		}
+	}
+}
+
+// TestRequestID tests que el id se genere o se respete y llegue al cuerpo del error
+func TestRequestID(t *testing.T) {
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+	}))
+
+	casos := []struct {
+		nombre  string
+		enviado string
+		respeta bool
+	}{
+		{"sin header", "", false},
+		{"del proxy", "abc-123.def_4", true},
+		{"inválido", "<script>", false},
+		{"demasiado largo", strings.Repeat("a", 65), false},
+	}
+	for _, c := range casos {
+		req := httptest.NewRequest(http.MethodGet, "/b/x", nil)
+		if c.enviado != "" {
+			req.Header.Set("X-Request-Id", c.enviado)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		var body RespuestaError
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		id := rec.Header().Get("X-Request-Id")
+		if id == "" || body.Error.RequestID != id {
+			t.Errorf("%s: header %q y cuerpo %q deberían coincidir", c.nombre, id, body.Error.RequestID)
+		}
+		if (id == c.enviado) != c.respeta {
+			t.Errorf("%s: se obtuvo el id %q", c.nombre, id)
+		}
+		if body.Error.Codigo != CodigoBarberiaNoEncontrada || rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: respuesta inesperada: %s", c.nombre, rec.Body.String())
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"testing"
 	"time"
@@ -36,6 +37,42 @@ func TestCreateReservaRequest_Structure(t *testing.T) {
 	}
 }
 
+// TestValidarCliente tests la normalización de los datos del cliente y los
+// errores por campo
+func TestValidarCliente(t *testing.T) {
+	valido := func() CreateReservaRequest {
+		return CreateReservaRequest{ServicioID: 1, Fecha: diaReservaTest(), HoraInicio: "10:00", ClienteNombre: "Pedro"}
+	}
+
+	req := valido()
+	req.ClienteNombre, req.ClienteTelefono, req.ClienteEmail = "  Pedro ", "011 15 4444-5555", " Pedro@Mail.com"
+	if errs := validar(&req); len(errs) != 0 {
+		t.Fatalf("No se esperaban errores: %v", errs)
+	}
+	if errs := validarTelefonoCliente(&req, "AR"); len(errs) != 0 {
+		t.Fatalf("No se esperaban errores en el teléfono: %v", errs)
+	}
+	if req.ClienteNombre != "Pedro" || req.ClienteTelefono != "+5491144445555" || req.ClienteEmail != "pedro@mail.com" {
+		t.Errorf("Datos mal normalizados: %+v", req)
+	}
+
+	// El teléfono es opcional
+	req = valido()
+	req.ClienteTelefono = "  "
+	if errs := validarTelefonoCliente(&req, "AR"); len(errs) != 0 || req.ClienteTelefono != "" {
+		t.Errorf("Sin teléfono: se obtuvo %v %q", errs, req.ClienteTelefono)
+	}
+
+	req = CreateReservaRequest{ClienteTelefono: "4444-5555", ClienteEmail: "pedro"}
+	errs := validar(&req)
+	if len(errs) != 5 || errs["cliente_nombre"] == "" || errs["cliente_email"] == "" || errs["fecha"] == "" {
+		t.Errorf("Se esperaban errores en servicio, fecha, hora, nombre y email: %v", errs)
+	}
+	if errs := validarTelefonoCliente(&req, "AR"); errs["cliente_telefono"] != "falta el código de área" {
+		t.Errorf("Se esperaba que falte el código de área: %v", errs)
+	}
+}
+
 // TestSlot_Structure tests que la estructura Slot funciona
 func TestSlot_Structure(t *testing.T) {
 	slot := Slot{
@@ -121,7 +158,7 @@ func TestCalcularSlots_Basic(t *testing.T) {
 	cierre := time.Date(2025, 1, 8, 11, 0, 0, 0, time.UTC)
 	duracion := int32(30) // 30 minutos
 
-	slots := calcularSlots(apertura, cierre, duracion, []db.ListTurnosOcupadosRow{})
+	slots := calcularSlots(apertura, cierre, duracion, duracion, []db.ListTurnosOcupadosRow{})
 
 	// Debería haber 4 slots: 9:00-9:30, 9:30-10:00, 10:00-10:30, 10:30-11:00
 	if len(slots) != 4 {
@@ -151,7 +188,7 @@ func TestCalcularSlots_WithOccupied(t *testing.T) {
 		},
 	}
 
-	slots := calcularSlots(apertura, cierre, duracion, ocupados)
+	slots := calcularSlots(apertura, cierre, duracion, duracion, ocupados)
 
 	// Debería haber 3 slots (el 9:30-10:00 está ocupado)
 	if len(slots) != 3 {
@@ -209,7 +246,7 @@ func TestCalcularSlots_LargeDuration(t *testing.T) {
 	cierre := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
 	duracion := int32(60) // 1 hora
 
-	slots := calcularSlots(apertura, cierre, duracion, []db.ListTurnosOcupadosRow{})
+	slots := calcularSlots(apertura, cierre, duracion, duracion, []db.ListTurnosOcupadosRow{})
 
 	// Debería haber 3 slots: 9:00-10:00, 10:00-11:00, 11:00-12:00
 	if len(slots) != 3 {
@@ -275,3 +312,527 @@ func TestWriteJSON_Output(t *testing.T) {
 		t.Errorf("Se esperaba 2 slots, pero se obtuvieron %d", len(decoded))
 	}
 }
+
+// TestCalcularSlotsPorBarbero tests que un turno de un barbero no bloquee a los demás
+func TestCalcularSlotsPorBarbero(t *testing.T) {
+	apertura := time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC)
+	cierre := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC)
+	duracion := int32(30)
+
+	// El barbero 1 está ocupado 9:00-9:30, el 2 está ocupado toda la hora
+	ocupados := []db.ListTurnosOcupadosRow{
+		{
+			BarberoID:  1,
+			HoraInicio: time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC),
+			HoraFin:    time.Date(2025, 1, 8, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			BarberoID:  2,
+			HoraInicio: time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC),
+			HoraFin:    time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	todoElDia := []rango{{Inicio: apertura, Fin: cierre}}
+	rangos := map[int32][]rango{1: todoElDia, 2: todoElDia, 3: todoElDia}
+
+	slots := calcularSlotsPorBarbero([]int32{1, 2, 3}, rangos, duracion, duracion, ocupados)
+
+	// Con el barbero 3 libre, ambos slots siguen disponibles
+	if len(slots) != 2 {
+		t.Fatalf("Se esperaba 2 slots, pero se obtuvieron %d", len(slots))
+	}
+	if len(slots[0].Barberos) != 1 || slots[0].Barberos[0] != 3 {
+		t.Errorf("En 09:00 sólo debería estar libre el barbero 3: %v", slots[0].Barberos)
+	}
+	if len(slots[1].Barberos) != 2 || slots[1].Barberos[0] != 1 || slots[1].Barberos[1] != 3 {
+		t.Errorf("En 09:30 deberían estar libres los barberos 1 y 3: %v", slots[1].Barberos)
+	}
+
+	// Sin el barbero 3, el slot de las 09:00 desaparece
+	slots = calcularSlotsPorBarbero([]int32{1, 2}, rangos, duracion, duracion, ocupados)
+	if len(slots) != 1 || slots[0].Inicio != "09:30" {
+		t.Errorf("Se esperaba sólo el slot de las 09:30, pero se obtuvo %v", slots)
+	}
+}
+
+// hora arma un valor como los de las columnas TIME (fecha 0000-01-01)
+func hora(h, m int) time.Time {
+	return time.Date(0, 1, 1, h, m, 0, 0, time.UTC)
+}
+
+// TestRangosDelDia tests la resolución de horarios: propio > barbería > apertura/cierre
+func TestRangosDelDia(t *testing.T) {
+	barberia := db.Barberia{HoraApertura: hora(9, 0), HoraCierre: hora(18, 0)}
+
+	// Sin horario semanal se usa apertura/cierre, incluso el domingo
+	rangos := rangosDelDia(barberia, nil, 1, time.Sunday)
+	if len(rangos) != 1 || !rangos[0].Inicio.Equal(hora(9, 0)) || !rangos[0].Fin.Equal(hora(18, 0)) {
+		t.Errorf("Se esperaba 09:00-18:00 por defecto, pero se obtuvo %v", rangos)
+	}
+
+	horarios := []db.Horario{
+		// Barbería: lunes partido al mediodía, sábado a la mañana
+		{DiaSemana: 1, HoraInicio: hora(9, 0), HoraFin: hora(13, 0)},
+		{DiaSemana: 1, HoraInicio: hora(14, 0), HoraFin: hora(18, 0)},
+		{DiaSemana: 6, HoraInicio: hora(9, 0), HoraFin: hora(13, 0)},
+		// El barbero 2 sólo trabaja los lunes a la tarde
+		{BarberoID: sql.NullInt32{Int32: 2, Valid: true}, DiaSemana: 1, HoraInicio: hora(15, 0), HoraFin: hora(20, 0)},
+	}
+
+	if rangos := rangosDelDia(barberia, horarios, 1, time.Monday); len(rangos) != 2 {
+		t.Errorf("El barbero 1 debería tener 2 rangos el lunes, pero tiene %d", len(rangos))
+	}
+	if rangos := rangosDelDia(barberia, horarios, 1, time.Sunday); len(rangos) != 0 {
+		t.Errorf("El domingo la barbería está cerrada, pero se obtuvo %v", rangos)
+	}
+	if rangos := rangosDelDia(barberia, horarios, 2, time.Monday); len(rangos) != 1 || !rangos[0].Fin.Equal(hora(20, 0)) {
+		t.Errorf("El barbero 2 debería usar su horario propio, pero se obtuvo %v", rangos)
+	}
+	if rangos := rangosDelDia(barberia, horarios, 2, time.Saturday); len(rangos) != 0 {
+		t.Errorf("El horario propio reemplaza al general: el barbero 2 no trabaja el sábado, pero se obtuvo %v", rangos)
+	}
+}
+
+// TestDentroDeHorario tests que un turno deba caer completo dentro de un rango
+func TestDentroDeHorario(t *testing.T) {
+	rangos := []rango{
+		{Inicio: hora(9, 0), Fin: hora(13, 0)},
+		{Inicio: hora(14, 0), Fin: hora(18, 0)},
+	}
+
+	if !dentroDeHorario(rangos, hora(12, 30), hora(13, 0)) {
+		t.Error("12:30-13:00 debería estar dentro del horario")
+	}
+	if dentroDeHorario(rangos, hora(12, 45), hora(13, 15)) {
+		t.Error("12:45-13:15 cruza el corte del mediodía")
+	}
+	if dentroDeHorario(rangos, hora(8, 30), hora(9, 0)) {
+		t.Error("08:30-09:00 es antes de la apertura")
+	}
+}
+
+// TestCalcularSlotsPorBarbero_HorarioPartido tests slots con rangos distintos por barbero
+func TestCalcularSlotsPorBarbero_HorarioPartido(t *testing.T) {
+	rangos := map[int32][]rango{
+		1: {{Inicio: hora(9, 0), Fin: hora(10, 0)}, {Inicio: hora(11, 0), Fin: hora(12, 0)}},
+		2: {{Inicio: hora(9, 30), Fin: hora(10, 30)}},
+	}
+
+	slots := calcularSlotsPorBarbero([]int32{1, 2}, rangos, 30, 30, nil)
+
+	esperados := []string{"09:00", "09:30", "10:00", "11:00", "11:30"}
+	if len(slots) != len(esperados) {
+		t.Fatalf("Se esperaban %d slots, pero se obtuvieron %d: %v", len(esperados), len(slots), slots)
+	}
+	for i, inicio := range esperados {
+		if slots[i].Inicio != inicio {
+			t.Errorf("Slot %d: se esperaba %s, pero se obtuvo %s", i, inicio, slots[i].Inicio)
+		}
+	}
+	if len(slots[1].Barberos) != 2 {
+		t.Errorf("A las 09:30 atienden ambos barberos: %v", slots[1].Barberos)
+	}
+}
+
+// TestParseHorarios tests la validación de los horarios recibidos
+func TestParseHorarios(t *testing.T) {
+	params, err := parseHorarios([]HorarioItem{
+		{DiaSemana: 1, HoraInicio: "14:00", HoraFin: "18:00"},
+		{DiaSemana: 1, HoraInicio: "09:00", HoraFin: "13:00"},
+	})
+	if err != nil {
+		t.Fatalf("Horario válido rechazado: %v", err)
+	}
+	if params[0].HoraInicio.Hour() != 9 {
+		t.Error("Los rangos deberían quedar ordenados por día y hora")
+	}
+
+	invalidos := [][]HorarioItem{
+		{{DiaSemana: 7, HoraInicio: "09:00", HoraFin: "13:00"}},
+		{{DiaSemana: 1, HoraInicio: "9", HoraFin: "13:00"}},
+		{{DiaSemana: 1, HoraInicio: "13:00", HoraFin: "09:00"}},
+		{
+			{DiaSemana: 2, HoraInicio: "09:00", HoraFin: "13:00"},
+			{DiaSemana: 2, HoraInicio: "12:00", HoraFin: "15:00"},
+		},
+	}
+	for i, items := range invalidos {
+		if _, err := parseHorarios(items); err == nil {
+			t.Errorf("Caso %d: se esperaba error para %v", i, items)
+		}
+	}
+}
+
+// fechaTest arma una fecha como las columnas DATE
+func fechaTest(anio int, mes time.Month, dia int) time.Time {
+	return time.Date(anio, mes, dia, 0, 0, 0, 0, time.UTC)
+}
+
+// TestBloqueoAplica tests bloqueos por período y recurrentes anuales
+func TestBloqueoAplica(t *testing.T) {
+	casos := []struct {
+		nombre  string
+		bloqueo db.Bloqueo
+		fecha   time.Time
+		aplica  bool
+	}{
+		{
+			nombre:  "vacaciones dentro del período",
+			bloqueo: db.Bloqueo{FechaDesde: fechaTest(2026, 2, 1), FechaHasta: fechaTest(2026, 2, 14)},
+			fecha:   fechaTest(2026, 2, 14),
+			aplica:  true,
+		},
+		{
+			nombre:  "vacaciones fuera del período",
+			bloqueo: db.Bloqueo{FechaDesde: fechaTest(2026, 2, 1), FechaHasta: fechaTest(2026, 2, 14)},
+			fecha:   fechaTest(2026, 2, 15),
+			aplica:  false,
+		},
+		{
+			nombre:  "feriado recurrente otro año",
+			bloqueo: db.Bloqueo{FechaDesde: fechaTest(2025, 7, 9), FechaHasta: fechaTest(2025, 7, 9), RecurrenteAnual: true},
+			fecha:   fechaTest(2027, 7, 9),
+			aplica:  true,
+		},
+		{
+			nombre:  "feriado recurrente otro día",
+			bloqueo: db.Bloqueo{FechaDesde: fechaTest(2025, 7, 9), FechaHasta: fechaTest(2025, 7, 9), RecurrenteAnual: true},
+			fecha:   fechaTest(2027, 7, 10),
+			aplica:  false,
+		},
+		{
+			nombre:  "recurrente que cruza fin de año",
+			bloqueo: db.Bloqueo{FechaDesde: fechaTest(2025, 12, 24), FechaHasta: fechaTest(2026, 1, 2), RecurrenteAnual: true},
+			fecha:   fechaTest(2028, 1, 1),
+			aplica:  true,
+		},
+		{
+			nombre:  "recurrente antes de su primer año",
+			bloqueo: db.Bloqueo{FechaDesde: fechaTest(2025, 7, 9), FechaHasta: fechaTest(2025, 7, 9), RecurrenteAnual: true},
+			fecha:   fechaTest(2024, 7, 9),
+			aplica:  false,
+		},
+	}
+
+	for _, c := range casos {
+		if got := bloqueoAplica(c.bloqueo, c.fecha); got != c.aplica {
+			t.Errorf("%s: se esperaba %v, pero se obtuvo %v", c.nombre, c.aplica, got)
+		}
+	}
+}
+
+// TestBloqueosComoOcupados tests el alcance por barbería y por barbero
+func TestBloqueosComoOcupados(t *testing.T) {
+	fecha := fechaTest(2026, 3, 10)
+	bloqueos := []db.Bloqueo{
+		// Médico del barbero 2 de 10 a 12
+		{
+			BarberoID:  sql.NullInt32{Int32: 2, Valid: true},
+			FechaDesde: fecha,
+			FechaHasta: fecha,
+			HoraInicio: sql.NullTime{Time: hora(10, 0), Valid: true},
+			HoraFin:    sql.NullTime{Time: hora(12, 0), Valid: true},
+		},
+	}
+
+	ocupados := bloqueosComoOcupados(bloqueos, fecha, []int32{1, 2})
+	if len(ocupados) != 1 || ocupados[0].BarberoID != 2 {
+		t.Fatalf("Sólo el barbero 2 debería quedar bloqueado: %v", ocupados)
+	}
+
+	// Cierre de la barbería todo el día: afecta a todos
+	bloqueos = append(bloqueos, db.Bloqueo{FechaDesde: fecha, FechaHasta: fecha, Motivo: "Feriado"})
+	ocupados = bloqueosComoOcupados(bloqueos, fecha, []int32{1, 2})
+	if len(ocupados) != 3 {
+		t.Fatalf("Se esperaban 3 rangos ocupados, pero se obtuvieron %d", len(ocupados))
+	}
+
+	slots := calcularSlots(hora(9, 0), hora(18, 0), 30, 30, ocupados)
+	if len(slots) != 0 {
+		t.Errorf("Con la barbería cerrada no debería haber slots: %v", slots)
+	}
+
+	b, bloqueado := bloqueoQueChoca(bloqueos, 1, fecha, hora(9, 0), hora(9, 30))
+	if !bloqueado || b.Motivo != "Feriado" {
+		t.Errorf("Se esperaba el bloqueo 'Feriado', pero se obtuvo %v (%v)", b, bloqueado)
+	}
+}
+
+// TestValidarBloqueo tests la validación de los bloqueos recibidos
+func TestValidarBloqueo(t *testing.T) {
+	req := BloqueoRequest{FechaDesde: "2026-05-25", Motivo: "Feriado"}
+	if errs := validar(&req); len(errs) > 0 {
+		t.Fatalf("Bloqueo válido rechazado: %v", errs)
+	}
+	if p := bloqueoParams(req); !p.FechaHasta.Equal(p.FechaDesde) || p.HoraInicio.Valid || p.BarberoID.Valid {
+		t.Errorf("Sin fecha_hasta ni horas debería ser un día completo de toda la barbería: %+v", p)
+	}
+
+	invalidos := []BloqueoRequest{
+		{FechaDesde: "2026-05-25"},
+		{FechaDesde: "25/05/2026", Motivo: "x"},
+		{FechaDesde: "2026-05-25", FechaHasta: "2026-05-20", Motivo: "x"},
+		{FechaDesde: "2026-05-25", HoraInicio: "10:00", Motivo: "x"},
+		{FechaDesde: "2026-05-25", HoraInicio: "12:00", HoraFin: "10:00", Motivo: "x"},
+		{FechaDesde: "2026-01-01", FechaHasta: "2027-01-01", RecurrenteAnual: true, Motivo: "x"},
+	}
+	for i, req := range invalidos {
+		if errs := validar(&req); len(errs) == 0 {
+			t.Errorf("Caso %d: se esperaba error para %+v", i, req)
+		}
+	}
+}
+
+// TestCalcularSlots_Grilla tests la grilla de inicios independiente de la duración
+func TestCalcularSlots_Grilla(t *testing.T) {
+	casos := []struct {
+		nombre    string
+		apertura  time.Time
+		cierre    time.Time
+		duracion  int32
+		paso      int32
+		ocupados  []db.ListTurnosOcupadosRow
+		esperados []string
+	}{
+		{
+			nombre:    "servicio de 45 min cada 15",
+			apertura:  hora(9, 0),
+			cierre:    hora(11, 0),
+			duracion:  45,
+			paso:      15,
+			esperados: []string{"09:00", "09:15", "09:30", "09:45", "10:00", "10:15"},
+		},
+		{
+			nombre:   "hueco después de un turno de 20 min",
+			apertura: hora(9, 0),
+			cierre:   hora(11, 0),
+			duracion: 45,
+			paso:     15,
+			ocupados: []db.ListTurnosOcupadosRow{
+				{BarberoID: 1, HoraInicio: hora(9, 0), HoraFin: hora(9, 20)},
+			},
+			esperados: []string{"09:30", "09:45", "10:00", "10:15"},
+		},
+		{
+			nombre:    "sin paso se avanza de a una duración",
+			apertura:  hora(9, 0),
+			cierre:    hora(11, 0),
+			duracion:  45,
+			paso:      0,
+			esperados: []string{"09:00", "09:45"},
+		},
+		{
+			nombre:   "grilla que no coincide con el turno ocupado",
+			apertura: hora(9, 0),
+			cierre:   hora(11, 0),
+			duracion: 30,
+			paso:     20,
+			ocupados: []db.ListTurnosOcupadosRow{
+				{BarberoID: 1, HoraInicio: hora(10, 0), HoraFin: hora(10, 30)},
+			},
+			esperados: []string{"09:00", "09:20"},
+		},
+		{
+			nombre:    "servicio más largo que el horario",
+			apertura:  hora(9, 0),
+			cierre:    hora(9, 30),
+			duracion:  45,
+			paso:      15,
+			esperados: nil,
+		},
+		{
+			nombre:    "duración inválida",
+			apertura:  hora(9, 0),
+			cierre:    hora(11, 0),
+			duracion:  0,
+			paso:      15,
+			esperados: nil,
+		},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			slots := calcularSlots(c.apertura, c.cierre, c.duracion, c.paso, c.ocupados)
+
+			if len(slots) != len(c.esperados) {
+				t.Fatalf("Se esperaban %d slots, pero se obtuvieron %d: %v", len(c.esperados), len(slots), slots)
+			}
+			for i, inicio := range c.esperados {
+				if slots[i].Inicio != inicio {
+					t.Errorf("Slot %d: se esperaba %s, pero se obtuvo %s", i, inicio, slots[i].Inicio)
+				}
+				h, _ := time.Parse("15:04", inicio)
+				if fin := h.Add(time.Duration(c.duracion) * time.Minute); slots[i].Fin != fin.Format("15:04") {
+					t.Errorf("Slot %d: el fin debería cubrir la duración completa, se obtuvo %s", i, slots[i].Fin)
+				}
+			}
+		})
+	}
+}
+
+// TestValidarConfiguracion tests los límites del intervalo de slots
+func TestValidarConfiguracion(t *testing.T) {
+	valido := int32(15)
+	if errs := validar(&ConfiguracionRequest{IntervaloMinutos: &valido}); len(errs) > 0 {
+		t.Errorf("Intervalo de 15 rechazado: %v", errs)
+	}
+	if errs := validar(&ConfiguracionRequest{}); len(errs) > 0 {
+		t.Errorf("Un request vacío no cambia nada y debería ser válido: %v", errs)
+	}
+	for _, v := range []int32{0, 3, 7, 300} {
+		v := v
+		if errs := validar(&ConfiguracionRequest{IntervaloMinutos: &v}); errs["intervalo_minutos"] == "" {
+			t.Errorf("Se esperaba error para intervalo %d", v)
+		}
+	}
+}
+
+// TestBuffersServicio tests que el buffer del servicio tenga prioridad sobre el de la barbería
+func TestBuffersServicio(t *testing.T) {
+	barberia := db.Barberia{BufferAntesMinutos: 5, BufferDespuesMinutos: 10}
+
+	antes, despues := buffersServicio(barberia, db.Servicio{})
+	if antes != 5*time.Minute || despues != 10*time.Minute {
+		t.Errorf("Sin buffer propio se esperaba 5/10 min, pero se obtuvo %v/%v", antes, despues)
+	}
+
+	servicio := db.Servicio{
+		BufferAntesMinutos:   sql.NullInt32{Int32: 0, Valid: true},
+		BufferDespuesMinutos: sql.NullInt32{Int32: 15, Valid: true},
+	}
+	antes, despues = buffersServicio(barberia, servicio)
+	if antes != 0 || despues != 15*time.Minute {
+		t.Errorf("Con buffer propio se esperaba 0/15 min, pero se obtuvo %v/%v", antes, despues)
+	}
+}
+
+// TestRangoOcupado tests que el rango ocupado se recorte al día
+func TestRangoOcupado(t *testing.T) {
+	desde, hasta := rangoOcupado(hora(10, 0), hora(10, 30), 5*time.Minute, 10*time.Minute)
+	if !desde.Equal(hora(9, 55)) || !hasta.Equal(hora(10, 40)) {
+		t.Errorf("Se esperaba 09:55-10:40, pero se obtuvo %s-%s", desde.Format("15:04"), hasta.Format("15:04"))
+	}
+
+	desde, hasta = rangoOcupado(hora(0, 5), hora(23, 55), 10*time.Minute, 10*time.Minute)
+	if !desde.Equal(hora(0, 0)) || hasta.Day() != hora(0, 0).Day() {
+		t.Errorf("El rango debería quedar dentro del día, se obtuvo %v-%v", desde, hasta)
+	}
+}
+
+// TestCalcularSlots_ConBuffers tests que los buffers cuenten como tiempo ocupado
+func TestCalcularSlots_ConBuffers(t *testing.T) {
+	// Turno existente 10:00-10:30 con 10 min de limpieza: ocupa 10:00-10:40
+	ocupados := []db.ListTurnosOcupadosRow{
+		{BarberoID: 1, HoraInicio: hora(10, 0), HoraFin: hora(10, 40)},
+	}
+
+	// El nuevo servicio necesita 5 min antes y 10 después
+	ocupados = expandirOcupados(ocupados, 5*time.Minute, 10*time.Minute)
+	slots := calcularSlots(hora(9, 0), hora(12, 0), 30, 15, ocupados)
+
+	esperados := []string{"09:00", "09:15", "10:45", "11:00", "11:15", "11:30"}
+	if len(slots) != len(esperados) {
+		t.Fatalf("Se esperaban %d slots, pero se obtuvieron %d: %v", len(esperados), len(slots), slots)
+	}
+	for i, inicio := range esperados {
+		if slots[i].Inicio != inicio {
+			t.Errorf("Slot %d: se esperaba %s, pero se obtuvo %s", i, inicio, slots[i].Inicio)
+		}
+	}
+
+	// El cliente sigue viendo el fin real del servicio
+	if slots[0].Fin != "09:30" {
+		t.Errorf("El fin visible no debería incluir buffers: %s", slots[0].Fin)
+	}
+}
+
+// TestTransicionValida tests la máquina de estados de los turnos
+func TestTransicionValida(t *testing.T) {
+	validas := [][2]string{
+		{EstadoPendiente, EstadoConfirmado},
+		{EstadoPendiente, EstadoCancelado},
+		{EstadoConfirmado, EstadoCompletado},
+		{EstadoConfirmado, EstadoNoAsistio},
+		{EstadoConfirmado, EstadoCancelado},
+	}
+	for _, tr := range validas {
+		if !transicionValida(tr[0], tr[1]) {
+			t.Errorf("%s -> %s debería ser válida", tr[0], tr[1])
+		}
+	}
+
+	invalidas := [][2]string{
+		{EstadoPendiente, EstadoCompletado},
+		{EstadoPendiente, EstadoNoAsistio},
+		{EstadoConfirmado, EstadoPendiente},
+		{EstadoCancelado, EstadoConfirmado},
+		{EstadoCompletado, EstadoCancelado},
+		{EstadoNoAsistio, EstadoCompletado},
+		{EstadoPendiente, EstadoPendiente},
+	}
+	for _, tr := range invalidas {
+		if transicionValida(tr[0], tr[1]) {
+			t.Errorf("%s -> %s debería ser inválida", tr[0], tr[1])
+		}
+	}
+}
+
+// TestEstadoTurno tests que un turno sin estado se considere pendiente
+func TestEstadoTurno(t *testing.T) {
+	if got := estadoTurno(db.Turno{}); got != EstadoPendiente {
+		t.Errorf("Se esperaba pendiente, pero se obtuvo %s", got)
+	}
+	if got := estadoTurno(db.Turno{Estado: toNullString(EstadoConfirmado)}); got != EstadoConfirmado {
+		t.Errorf("Se esperaba confirmado, pero se obtuvo %s", got)
+	}
+}
+
+// TestNuevoToken tests que los tokens sean únicos y que sólo se guarde el hash
+func TestNuevoToken(t *testing.T) {
+	token1, hash1, err := nuevoToken()
+	if err != nil {
+		t.Fatalf("Error generando token: %v", err)
+	}
+	token2, _, _ := nuevoToken()
+
+	if len(token1) != 43 {
+		t.Errorf("Se esperaban 43 caracteres (32 bytes), pero se obtuvieron %d", len(token1))
+	}
+	if token1 == token2 {
+		t.Error("Dos tokens no deberían repetirse")
+	}
+	if hash1 == token1 || len(hash1) != 64 {
+		t.Errorf("El hash debería ser SHA-256 en hex, se obtuvo %q", hash1)
+	}
+	if hashToken(token1) != hash1 {
+		t.Error("hashToken debería ser determinístico")
+	}
+}
+
+// TestAvisoCumplido tests la anticipación mínima para cambios del cliente
+func TestAvisoCumplido(t *testing.T) {
+	barberia := db.Barberia{AvisoCambioMinutos: 120, ZonaHoraria: zonaPorDefecto}
+	inicio := momentoTurno(barberia, fechaTest(2030, 1, 10), hora(10, 0))
+
+	casos := []struct {
+		nombre string
+		now    time.Time
+		want   bool
+	}{
+		{"con tiempo de sobra", inicio.Add(-24 * time.Hour), true},
+		{"justo en el límite", inicio.Add(-2 * time.Hour), true},
+		{"un minuto tarde", inicio.Add(-119 * time.Minute), false},
+		{"turno ya pasado", inicio.Add(time.Hour), false},
+	}
+	for _, c := range casos {
+		if got := avisoCumplido(barberia, inicio, c.now); got != c.want {
+			t.Errorf("%s: se esperaba %v, pero se obtuvo %v", c.nombre, c.want, got)
+		}
+	}
+
+	// Sin aviso configurado se puede cambiar hasta el inicio
+	if !avisoCumplido(db.Barberia{}, inicio, inicio) {
+		t.Error("Con aviso 0 se debería poder cambiar hasta la hora del turno")
+	}
+}
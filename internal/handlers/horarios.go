@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// rango es un intervalo de atención dentro de un día. Las horas viven sobre
+// la fecha 0000-01-01, igual que las columnas TIME que devuelve la DB.
+type rango struct {
+	Inicio time.Time
+	Fin    time.Time
+}
+
+// rangosDelDia resuelve en qué rangos atiende un barbero un día de la semana:
+// su horario propio si tiene uno cargado, si no el horario semanal de la
+// barbería, y si tampoco existe se usa hora_apertura/hora_cierre todos los días.
+func rangosDelDia(barberia db.Barberia, horarios []db.Horario, barberoID int32, dia time.Weekday) []rango {
+	var propios, generales []db.Horario
+	for _, h := range horarios {
+		switch {
+		case !h.BarberoID.Valid:
+			generales = append(generales, h)
+		case h.BarberoID.Int32 == barberoID:
+			propios = append(propios, h)
+		}
+	}
+
+	semana := generales
+	if len(propios) > 0 {
+		semana = propios
+	}
+	if len(semana) == 0 {
+		return []rango{{Inicio: barberia.HoraApertura, Fin: barberia.HoraCierre}}
+	}
+
+	var rangos []rango
+	for _, h := range semana {
+		if h.DiaSemana == int32(dia) {
+			rangos = append(rangos, rango{Inicio: h.HoraInicio, Fin: h.HoraFin})
+		}
+	}
+	return rangos
+}
+
+// dentroDeHorario indica si [inicio, fin] cae completo dentro de algún rango
+func dentroDeHorario(rangos []rango, inicio, fin time.Time) bool {
+	for _, r := range rangos {
+		if !inicio.Before(r.Inicio) && !fin.After(r.Fin) {
+			return true
+		}
+	}
+	return false
+}
+
+type HorariosHandler struct {
+	Queries *db.Queries
+	DB      *sql.DB
+}
+
+func NewHorariosHandler(q *db.Queries, conn *sql.DB) *HorariosHandler {
+	return &HorariosHandler{Queries: q, DB: conn}
+}
+
+// HorarioItem es un rango de atención tal como viaja en el JSON
+type HorarioItem struct {
+	DiaSemana  int32  `json:"dia_semana" validar:"min=0,max=6"`     // 0 = domingo ... 6 = sábado
+	HoraInicio string `json:"hora_inicio" validar:"requerido,hora"` // HH:MM
+	HoraFin    string `json:"hora_fin" validar:"requerido,hora"`    // HH:MM
+}
+
+type UpdateHorariosRequest struct {
+	Horarios []HorarioItem `json:"horarios"`
+}
+
+// validarCampos revisa los rangos entre sí (cada uno ya tiene formato válido)
+func (req *UpdateHorariosRequest) validarCampos() erroresCampos {
+	if _, err := parseHorarios(req.Horarios); err != nil {
+		return erroresCampos{"horarios": err.Error()}
+	}
+	return nil
+}
+
+// parseHorarios valida los rangos recibidos: día válido, inicio < fin y sin
+// superposiciones dentro del mismo día.
+func parseHorarios(items []HorarioItem) ([]db.CreateHorarioParams, error) {
+	params := make([]db.CreateHorarioParams, 0, len(items))
+	for _, it := range items {
+		if it.DiaSemana < 0 || it.DiaSemana > 6 {
+			return nil, fmt.Errorf("dia_semana inválido: %d", it.DiaSemana)
+		}
+		inicio, err := time.Parse("15:04", it.HoraInicio)
+		if err != nil {
+			return nil, fmt.Errorf("hora_inicio inválida: %q", it.HoraInicio)
+		}
+		fin, err := time.Parse("15:04", it.HoraFin)
+		if err != nil {
+			return nil, fmt.Errorf("hora_fin inválida: %q", it.HoraFin)
+		}
+		if !inicio.Before(fin) {
+			return nil, fmt.Errorf("el rango %s-%s termina antes de empezar", it.HoraInicio, it.HoraFin)
+		}
+		params = append(params, db.CreateHorarioParams{
+			DiaSemana:  it.DiaSemana,
+			HoraInicio: inicio,
+			HoraFin:    fin,
+		})
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].DiaSemana != params[j].DiaSemana {
+			return params[i].DiaSemana < params[j].DiaSemana
+		}
+		return params[i].HoraInicio.Before(params[j].HoraInicio)
+	})
+	for i := 1; i < len(params); i++ {
+		prev, cur := params[i-1], params[i]
+		if prev.DiaSemana == cur.DiaSemana && cur.HoraInicio.Before(prev.HoraFin) {
+			return nil, fmt.Errorf("rangos superpuestos el día %d", cur.DiaSemana)
+		}
+	}
+
+	return params, nil
+}
+
+func toHorarioItems(horarios []db.Horario, barberoID sql.NullInt32) []HorarioItem {
+	items := []HorarioItem{}
+	for _, h := range horarios {
+		if h.BarberoID != barberoID {
+			continue
+		}
+		items = append(items, HorarioItem{
+			DiaSemana:  h.DiaSemana,
+			HoraInicio: h.HoraInicio.Format("15:04"),
+			HoraFin:    h.HoraFin.Format("15:04"),
+		})
+	}
+	return items
+}
+
+// GetHorariosBarberia devuelve el horario semanal general de la barbería
+func (h *HorariosHandler) GetHorariosBarberia(w http.ResponseWriter, r *http.Request) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	horarios, err := h.Queries.ListHorarios(r.Context(), barberia.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo horarios")
+		return
+	}
+
+	writeJSON(w, toHorarioItems(horarios, sql.NullInt32{}))
+}
+
+// PutHorariosBarberia reemplaza el horario semanal general de la barbería
+func (h *HorariosHandler) PutHorariosBarberia(w http.ResponseWriter, r *http.Request) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	h.reemplazarHorarios(w, r, barberia.ID, sql.NullInt32{})
+}
+
+// GetHorariosBarbero devuelve el horario propio de un barbero (vacío si usa el de la barbería)
+func (h *HorariosHandler) GetHorariosBarbero(w http.ResponseWriter, r *http.Request) {
+	barberia, barberoID, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	horarios, err := h.Queries.ListHorarios(r.Context(), barberia.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo horarios")
+		return
+	}
+
+	writeJSON(w, toHorarioItems(horarios, barberoID))
+}
+
+// PutHorariosBarbero reemplaza el horario propio de un barbero
+func (h *HorariosHandler) PutHorariosBarbero(w http.ResponseWriter, r *http.Request) {
+	barberia, barberoID, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	h.reemplazarHorarios(w, r, barberia.ID, barberoID)
+}
+
+// DeleteHorariosBarbero borra el horario propio: el barbero vuelve a usar el de la barbería
+func (h *HorariosHandler) DeleteHorariosBarbero(w http.ResponseWriter, r *http.Request) {
+	barberia, barberoID, ok := h.barberoDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.Queries.DeleteHorariosBarbero(r.Context(), db.DeleteHorariosBarberoParams{
+		BarberiaID: barberia.ID,
+		BarberoID:  barberoID,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error borrando horarios")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// barberoDeURL resuelve {slug} y {id} verificando que el barbero sea de esa barbería
+func (h *HorariosHandler) barberoDeURL(w http.ResponseWriter, r *http.Request) (db.Barberia, sql.NullInt32, bool) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return db.Barberia{}, sql.NullInt32{}, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de barbero inválido")
+		return db.Barberia{}, sql.NullInt32{}, false
+	}
+
+	barberos, err := h.Queries.ListBarberosByBarberia(r.Context(), barberia.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo barberos")
+		return db.Barberia{}, sql.NullInt32{}, false
+	}
+	for _, b := range barberos {
+		if b.ID == int32(id) {
+			return barberia, sql.NullInt32{Int32: b.ID, Valid: true}, true
+		}
+	}
+
+	responderError(w, r, http.StatusNotFound, CodigoBarberoNoEncontrado, "Barbero no encontrado")
+	return db.Barberia{}, sql.NullInt32{}, false
+}
+
+// reemplazarHorarios borra y vuelve a crear los rangos en una sola transacción
+func (h *HorariosHandler) reemplazarHorarios(w http.ResponseWriter, r *http.Request, barberiaID int32, barberoID sql.NullInt32) {
+	ctx := r.Context()
+
+	var req UpdateHorariosRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+	params, _ := parseHorarios(req.Horarios)
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		errorInterno(w, r, err, "Error guardando horarios")
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	if barberoID.Valid {
+		err = qtx.DeleteHorariosBarbero(ctx, db.DeleteHorariosBarberoParams{
+			BarberiaID: barberiaID,
+			BarberoID:  barberoID,
+		})
+	} else {
+		err = qtx.DeleteHorariosBarberia(ctx, barberiaID)
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error guardando horarios")
+		return
+	}
+
+	var creados []db.Horario
+	for _, p := range params {
+		p.BarberiaID = barberiaID
+		p.BarberoID = barberoID
+		horario, err := qtx.CreateHorario(ctx, p)
+		if err != nil {
+			errorInterno(w, r, err, "Error guardando horarios")
+			return
+		}
+		creados = append(creados, horario)
+	}
+
+	if err := tx.Commit(); err != nil {
+		errorInterno(w, r, err, "Error guardando horarios")
+		return
+	}
+
+	writeJSON(w, toHorarioItems(creados, barberoID))
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// politicaLogin define cuánto se tolera antes de frenar los intentos
+type politicaLogin struct {
+	Libres   int32         // fallos seguidos sin demora
+	Bloqueo  int32         // desde este fallo, bloqueo temporal
+	Duracion time.Duration // duración del bloqueo
+}
+
+var (
+	// Por usuario se frena rápido: nadie se equivoca diez veces seguidas
+	politicaUsuario = politicaLogin{Libres: 3, Bloqueo: 10, Duracion: 15 * time.Minute}
+	// Por IP se tolera más, porque varios empleados pueden salir por la misma
+	politicaIP = politicaLogin{Libres: 10, Bloqueo: 50, Duracion: 15 * time.Minute}
+
+	// Cada pedido de recuperación manda un mail: por email se frena enseguida
+	// para que nadie pueda llenarle la casilla a otro
+	politicaOlvidoEmail = politicaLogin{Libres: 2, Bloqueo: 5, Duracion: time.Hour}
+	politicaOlvidoIP    = politicaLogin{Libres: 10, Bloqueo: 30, Duracion: time.Hour}
+)
+
+// esperaMaxima acota la demora progresiva antes del bloqueo
+const esperaMaxima = time.Minute
+
+// calcularEspera devuelve cuánto hay que esperar tras fallos intentos fallidos
+// seguidos: nada mientras haya intentos libres, después 1s, 2s, 4s... hasta
+// esperaMaxima, y el bloqueo completo al llegar al límite.
+func calcularEspera(p politicaLogin, fallos int32) time.Duration {
+	if fallos >= p.Bloqueo {
+		return p.Duracion
+	}
+	if fallos <= p.Libres {
+		return 0
+	}
+
+	n := fallos - p.Libres - 1
+	if n >= 6 {
+		return esperaMaxima
+	}
+	espera := time.Second << n
+	if espera > esperaMaxima {
+		return esperaMaxima
+	}
+	return espera
+}
+
+func claveUsuario(username string) string { return "usuario:" + username }
+func claveIP(ip string) string            { return "ip:" + ip }
+
+// Los pedidos de recuperación cuentan aparte de los logins, y nunca se
+// liberan: no hay un "pedido correcto" que limpie el contador
+func claveOlvidoEmail(email string) string { return "olvido:" + strings.ToLower(email) }
+func claveOlvidoIP(ip string) string       { return "olvido-ip:" + ip }
+
+// clientIP toma la IP de la conexión. No se confía en X-Forwarded-For.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// intentoReservado guarda, por clave, la demora que dejó fijada la reserva
+// (inválida si no dejó ninguna), para poder deshacerla si el intento sale bien
+type intentoReservado map[string]sql.NullTime
+
+// reservarIntento cuenta el intento en cada clave antes de verificarlo, así
+// los pedidos en paralelo no ven todos el contador en cero: las filas quedan
+// bloqueadas hasta el commit y el pedido siguiente con la misma clave espera
+// y ve la demora que dejó éste. Si alguna clave está frenada no cuenta nada
+// y devuelve la espera. Si no, deja fijada la demora como si el intento
+// fallara; liberarIntento la deshace cuando sale bien.
+func (h *AuthHandler) reservarIntento(ctx context.Context, politicas map[string]politicaLogin) (time.Duration, intentoReservado, error) {
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	now := ahora().UTC() // TIMESTAMP sin zona: siempre UTC
+	var espera time.Duration
+	limites := map[string]db.LoginLimite{}
+	// Siempre en el mismo orden, para que dos pedidos no se bloqueen entre sí
+	for _, clave := range slices.Sorted(maps.Keys(politicas)) {
+		limite, err := qtx.ReservarIntentoLogin(ctx, clave)
+		if err != nil {
+			return 0, nil, err
+		}
+		if limite.BloqueadoHasta.Valid && limite.BloqueadoHasta.Time.After(now) {
+			espera = max(espera, limite.BloqueadoHasta.Time.Sub(now))
+		}
+		limites[clave] = limite
+	}
+	if espera > 0 {
+		return espera, nil, nil // el rollback descuenta los intentos
+	}
+
+	reserva := intentoReservado{}
+	for clave, limite := range limites {
+		d := calcularEspera(politicas[clave], limite.Fallos)
+		if d == 0 {
+			reserva[clave] = sql.NullTime{}
+			continue
+		}
+		// Postgres guarda microsegundos: así LiberarIntentoLogin la reconoce
+		hasta := sql.NullTime{Time: now.Add(d).Truncate(time.Microsecond), Valid: true}
+		err := qtx.SetLoginBloqueadoHasta(ctx, db.SetLoginBloqueadoHastaParams{Clave: clave, BloqueadoHasta: hasta})
+		if err != nil {
+			return 0, nil, err
+		}
+		reserva[clave] = hasta
+	}
+	return 0, reserva, tx.Commit()
+}
+
+// liberarIntento descuenta el intento reservado en clave
+func (h *AuthHandler) liberarIntento(ctx context.Context, reserva intentoReservado, clave string) error {
+	return h.Queries.LiberarIntentoLogin(ctx, db.LiberarIntentoLoginParams{
+		Clave:          clave,
+		BloqueadoHasta: reserva[clave],
+	})
+}
+
+// auditarLogin guarda el intento fallido; un error sólo se loguea
+func (h *AuthHandler) auditarLogin(ctx context.Context, username, ip, motivo string) {
+	err := h.Queries.CreateLoginFallido(ctx, db.CreateLoginFallidoParams{
+		Username: username,
+		Ip:       ip,
+		Motivo:   motivo,
+	})
+	if err != nil {
+		log.Println("Error registrando login fallido:", err)
+	}
+}
+
+// responderDemasiadosIntentos contesta 429 con Retry-After en segundos
+func responderDemasiadosIntentos(w http.ResponseWriter, r *http.Request, espera time.Duration) {
+	segundos := int((espera + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(segundos))
+	responderError(w, r, http.StatusTooManyRequests, CodigoDemasiadosIntentos, "Demasiados intentos, probá de nuevo en "+strconv.Itoa(segundos)+" segundos")
+}
+
+// DesbloquearUsuario borra los fallos acumulados de un usuario de la barbería
+func (h *AuthHandler) DesbloquearUsuario(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, err := h.Queries.GetBarberiaBySlug(ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de usuario inválido")
+		return
+	}
+
+	usuario, err := h.Queries.GetUsuarioByID(ctx, int32(id))
+	if err != nil || usuario.BarberiaID != barberia.ID {
+		responderError(w, r, http.StatusNotFound, CodigoUsuarioNoEncontrado, "Usuario no encontrado")
+		return
+	}
+
+	if err := h.Queries.DeleteLoginLimite(ctx, claveUsuario(usuario.Username)); err != nil {
+		errorInterno(w, r, err, "Error desbloqueando usuario")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/mail"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestCalcularEspera tests la demora progresiva y el bloqueo
+func TestCalcularEspera(t *testing.T) {
+	p := politicaLogin{Libres: 3, Bloqueo: 10, Duracion: 15 * time.Minute}
+
+	casos := []struct {
+		fallos int32
+		want   time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{9, 32 * time.Second},
+		{10, 15 * time.Minute},
+		{40, 15 * time.Minute},
+	}
+	for _, c := range casos {
+		if got := calcularEspera(p, c.fallos); got != c.want {
+			t.Errorf("%d fallos: se esperaba %v, pero se obtuvo %v", c.fallos, c.want, got)
+		}
+	}
+
+	// Con muchos intentos antes del bloqueo la demora no pasa del máximo
+	if got := calcularEspera(politicaIP, 49); got != esperaMaxima {
+		t.Errorf("Se esperaba la demora máxima, pero se obtuvo %v", got)
+	}
+}
+
+// TestClientIP tests que se use la IP de la conexión sin el puerto
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/login", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1")
+	if got := clientIP(r); got != "203.0.113.7" {
+		t.Errorf("Se esperaba 203.0.113.7, pero se obtuvo %s", got)
+	}
+}
+
+// usuarioLoginTest crea un usuario con contraseña "correcta123" y una IP
+// propia del test, para no arrastrar fallos de otras corridas
+func usuarioLoginTest(t *testing.T, conn *sql.DB) (db.Barberia, db.Usuario, string) {
+	t.Helper()
+	barberia, _, _ := fixtureBarberia(t, conn)
+	q := db.New(conn)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correcta123"), bcrypt.MinCost)
+	usuario, err := q.CreateUsuario(context.Background(), db.CreateUsuarioParams{
+		BarberiaID:   barberia.ID,
+		Nombre:       "Bloqueable",
+		Apellido:     "Test",
+		Username:     "bloq" + barberia.Slug,
+		Email:        "bloq" + barberia.Slug + "@test.com",
+		PasswordHash: string(hash),
+		Rol:          RolBarbero,
+	})
+	if err != nil {
+		t.Fatalf("Error creando usuario: %v", err)
+	}
+
+	ip := "198.51.100." + strconv.Itoa(int(time.Now().UnixNano()%250))
+	t.Cleanup(func() {
+		conn.Exec("DELETE FROM login_limites WHERE clave IN ($1, $2)", claveUsuario(usuario.Username), claveIP(ip))
+		conn.Exec("DELETE FROM login_fallidos WHERE username = $1", usuario.Username)
+	})
+	return barberia, usuario, ip
+}
+
+// loginTest hace el POST /login desde ip
+func loginTest(r http.Handler, ip, username, password string) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(Credentials{Username: username, Password: password})
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(b))
+	req.RemoteAddr = ip + ":40000"
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestLogin_Bloqueo cubre contra la DB: demora tras varios fallos (429 con
+// Retry-After) y desbloqueo por un admin.
+func TestLogin_Bloqueo(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, usuario, ip := usuarioLoginTest(t, conn)
+	h := NewAuthHandler(db.New(conn), conn, mail.LogSender{})
+
+	r := chi.NewRouter()
+	r.Post("/login", h.Login)
+	r.Post("/b/{slug}/usuarios/{id}/desbloquear", h.DesbloquearUsuario)
+	login := func(password string) *httptest.ResponseRecorder {
+		return loginTest(r, ip, usuario.Username, password)
+	}
+
+	for i := 1; i <= int(politicaUsuario.Libres)+1; i++ {
+		if rec := login("mala"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Intento %d: se esperaba 401, pero se obtuvo %d", i, rec.Code)
+		}
+	}
+
+	// Tras superar los intentos libres hay demora, aun con la contraseña correcta
+	rec := login("correcta123")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("Se esperaba 429 con Retry-After, pero se obtuvo %d", rec.Code)
+	}
+
+	// El admin desbloquea y el usuario puede entrar
+	req := httptest.NewRequest(http.MethodPost, "/b/"+barberia.Slug+"/usuarios/"+strconv.Itoa(int(usuario.ID))+"/desbloquear", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Desbloquear: se esperaba 204, pero se obtuvo %d", rec.Code)
+	}
+	if rec := login("correcta123"); rec.Code != http.StatusOK {
+		t.Errorf("Tras desbloquear se esperaba 200, pero se obtuvo %d", rec.Code)
+	}
+}
+
+// TestLogin_IntentosEnParalelo tests que adivinar en paralelo no saltee la
+// demora: cada intento se cuenta antes de verificar la contraseña
+func TestLogin_IntentosEnParalelo(t *testing.T) {
+	conn := abrirDBTest(t)
+	_, usuario, ip := usuarioLoginTest(t, conn)
+	h := NewAuthHandler(db.New(conn), conn, mail.LogSender{})
+
+	codigos := make(chan int, 20)
+	var wg sync.WaitGroup
+	for range cap(codigos) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codigos <- loginTest(http.HandlerFunc(h.Login), ip, usuario.Username, "mala").Code
+		}()
+	}
+	wg.Wait()
+	close(codigos)
+
+	// Pasan los intentos libres más el que fija la primera demora
+	verificados := 0
+	for code := range codigos {
+		if code == http.StatusUnauthorized {
+			verificados++
+		} else if code != http.StatusTooManyRequests {
+			t.Errorf("Se esperaba 401 o 429, pero se obtuvo %d", code)
+		}
+	}
+	if verificados != int(politicaUsuario.Libres)+1 {
+		t.Errorf("Se esperaban %d contraseñas verificadas, pero fueron %d", politicaUsuario.Libres+1, verificados)
+	}
+}
+
+// TestLogin_ExitoLiberaIntento tests que entrar bien no le sume un fallo a la IP
+func TestLogin_ExitoLiberaIntento(t *testing.T) {
+	conn := abrirDBTest(t)
+	_, usuario, ip := usuarioLoginTest(t, conn)
+	q := db.New(conn)
+	h := NewAuthHandler(q, conn, mail.LogSender{})
+
+	loginTest(http.HandlerFunc(h.Login), ip, usuario.Username, "mala")
+	if rec := loginTest(http.HandlerFunc(h.Login), ip, usuario.Username, "correcta123"); rec.Code != http.StatusOK {
+		t.Fatalf("Se esperaba 200, pero se obtuvo %d", rec.Code)
+	}
+
+	limite, err := q.GetLoginLimite(context.Background(), claveIP(ip))
+	if err != nil || limite.Fallos != 1 || limite.BloqueadoHasta.Valid {
+		t.Errorf("A la IP sólo debería quedarle el fallo, se obtuvo %+v (%v)", limite, err)
+	}
+	if _, err := q.GetLoginLimite(context.Background(), claveUsuario(usuario.Username)); err == nil {
+		t.Error("Los fallos del usuario deberían limpiarse al entrar")
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// PrefijoAPI es donde se monta la API JSON. Las rutas sin prefijo quedan
+// como alias deprecados para las páginas de web/.
+const PrefijoAPI = "/api/v1"
+
+// especificacionOpenAPI describe la API bajo PrefijoAPI. Se mantiene a mano:
+// el test de cmd/server falla si una ruta del router no está documentada o
+// si el documento describe una que ya no existe.
+//
+//go:embed openapi.json
+var especificacionOpenAPI []byte
+
+// OpenAPI sirve el documento OpenAPI 3 de la API
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(especificacionOpenAPI)
+}
+
+// Deprecada marca las respuestas de las rutas viejas sin prefijo: el header
+// Deprecation avisa que van a desaparecer y Link apunta a la ruta nueva
+func Deprecada(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+PrefijoAPI+r.URL.Path+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/mail"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// duracionReset es cuánto vale el link de recuperación de contraseña
+const duracionReset = time.Hour
+
+// validarPassword: bcrypt ignora lo que pase de 72 bytes, así que se rechaza
+func validarPassword(p string) error {
+	if len(p) < 8 {
+		return errors.New("la contraseña debe tener al menos 8 caracteres")
+	}
+	if len(p) > 72 {
+		return errors.New("la contraseña no puede superar los 72 caracteres")
+	}
+	return nil
+}
+
+type CambiarPasswordRequest struct {
+	PasswordActual string `json:"password_actual" validar:"requerido"`
+	PasswordNuevo  string `json:"password_nuevo" validar:"requerido,password"`
+}
+
+// CambiarPassword cambia la contraseña del usuario logueado. Cierra el resto
+// de sus sesiones revocando los refresh tokens.
+func (h *AuthHandler) CambiarPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Se requiere autenticación")
+		return
+	}
+
+	var req CambiarPasswordRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	usuario, err := h.Queries.GetUsuarioByID(ctx, claims.UserID)
+	if err != nil {
+		responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Usuario no disponible")
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(usuario.PasswordHash), []byte(req.PasswordActual)) != nil {
+		responderError(w, r, http.StatusForbidden, CodigoCredenciales, "La contraseña actual no es correcta")
+		return
+	}
+
+	if err := h.guardarPassword(ctx, h.Queries, usuario.ID, req.PasswordNuevo); err != nil {
+		errorInterno(w, r, err, "Error guardando contraseña")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type OlvidoPasswordRequest struct {
+	Email string `json:"email" validar:"requerido,max=100,email"`
+}
+
+// OlvidoPassword envía por mail un link de recuperación. Responde 202 exista o
+// no el email, para no revelar qué cuentas existen. Los pedidos se limitan
+// por email y por IP como los logins, cuenten o no con un usuario detrás.
+func (h *AuthHandler) OlvidoPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req OlvidoPasswordRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	espera, _, err := h.reservarIntento(ctx, map[string]politicaLogin{
+		claveOlvidoEmail(req.Email): politicaOlvidoEmail,
+		claveOlvidoIP(clientIP(r)):  politicaOlvidoIP,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error procesando el pedido")
+		return
+	}
+	if espera > 0 {
+		responderDemasiadosIntentos(w, r, espera)
+		return
+	}
+
+	usuario, err := h.Queries.GetUsuarioByEmail(ctx, req.Email)
+	if err == nil {
+		err = h.enviarReset(ctx, usuario)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// Se loguea pero no se le cuenta al cliente
+		log.Println("Error enviando recuperación de contraseña:", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// enviarReset crea el token de un solo uso y lo manda al email del usuario.
+// Los códigos anteriores sin usar dejan de valer: sólo sirve el último mail.
+func (h *AuthHandler) enviarReset(ctx context.Context, usuario db.Usuario) error {
+	token, hash, err := nuevoToken()
+	if err != nil {
+		return err
+	}
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	if err := qtx.InvalidatePasswordResets(ctx, usuario.ID); err != nil {
+		return err
+	}
+	_, err = qtx.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		UsuarioID: usuario.ID,
+		TokenHash: hash,
+		ExpiraEn:  ahora().UTC().Add(duracionReset), // TIMESTAMP sin zona: siempre UTC
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	cuerpo := fmt.Sprintf("Hola %s,\n\nPara elegir una contraseña nueva usá este código dentro de la próxima hora:\n\n%s\n", usuario.Nombre, token)
+	if h.URLBase != "" {
+		cuerpo += fmt.Sprintf("\nO entrá a: %s/admin?reset=%s\n", h.URLBase, token)
+	}
+	cuerpo += "\nSi no lo pediste, ignorá este mensaje.\n"
+
+	return h.Mail.Enviar(ctx, mail.Mensaje{
+		Para:   usuario.Email,
+		Asunto: "Recuperar contraseña - AgendaFácil",
+		Cuerpo: cuerpo,
+	})
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validar:"requerido"`
+	Password string `json:"password" validar:"requerido,password"`
+}
+
+var errResetInvalido = errors.New("el código de recuperación es inválido o ya venció")
+
+// ResetPassword canjea el token del mail por una contraseña nueva
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ResetPasswordRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	err := h.canjearReset(ctx, req.Token, req.Password)
+	if errors.Is(err, errResetInvalido) {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, errResetInvalido.Error())
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error guardando contraseña")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canjearReset marca el token como usado y cambia la contraseña en una sola
+// transacción: si algo falla, el token sigue sirviendo.
+func (h *AuthHandler) canjearReset(ctx context.Context, token, password string) error {
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	reset, err := qtx.GetPasswordReset(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return errResetInvalido
+	}
+	if err != nil {
+		return err
+	}
+	if ahora().UTC().After(reset.ExpiraEn) {
+		return errResetInvalido
+	}
+
+	n, err := qtx.UsePasswordReset(ctx, reset.ID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errResetInvalido
+	}
+
+	if err := h.guardarPassword(ctx, qtx, reset.UsuarioID, password); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// guardarPassword hashea y guarda la contraseña y revoca las sesiones abiertas
+func (h *AuthHandler) guardarPassword(ctx context.Context, q *db.Queries, usuarioID int32, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	err = q.UpdateUsuarioPassword(ctx, db.UpdateUsuarioPasswordParams{
+		ID:           usuarioID,
+		PasswordHash: string(hash),
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.RevokeRefreshTokensUsuario(ctx, usuarioID)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/mail"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// senderTest guarda los mensajes en memoria en lugar de enviarlos
+type senderTest struct {
+	mu       sync.Mutex
+	enviados []mail.Mensaje
+}
+
+func (s *senderTest) Enviar(_ context.Context, m mail.Mensaje) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enviados = append(s.enviados, m)
+	return nil
+}
+
+// TestValidarPassword tests los límites de largo de la contraseña
+func TestValidarPassword(t *testing.T) {
+	if validarPassword("corta") == nil {
+		t.Error("Una contraseña de 5 caracteres debería rechazarse")
+	}
+	if validarPassword(strings.Repeat("a", 73)) == nil {
+		t.Error("Más de 72 bytes debería rechazarse (bcrypt los ignora)")
+	}
+	if err := validarPassword("unaClaveSegura"); err != nil {
+		t.Errorf("Se esperaba válida, se obtuvo %v", err)
+	}
+}
+
+// TestResetPassword cubre el flujo de recuperación contra la DB: el token
+// llega por mail, cambia la contraseña una sola vez y cierra las sesiones.
+func TestResetPassword(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, _, _ := fixtureBarberia(t, conn)
+	q := db.New(conn)
+	sender := &senderTest{}
+	h := NewAuthHandler(q, conn, sender)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("vieja12345"), bcrypt.MinCost)
+	usuario, err := q.CreateUsuario(context.Background(), db.CreateUsuarioParams{
+		BarberiaID:   barberia.ID,
+		Nombre:       "Olvidadizo",
+		Apellido:     "Test",
+		Username:     "olvido" + barberia.Slug,
+		Email:        "olvido" + barberia.Slug + "@test.com",
+		PasswordHash: string(hash),
+		Rol:          RolBarbero,
+	})
+	if err != nil {
+		t.Fatalf("Error creando usuario: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Post("/login", h.Login)
+	r.Post("/password/olvido", h.OlvidoPassword)
+	r.Post("/password/reset", h.ResetPassword)
+
+	// IP propia del test: los pedidos de recuperación se limitan por IP
+	ip := "198.51.100." + strconv.Itoa(int(time.Now().UnixNano()%250))
+	t.Cleanup(func() {
+		conn.Exec("DELETE FROM login_limites WHERE clave IN ($1, $2, $3, $4, $5)",
+			claveOlvidoEmail(usuario.Email), claveOlvidoEmail("nadie@test.com"), claveOlvidoIP(ip), claveIP(ip), claveUsuario(usuario.Username))
+	})
+	post := func(ruta string, body any) int {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, ruta, bytes.NewReader(b))
+		req.RemoteAddr = ip + ":40000"
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Un email inexistente responde igual y no manda nada
+	if code := post("/password/olvido", OlvidoPasswordRequest{Email: "nadie@test.com"}); code != http.StatusAccepted {
+		t.Errorf("Se esperaba 202, se obtuvo %d", code)
+	}
+	if code := post("/password/olvido", OlvidoPasswordRequest{Email: usuario.Email}); code != http.StatusAccepted {
+		t.Errorf("Se esperaba 202, se obtuvo %d", code)
+	}
+	if code := post("/password/olvido", OlvidoPasswordRequest{Email: usuario.Email}); code != http.StatusAccepted {
+		t.Errorf("Segundo pedido: se esperaba 202, se obtuvo %d", code)
+	}
+	if len(sender.enviados) != 2 || sender.enviados[0].Para != usuario.Email {
+		t.Fatalf("Se esperaban dos mails a %s, se enviaron %v", usuario.Email, sender.enviados)
+	}
+
+	// El token es la línea del cuerpo que no tiene espacios
+	tokenDe := func(m mail.Mensaje) string {
+		var token string
+		for _, linea := range strings.Split(m.Cuerpo, "\n") {
+			if linea != "" && !strings.Contains(linea, " ") {
+				token = linea
+			}
+		}
+		return token
+	}
+
+	// Sólo vale el último código enviado
+	if code := post("/password/reset", ResetPasswordRequest{Token: tokenDe(sender.enviados[0]), Password: "nueva12345"}); code != http.StatusBadRequest {
+		t.Errorf("Código anterior: se esperaba 400, se obtuvo %d", code)
+	}
+	token := tokenDe(sender.enviados[1])
+	if code := post("/password/reset", ResetPasswordRequest{Token: token, Password: "nueva12345"}); code != http.StatusNoContent {
+		t.Fatalf("Reset: se esperaba 204, se obtuvo %d", code)
+	}
+	if code := post("/password/reset", ResetPasswordRequest{Token: token, Password: "otra123456"}); code != http.StatusBadRequest {
+		t.Errorf("Reusar el token: se esperaba 400, se obtuvo %d", code)
+	}
+
+	if code := post("/login", Credentials{Username: usuario.Username, Password: "vieja12345"}); code != http.StatusUnauthorized {
+		t.Errorf("La contraseña vieja no debería servir, se obtuvo %d", code)
+	}
+	if code := post("/login", Credentials{Username: usuario.Username, Password: "nueva12345"}); code != http.StatusOK {
+		t.Errorf("La contraseña nueva debería servir, se obtuvo %d", code)
+	}
+}
+
+// TestOlvidoPassword_Limite tests que no se pueda llenar de mails la casilla
+// de otro: pasados los pedidos libres por email se responde 429
+func TestOlvidoPassword_Limite(t *testing.T) {
+	conn := abrirDBTest(t)
+	sender := &senderTest{}
+	h := NewAuthHandler(db.New(conn), conn, sender)
+
+	email := "limite" + strconv.FormatInt(time.Now().UnixNano(), 10) + "@test.com"
+	t.Cleanup(func() { conn.Exec("DELETE FROM login_limites WHERE clave = $1", claveOlvidoEmail(email)) })
+
+	pedir := func(i int) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(OlvidoPasswordRequest{Email: email})
+		req := httptest.NewRequest(http.MethodPost, "/password/olvido", bytes.NewReader(b))
+		// Cada pedido desde otra IP: el límite por email alcanza igual
+		req.RemoteAddr = "203.0.113." + strconv.Itoa(i) + ":40000"
+		t.Cleanup(func() { conn.Exec("DELETE FROM login_limites WHERE clave = $1", claveOlvidoIP(clientIP(req))) })
+		rec := httptest.NewRecorder()
+		h.OlvidoPassword(rec, req)
+		return rec
+	}
+
+	for i := 1; i <= int(politicaOlvidoEmail.Libres)+1; i++ {
+		if rec := pedir(i); rec.Code != http.StatusAccepted {
+			t.Fatalf("Pedido %d: se esperaba 202, se obtuvo %d", i, rec.Code)
+		}
+	}
+	rec := pedir(100)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Se esperaba 429 con Retry-After, se obtuvo %d", rec.Code)
+	}
+}
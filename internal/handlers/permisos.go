@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Roles de usuarios.rol
+const (
+	RolAdmin   = "admin"
+	RolBarbero = "barbero"
+)
+
+// Permiso es una acción del panel que se habilita según el rol
+type Permiso string
+
+const (
+	PermisoStaff         Permiso = "staff"         // alta y gestión de barberos
+	PermisoServicios     Permiso = "servicios"     // catálogo de servicios
+	PermisoConfiguracion Permiso = "configuracion" // ajustes y horario general de la barbería
+	PermisoAgenda        Permiso = "agenda"        // turnos, horarios y bloqueos (propios si es barbero)
+	PermisoClientes      Permiso = "clientes"      // fichas e historial de clientes
+)
+
+// permisosPorRol es la matriz de permisos. El barbero sólo gestiona su agenda;
+// qué parte de la agenda es "suya" lo resuelven SoloPropioBarbero y los handlers.
+var permisosPorRol = map[string][]Permiso{
+	RolAdmin:   {PermisoStaff, PermisoServicios, PermisoConfiguracion, PermisoAgenda, PermisoClientes},
+	RolBarbero: {PermisoAgenda, PermisoClientes},
+}
+
+func tienePermiso(rol string, p Permiso) bool {
+	for _, permiso := range permisosPorRol[rol] {
+		if permiso == p {
+			return true
+		}
+	}
+	return false
+}
+
+// esAdmin indica si el usuario del request es admin de su barbería
+func esAdmin(r *http.Request) bool {
+	claims, ok := ClaimsFromContext(r.Context())
+	return ok && claims.Rol == RolAdmin
+}
+
+// puedeGestionarBarbero: el admin gestiona a cualquier barbero, el barbero sólo a sí mismo
+func puedeGestionarBarbero(r *http.Request, barberoID int32) bool {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	return claims.Rol == RolAdmin || claims.UserID == barberoID
+}
+
+// RequireRole deja pasar sólo a los roles indicados. Va después de AuthMiddleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Se requiere autenticación")
+				return
+			}
+			for _, rol := range roles {
+				if claims.Rol == rol {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			responderError(w, r, http.StatusForbidden, CodigoSinPermiso, "No tenés permisos para esta acción")
+		})
+	}
+}
+
+// RequirePermiso consulta la matriz de permisos con el rol del usuario
+func RequirePermiso(p Permiso) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				responderError(w, r, http.StatusUnauthorized, CodigoNoAutenticado, "Se requiere autenticación")
+				return
+			}
+			if !tienePermiso(claims.Rol, p) {
+				responderError(w, r, http.StatusForbidden, CodigoSinPermiso, "No tenés permisos para esta acción")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SoloPropioBarbero restringe las rutas /barberos/{id}/... : un barbero sólo
+// puede operar sobre su propio id, el admin sobre cualquiera.
+func SoloPropioBarbero(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de barbero inválido")
+			return
+		}
+		if !puedeGestionarBarbero(r, int32(id)) {
+			responderError(w, r, http.StatusForbidden, CodigoSinPermiso, "Sólo podés gestionar tu propia agenda")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestTienePermiso tests la matriz de permisos por rol
+func TestTienePermiso(t *testing.T) {
+	for _, p := range []Permiso{PermisoStaff, PermisoServicios, PermisoConfiguracion, PermisoAgenda, PermisoClientes} {
+		if !tienePermiso(RolAdmin, p) {
+			t.Errorf("El admin debería tener el permiso %s", p)
+		}
+	}
+
+	if !tienePermiso(RolBarbero, PermisoAgenda) {
+		t.Error("El barbero debería poder gestionar su agenda")
+	}
+	if !tienePermiso(RolBarbero, PermisoClientes) {
+		t.Error("El barbero debería poder ver a los clientes")
+	}
+	for _, p := range []Permiso{PermisoStaff, PermisoServicios, PermisoConfiguracion} {
+		if tienePermiso(RolBarbero, p) {
+			t.Errorf("El barbero no debería tener el permiso %s", p)
+		}
+	}
+
+	if tienePermiso("desconocido", PermisoAgenda) {
+		t.Error("Un rol desconocido no debería tener permisos")
+	}
+}
+
+// TestPermisosRutas tests los middlewares de rol aplicados como en main.go
+func TestPermisosRutas(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(AuthMiddleware)
+		r.Use(TenantGuard(resolverTest))
+		r.With(RequirePermiso(PermisoServicios)).Post("/b/{slug}/servicios", ok)
+		r.With(RequirePermiso(PermisoServicios)).Delete("/b/{slug}/servicios/{id}", ok)
+		r.With(RequireRole(RolAdmin)).Post("/b/{slug}/barberos", ok)
+		r.With(RequirePermiso(PermisoAgenda), SoloPropioBarbero).Put("/b/{slug}/barberos/{id}/horarios", ok)
+		r.With(RequirePermiso(PermisoClientes)).Get("/b/{slug}/clientes", ok)
+		r.With(RequirePermiso(PermisoClientes), RequireRole(RolAdmin)).Post("/b/{slug}/clientes/{id}/fusionar", ok)
+	})
+
+	admin := tokenTest(t, 1, RolAdmin)
+	barbero := tokenTest(t, 1, RolBarbero) // UserID 7
+
+	casos := []struct {
+		nombre string
+		metodo string
+		ruta   string
+		token  string
+		want   int
+	}{
+		{"admin crea servicio", http.MethodPost, "/b/a/servicios", admin, http.StatusNoContent},
+		{"barbero crea servicio", http.MethodPost, "/b/a/servicios", barbero, http.StatusForbidden},
+		{"admin da de baja servicio", http.MethodDelete, "/b/a/servicios/3", admin, http.StatusNoContent},
+		{"barbero da de baja servicio", http.MethodDelete, "/b/a/servicios/3", barbero, http.StatusForbidden},
+		{"admin crea barbero", http.MethodPost, "/b/a/barberos", admin, http.StatusNoContent},
+		{"barbero crea barbero", http.MethodPost, "/b/a/barberos", barbero, http.StatusForbidden},
+		{"barbero edita su horario", http.MethodPut, "/b/a/barberos/7/horarios", barbero, http.StatusNoContent},
+		{"barbero edita horario ajeno", http.MethodPut, "/b/a/barberos/8/horarios", barbero, http.StatusForbidden},
+		{"admin edita horario de un barbero", http.MethodPut, "/b/a/barberos/8/horarios", admin, http.StatusNoContent},
+		{"barbero busca clientes", http.MethodGet, "/b/a/clientes", barbero, http.StatusNoContent},
+		{"barbero fusiona clientes", http.MethodPost, "/b/a/clientes/1/fusionar", barbero, http.StatusForbidden},
+		{"admin fusiona clientes", http.MethodPost, "/b/a/clientes/1/fusionar", admin, http.StatusNoContent},
+		{"admin de otra barbería", http.MethodPost, "/b/b/servicios", admin, http.StatusForbidden},
+	}
+	for _, c := range casos {
+		req := httptest.NewRequest(c.metodo, c.ruta, nil)
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != c.want {
+			t.Errorf("%s: se esperaba %d, pero se obtuvo %d", c.nombre, c.want, rec.Code)
+		}
+	}
+}
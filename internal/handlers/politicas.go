@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+)
+
+// Motivos de rechazo de una reserva por las políticas de la barbería. Son
+// parte de la API: el front los usa para elegir qué mostrar, así que no se
+// renombran.
+const (
+	MotivoHorarioPasado      = "horario_pasado"
+	MotivoAnticipacionMinima = "anticipacion_minima"
+	MotivoAnticipacionMaxima = "anticipacion_maxima"
+	MotivoTelefonoRequerido  = "telefono_requerido"
+	MotivoLimiteCliente      = "limite_turnos_cliente"
+)
+
+// errorPolitica es una reserva rechazada por una política. Se responde con
+// el código CodigoPolitica y el motivo aparte, para que se pueda leer sin
+// parsear el mensaje; Limite es el valor configurado que se incumplió
+// (minutos, días o turnos).
+type errorPolitica struct {
+	Status  int
+	Mensaje string
+	Motivo  string
+	Limite  int32
+}
+
+func (e errorPolitica) Error() string {
+	return e.Mensaje
+}
+
+func responderPolitica(w http.ResponseWriter, r *http.Request, e errorPolitica) {
+	escribirError(w, r, e.Status, ErrorAPI{
+		Codigo:  CodigoPolitica,
+		Mensaje: e.Mensaje,
+		Motivo:  e.Motivo,
+		Limite:  e.Limite,
+	})
+}
+
+// ventanaReserva verifica que el horario pedido caiga dentro de la ventana de
+// reserva de la barbería: no pasado, con la anticipación mínima y no más
+// allá de la máxima. La máxima se cuenta en días de calendario de la
+// barbería: con 30, hoy se puede reservar hasta el mismo día del mes que viene.
+func ventanaReserva(barberia db.Barberia, fecha, hora, now time.Time) error {
+	inicio, err := momentoReserva(barberia, fecha, hora, now)
+	if err != nil {
+		return err
+	}
+
+	if minimo := barberia.AnticipacionMinimaMinutos; minimo > 0 && inicio.Before(now.Add(time.Duration(minimo)*time.Minute)) {
+		return errorPolitica{
+			Status:  http.StatusBadRequest,
+			Mensaje: fmt.Sprintf("Los turnos se reservan con al menos %d minutos de anticipación", minimo),
+			Motivo:  MotivoAnticipacionMinima,
+			Limite:  minimo,
+		}
+	}
+
+	if maximo := barberia.AnticipacionMaximaDias; maximo > 0 {
+		hoy, _ := relojLocal(barberia, now)
+		if fecha.After(hoy.AddDate(0, 0, int(maximo))) {
+			return errorPolitica{
+				Status:  http.StatusBadRequest,
+				Mensaje: fmt.Sprintf("Los turnos se reservan con hasta %d días de anticipación", maximo),
+				Motivo:  MotivoAnticipacionMaxima,
+				Limite:  maximo,
+			}
+		}
+	}
+
+	return nil
+}
+
+// limiteCliente verifica que el cliente no supere los turnos activos que
+// permite la barbería. El cliente se identifica por teléfono, así que con un
+// límite configurado el teléfono pasa a ser obligatorio. Fuera de una
+// transacción es sólo un control previo: ver limiteClienteTx.
+func limiteCliente(ctx context.Context, q *db.Queries, barberia db.Barberia, telefono string, now time.Time) error {
+	maximo := barberia.MaxTurnosActivosCliente
+	if maximo <= 0 {
+		return nil
+	}
+
+	telefono = strings.TrimSpace(telefono)
+	if telefono == "" {
+		return errorPolitica{
+			Status:  http.StatusBadRequest,
+			Mensaje: "El teléfono es obligatorio para reservar en esta barbería",
+			Motivo:  MotivoTelefonoRequerido,
+		}
+	}
+
+	fecha, hora := relojLocal(barberia, now)
+	activos, err := q.CountTurnosActivosCliente(ctx, db.CountTurnosActivosClienteParams{
+		BarberiaID:      barberia.ID,
+		ClienteTelefono: toNullString(telefono),
+		DesdeFecha:      fecha,
+		DesdeHora:       hora,
+	})
+	if err != nil {
+		return err
+	}
+	if activos >= int64(maximo) {
+		return errorPolitica{
+			Status:  http.StatusConflict,
+			Mensaje: fmt.Sprintf("Ya tenés %d turnos activos; el máximo por cliente es %d", activos, maximo),
+			Motivo:  MotivoLimiteCliente,
+			Limite:  maximo,
+		}
+	}
+	return nil
+}
+
+// limiteClienteTx repite limiteCliente dentro de la transacción que guarda el
+// turno, con un lock por (barbería, teléfono) hasta el commit: dos reservas
+// simultáneas del mismo cliente se serializan y la segunda ya cuenta la
+// primera.
+func limiteClienteTx(ctx context.Context, qtx *db.Queries, barberia db.Barberia, telefono string, now time.Time) error {
+	if barberia.MaxTurnosActivosCliente <= 0 {
+		return nil
+	}
+	err := qtx.LockTurnosCliente(ctx, db.LockTurnosClienteParams{
+		BarberiaID: barberia.ID,
+		Telefono:   strings.TrimSpace(telefono),
+	})
+	if err != nil {
+		return err
+	}
+	return limiteCliente(ctx, qtx, barberia, telefono, now)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+)
+
+// TestVentanaReserva tests la anticipación mínima y máxima y el motivo de cada rechazo
+func TestVentanaReserva(t *testing.T) {
+	barberia := db.Barberia{
+		ZonaHoraria:               zonaPorDefecto,
+		AnticipacionMinimaMinutos: 60,
+		AnticipacionMaximaDias:    7,
+	}
+	// 10:00 del 10/01 en Buenos Aires
+	now := time.Date(2030, 1, 10, 13, 0, 0, 0, time.UTC)
+
+	casos := []struct {
+		nombre string
+		fecha  time.Time
+		hora   time.Time
+		motivo string // "" = se acepta
+	}{
+		{"ya pasó", fechaTest(2030, 1, 9), hora(12, 0), MotivoHorarioPasado},
+		{"sin la anticipación mínima", fechaTest(2030, 1, 10), hora(10, 30), MotivoAnticipacionMinima},
+		{"justo con la anticipación mínima", fechaTest(2030, 1, 10), hora(11, 0), ""},
+		{"último día de la ventana", fechaTest(2030, 1, 17), hora(18, 0), ""},
+		{"después de la ventana", fechaTest(2030, 1, 18), hora(9, 0), MotivoAnticipacionMaxima},
+	}
+	for _, c := range casos {
+		err := ventanaReserva(barberia, c.fecha, c.hora, now)
+		var politica errorPolitica
+		errors.As(err, &politica)
+		if politica.Motivo != c.motivo || (c.motivo == "" && err != nil) {
+			t.Errorf("%s: se esperaba motivo %q, se obtuvo %v", c.nombre, c.motivo, err)
+		}
+	}
+
+	// Sin políticas configuradas sólo se rechaza el pasado
+	sinLimites := db.Barberia{ZonaHoraria: zonaPorDefecto}
+	if err := ventanaReserva(sinLimites, fechaTest(2040, 1, 10), hora(10, 0), now); err != nil {
+		t.Errorf("Sin anticipación máxima se debería poder reservar lejos: %v", err)
+	}
+}
+
+// TestResponderPolitica tests que el rechazo llegue como JSON con el motivo
+func TestResponderPolitica(t *testing.T) {
+	rec := httptest.NewRecorder()
+	responderErrorAgenda(rec, httptest.NewRequest(http.MethodPost, "/b/a/reservar", nil), errorPolitica{
+		Status:  http.StatusConflict,
+		Mensaje: "Ya tenés 2 turnos activos",
+		Motivo:  MotivoLimiteCliente,
+		Limite:  2,
+	})
+
+	if rec.Code != http.StatusConflict || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Se esperaba 409 JSON, se obtuvo %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body struct {
+		Error map[string]any `json:"error"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	e := body.Error
+	if e["code"] != CodigoPolitica || e["motivo"] != MotivoLimiteCliente || e["limite"] != float64(2) || e["message"] == "" {
+		t.Errorf("Cuerpo inesperado: %s", rec.Body.String())
+	}
+}
+
+// TestReservar_LimiteCliente tests el máximo de turnos activos por teléfono
+func TestReservar_LimiteCliente(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	q := db.New(conn)
+	h := NewBarberiaHandler(q, conn)
+
+	_, err := q.UpdateBarberiaConfiguracion(context.Background(), db.UpdateBarberiaConfiguracionParams{
+		ID:                      barberia.ID,
+		MaxTurnosActivosCliente: sql.NullInt32{Int32: 1, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Error configurando la barbería: %v", err)
+	}
+
+	reservar := func(hora, telefono string) (int, string) {
+		rec := reservarTest(t, h, barberia.Slug, CreateReservaRequest{
+			ServicioID:      servicio.ID,
+			BarberoID:       barberoID,
+			Fecha:           diaReservaTest(),
+			HoraInicio:      hora,
+			ClienteNombre:   "Pedro",
+			ClienteTelefono: telefono,
+		})
+		var rechazo RespuestaError
+		json.Unmarshal(rec.Body.Bytes(), &rechazo)
+		return rec.Code, rechazo.Error.Motivo
+	}
+
+	if code, _ := reservar("10:00", "11 4444-5555"); code != http.StatusCreated {
+		t.Fatalf("Primera reserva: se esperaba 201, se obtuvo %d", code)
+	}
+	if code, motivo := reservar("11:00", "(011) 4444 5555"); code != http.StatusConflict || motivo != MotivoLimiteCliente {
+		t.Errorf("Segunda reserva: se esperaba 409 %s, se obtuvo %d %q", MotivoLimiteCliente, code, motivo)
+	}
+	if code, motivo := reservar("11:00", ""); code != http.StatusBadRequest || motivo != MotivoTelefonoRequerido {
+		t.Errorf("Sin teléfono: se esperaba 400 %s, se obtuvo %d %q", MotivoTelefonoRequerido, code, motivo)
+	}
+	if code, _ := reservar("11:00", "11 5555-6666"); code != http.StatusCreated {
+		t.Errorf("Otro cliente: se esperaba 201, se obtuvo %d", code)
+	}
+}
+
+// TestReservar_LimiteClienteConcurrente tests que reservas simultáneas del
+// mismo cliente en horarios distintos no pasen juntas el límite
+func TestReservar_LimiteClienteConcurrente(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	q := db.New(conn)
+	h := NewBarberiaHandler(q, conn)
+
+	_, err := q.UpdateBarberiaConfiguracion(context.Background(), db.UpdateBarberiaConfiguracionParams{
+		ID:                      barberia.ID,
+		MaxTurnosActivosCliente: sql.NullInt32{Int32: 1, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Error configurando la barbería: %v", err)
+	}
+
+	codigos := make(chan int, 8)
+	var wg sync.WaitGroup
+	for i := range cap(codigos) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codigos <- reservarTest(t, h, barberia.Slug, CreateReservaRequest{
+				ServicioID:      servicio.ID,
+				BarberoID:       barberoID,
+				Fecha:           diaReservaTest(),
+				HoraInicio:      fmt.Sprintf("%02d:00", 9+i),
+				ClienteNombre:   "Pedro",
+				ClienteTelefono: "11 4444-5555",
+			}).Code
+		}()
+	}
+	wg.Wait()
+	close(codigos)
+
+	creados := 0
+	for code := range codigos {
+		if code == http.StatusCreated {
+			creados++
+		} else if code != http.StatusConflict {
+			t.Errorf("Se esperaba 201 o 409, pero se obtuvo %d", code)
+		}
+	}
+	if creados != 1 {
+		t.Errorf("Se esperaba 1 reserva con límite 1, pero se crearon %d", creados)
+	}
+}
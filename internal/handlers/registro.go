@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/telefono"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	errSlugInvalido = errors.New("el slug debe tener entre 3 y 50 caracteres: minúsculas, números y guiones")
+	errSlugOcupado  = errors.New("ya existe una barbería con ese slug")
+)
+
+var patronSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func slugValido(slug string) bool {
+	return len(slug) >= 3 && len(slug) <= 50 && patronSlug.MatchString(slug)
+}
+
+// sinAcentos traduce las letras acentuadas más comunes del castellano
+var sinAcentos = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+)
+
+// slugDesdeNombre arma un slug a partir del nombre: "Barbería Don José" →
+// "barberia-don-jose". Puede devolver algo inválido si el nombre no tiene
+// letras ni números; quien lo usa lo valida.
+func slugDesdeNombre(nombre string) string {
+	nombre = sinAcentos.Replace(strings.ToLower(nombre))
+
+	var b strings.Builder
+	guion := false
+	for _, c := range nombre {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+			guion = false
+		} else if !guion && b.Len() > 0 {
+			b.WriteByte('-')
+			guion = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimSuffix(slug[:40], "-") // deja lugar para el sufijo "-N"
+	}
+	return slug
+}
+
+// RegistroBarberia son los datos de la barbería a crear. Sin slug se genera
+// uno a partir del nombre; sin horario se usa 09:00 a 19:00, sin zona
+// zonaPorDefecto y sin país, Argentina.
+type RegistroBarberia struct {
+	Nombre       string `json:"nombre" validar:"requerido,max=100"`
+	Slug         string `json:"slug" validar:"slug"`
+	HoraApertura string `json:"hora_apertura" validar:"hora"` // HH:MM
+	HoraCierre   string `json:"hora_cierre" validar:"hora"`   // HH:MM
+	ZonaHoraria  string `json:"zona_horaria" validar:"zona"`  // IANA
+	Pais         string `json:"pais" validar:"pais"`          // ISO 3166-1
+}
+
+// normalizar recorta los textos y completa los valores por defecto
+func (b *RegistroBarberia) normalizar() {
+	recortar(&b.Nombre, &b.Slug, &b.HoraApertura, &b.HoraCierre, &b.ZonaHoraria, &b.Pais)
+	b.Pais = strings.ToUpper(b.Pais)
+	if b.ZonaHoraria == "" {
+		b.ZonaHoraria = zonaPorDefecto
+	}
+	if b.Pais == "" {
+		b.Pais = telefono.PaisPorDefecto
+	}
+	if b.HoraApertura == "" {
+		b.HoraApertura = "09:00"
+	}
+	if b.HoraCierre == "" {
+		b.HoraCierre = "19:00"
+	}
+}
+
+func (b *RegistroBarberia) validarCampos() erroresCampos {
+	if !horaAntes(b.horario()) {
+		return erroresCampos{"hora_apertura": "debe ser anterior a hora_cierre"}
+	}
+	return nil
+}
+
+// horario devuelve apertura y cierre parseados; sólo vale después de validar
+func (b *RegistroBarberia) horario() (apertura, cierre time.Time) {
+	apertura, _ = time.Parse("15:04", b.HoraApertura)
+	cierre, _ = time.Parse("15:04", b.HoraCierre)
+	return apertura, cierre
+}
+
+// RegistroRequest da de alta una barbería con su primer admin. Los servicios
+// son opcionales: sin ellos se cargan serviciosIniciales.
+type RegistroRequest struct {
+	Barberia  RegistroBarberia        `json:"barberia"`
+	Admin     CreateBarberoRequest    `json:"admin"`
+	Servicios []CreateServicioRequest `json:"servicios"`
+}
+
+// normalizar completa los servicios iniciales. Se copian para que la
+// normalización de cada servicio no toque la lista global.
+func (req *RegistroRequest) normalizar() {
+	if len(req.Servicios) == 0 {
+		req.Servicios = append([]CreateServicioRequest(nil), serviciosIniciales...)
+	}
+}
+
+// RegistroResponse devuelve lo creado y la sesión del admin ya iniciada
+type RegistroResponse struct {
+	Barberia  db.Barberia     `json:"barberia"`
+	Admin     BarberoResponse `json:"admin"`
+	Servicios []db.Servicio   `json:"servicios"`
+	Sesion    SesionResponse  `json:"sesion"`
+}
+
+// serviciosIniciales se cargan si el registro no trae servicios. El precio
+// queda en cero para que el admin lo complete.
+var serviciosIniciales = []CreateServicioRequest{
+	{Nombre: "Corte de cabello", DuracionMinutos: 30, Precio: "0.00"},
+	{Nombre: "Barba", DuracionMinutos: 20, Precio: "0.00"},
+	{Nombre: "Corte y barba", DuracionMinutos: 45, Precio: "0.00"},
+}
+
+// diasIniciales es el horario semanal inicial: de lunes (1) a sábado (6)
+var diasIniciales = []int32{1, 2, 3, 4, 5, 6}
+
+// RegistrarBarberia crea en una sola transacción la barbería, su admin, el
+// horario semanal y los servicios iniciales, y devuelve la sesión del admin.
+func (h *AuthHandler) RegistrarBarberia(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req RegistroRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+	apertura, cierre := req.Barberia.horario()
+
+	slug, err := h.slugDisponible(ctx, req.Barberia)
+	if err != nil {
+		if errors.Is(err, errSlugOcupado) {
+			responderError(w, r, http.StatusConflict, CodigoSlugOcupado, err.Error())
+		} else {
+			errorInterno(w, r, err, "Error creando barbería")
+		}
+		return
+	}
+
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(req.Admin.Password), bcrypt.DefaultCost)
+	if err != nil {
+		errorInterno(w, r, err, "Error procesando contraseña")
+		return
+	}
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		errorInterno(w, r, err, "Error creando barbería")
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	resp := RegistroResponse{Servicios: []db.Servicio{}}
+	resp.Barberia, err = qtx.CreateBarberia(ctx, db.CreateBarberiaParams{
+		Nombre:       req.Barberia.Nombre,
+		Slug:         slug,
+		HoraApertura: apertura,
+		HoraCierre:   cierre,
+		ZonaHoraria:  req.Barberia.ZonaHoraria,
+		Pais:         req.Barberia.Pais,
+	})
+	if err != nil {
+		responderErrorRegistro(w, r, err)
+		return
+	}
+
+	admin, err := qtx.CreateUsuario(ctx, db.CreateUsuarioParams{
+		BarberiaID:   resp.Barberia.ID,
+		Nombre:       req.Admin.Nombre,
+		Apellido:     req.Admin.Apellido,
+		Username:     req.Admin.Username,
+		Email:        req.Admin.Email,
+		PasswordHash: string(hashedPwd),
+		Rol:          RolAdmin,
+	})
+	if err != nil {
+		responderErrorRegistro(w, r, err)
+		return
+	}
+	resp.Admin = toBarberoResponse(admin)
+
+	for _, dia := range diasIniciales {
+		_, err := qtx.CreateHorario(ctx, db.CreateHorarioParams{
+			BarberiaID: resp.Barberia.ID,
+			DiaSemana:  dia,
+			HoraInicio: apertura,
+			HoraFin:    cierre,
+		})
+		if err != nil {
+			responderErrorRegistro(w, r, err)
+			return
+		}
+	}
+
+	for _, s := range req.Servicios {
+		servicio, err := qtx.CreateServicio(ctx, db.CreateServicioParams{
+			BarberiaID:           resp.Barberia.ID,
+			Nombre:               s.Nombre,
+			DuracionMinutos:      s.DuracionMinutos,
+			Precio:               s.Precio,
+			BufferAntesMinutos:   toNullInt32(s.BufferAntesMinutos),
+			BufferDespuesMinutos: toNullInt32(s.BufferDespuesMinutos),
+		})
+		if err != nil {
+			responderErrorRegistro(w, r, err)
+			return
+		}
+		resp.Servicios = append(resp.Servicios, servicio)
+	}
+
+	if err := tx.Commit(); err != nil {
+		responderErrorRegistro(w, r, err)
+		return
+	}
+
+	resp.Sesion, err = h.crearSesion(ctx, admin)
+	if err != nil {
+		// La barbería ya existe: el admin puede entrar por /login
+		errorInterno(w, r, err, "Barbería creada, pero falló el inicio de sesión")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// slugDisponible devuelve el slug pedido si está libre (si no, errSlugOcupado)
+// o, sin slug pedido, el derivado del nombre con un sufijo si hace falta.
+func (h *AuthHandler) slugDisponible(ctx context.Context, b RegistroBarberia) (string, error) {
+	if b.Slug != "" {
+		existe, err := h.Queries.ExisteSlug(ctx, b.Slug)
+		if err != nil {
+			return "", err
+		}
+		if existe {
+			return "", errSlugOcupado
+		}
+		return b.Slug, nil
+	}
+
+	base := slugDesdeNombre(b.Nombre)
+	if len(base) < 3 {
+		base = "barberia-" + base
+		base = strings.TrimSuffix(base, "-")
+	}
+	for n := 1; n <= 20; n++ {
+		slug := base
+		if n > 1 {
+			slug = base + "-" + strconv.Itoa(n)
+		}
+		existe, err := h.Queries.ExisteSlug(ctx, slug)
+		if err != nil {
+			return "", err
+		}
+		if !existe {
+			return slug, nil
+		}
+	}
+	return "", errSlugOcupado
+}
+
+// responderErrorRegistro distingue los duplicados (otro registro ganó la
+// carrera por el slug, o el usuario ya existe) del resto de los errores.
+func responderErrorRegistro(w http.ResponseWriter, r *http.Request, err error) {
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "barberias_slug_key":
+		responderError(w, r, http.StatusConflict, CodigoSlugOcupado, errSlugOcupado.Error())
+	case esViolacionUnica(err):
+		responderError(w, r, http.StatusConflict, CodigoUsuarioExistente, errUsuarioDuplicado.Error())
+	default:
+		errorInterno(w, r, err, "Error creando barbería")
+	}
+}
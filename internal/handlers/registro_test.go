@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/mail"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestSlugDesdeNombre tests la generación del slug a partir del nombre
+func TestSlugDesdeNombre(t *testing.T) {
+	casos := map[string]string{
+		"Barbería Don José":        "barberia-don-jose",
+		"  El Rincón   del Corte ": "el-rincon-del-corte",
+		"Peluquería Nº 1!":         "peluqueria-n-1",
+		"¡¡¡":                      "",
+	}
+	for nombre, esperado := range casos {
+		if got := slugDesdeNombre(nombre); got != esperado {
+			t.Errorf("slugDesdeNombre(%q) = %q, se esperaba %q", nombre, got, esperado)
+		}
+	}
+
+	largo := slugDesdeNombre(strings.Repeat("barberia ", 10))
+	if len(largo) > 40 || strings.HasSuffix(largo, "-") || !slugValido(largo) {
+		t.Errorf("Slug largo mal recortado: %q", largo)
+	}
+}
+
+// TestSlugValido tests el formato aceptado para los slugs
+func TestSlugValido(t *testing.T) {
+	for _, s := range []string{"abc", "barberia-centro", "corte-2"} {
+		if !slugValido(s) {
+			t.Errorf("Slug válido rechazado: %q", s)
+		}
+	}
+	for _, s := range []string{"ab", "Barberia", "con espacio", "-inicio", "fin-", "doble--guion", "ñandu", strings.Repeat("a", 51)} {
+		if slugValido(s) {
+			t.Errorf("Slug inválido aceptado: %q", s)
+		}
+	}
+}
+
+func registroValido() RegistroRequest {
+	return RegistroRequest{
+		Barberia: RegistroBarberia{Nombre: " Barbería Centro "},
+		Admin: CreateBarberoRequest{
+			Nombre:   "Ana",
+			Apellido: "García",
+			Username: "ana",
+			Email:    "ana@correo.com",
+			Password: "Secreta123",
+		},
+	}
+}
+
+// TestValidarRegistro tests los valores por defecto y los errores del alta
+func TestValidarRegistro(t *testing.T) {
+	req := registroValido()
+	if errs := validar(&req); len(errs) > 0 {
+		t.Fatalf("Registro válido rechazado: %v", errs)
+	}
+	apertura, cierre := req.Barberia.horario()
+	if req.Barberia.Nombre != "Barbería Centro" {
+		t.Errorf("No se recortó el nombre: %q", req.Barberia.Nombre)
+	}
+	if apertura.Format("15:04") != "09:00" || cierre.Format("15:04") != "19:00" {
+		t.Errorf("Horario por defecto inesperado: %s a %s", apertura.Format("15:04"), cierre.Format("15:04"))
+	}
+	if len(req.Servicios) != len(serviciosIniciales) {
+		t.Errorf("Se esperaban %d servicios iniciales, se obtuvieron %d", len(serviciosIniciales), len(req.Servicios))
+	}
+
+	// Cada caso indica el campo que debe quedar marcado, con su nombre completo
+	invalidos := map[string]func(*RegistroRequest){
+		"barberia.nombre":        func(r *RegistroRequest) { r.Barberia.Nombre = "  " },
+		"barberia.slug":          func(r *RegistroRequest) { r.Barberia.Slug = "Mi Barbería" },
+		"barberia.hora_apertura": func(r *RegistroRequest) { r.Barberia.HoraApertura = "9hs" },
+		"barberia.pais":          func(r *RegistroRequest) { r.Barberia.Pais = "ZZ" },
+		"admin.email":            func(r *RegistroRequest) { r.Admin.Email = "" },
+		"admin.password":         func(r *RegistroRequest) { r.Admin.Password = "123" },
+		"servicios[1].duracion_minutos": func(r *RegistroRequest) {
+			r.Servicios = []CreateServicioRequest{{Nombre: "Corte", DuracionMinutos: 30, Precio: "10"}, {Nombre: "Barba", Precio: "10"}}
+		},
+	}
+	for campo, modificar := range invalidos {
+		req := registroValido()
+		modificar(&req)
+		if errs := validar(&req); len(errs) != 1 || errs[campo] == "" {
+			t.Errorf("%s: se esperaba sólo ese error, se obtuvo %v", campo, errs)
+		}
+	}
+
+	req = registroValido()
+	req.Barberia.HoraApertura, req.Barberia.HoraCierre = "20:00", "10:00"
+	if errs := validar(&req); errs["barberia.hora_apertura"] == "" {
+		t.Errorf("El cierre antes de la apertura debería rechazarse: %v", errs)
+	}
+	if serviciosIniciales[0].Nombre != "Corte de cabello" {
+		t.Error("La validación no debe modificar los servicios iniciales")
+	}
+}
+
+// TestValidarConfiguracion_Datos tests nombre, slug y horario en la configuración
+func TestValidarConfiguracion_Datos(t *testing.T) {
+	vacio, espacios := "", "  "
+	req := ConfiguracionRequest{Nombre: &espacios, Slug: &vacio}
+	if errs := validar(&req); len(errs) > 0 || req.Nombre != nil || req.Slug != nil {
+		t.Errorf("Los campos vacíos deberían quedar sin cambios: %+v %v", req, errs)
+	}
+
+	slug, hora := "Con Mayúsculas", "25:00"
+	errs := validar(&ConfiguracionRequest{Slug: &slug, HoraCierre: &hora})
+	if errs["slug"] != errSlugInvalido.Error() || errs["hora_cierre"] == "" {
+		t.Errorf("Se esperaban errores en slug y hora_cierre: %v", errs)
+	}
+
+	pais := " uy"
+	req = ConfiguracionRequest{Pais: &pais}
+	if errs := validar(&req); len(errs) > 0 || *req.Pais != "UY" {
+		t.Errorf("El país debería normalizarse a UY: %q %v", *req.Pais, errs)
+	}
+	pais = "ZZ"
+	if errs := validar(&ConfiguracionRequest{Pais: &pais}); errs["pais"] != errPaisNoSoportado.Error() {
+		t.Errorf("Se esperaba errPaisNoSoportado, se obtuvo %v", errs)
+	}
+}
+
+// TestRegistrarBarberia da de alta una barbería y prueba los duplicados
+func TestRegistrarBarberia(t *testing.T) {
+	conn := abrirDBTest(t)
+	q := db.New(conn)
+	h := NewAuthHandler(q, conn, mail.LogSender{})
+
+	registrar := func(req RegistroRequest) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(req)
+		rec := httptest.NewRecorder()
+		h.RegistrarBarberia(rec, httptest.NewRequest(http.MethodPost, "/barberias", bytes.NewReader(b)))
+		return rec
+	}
+
+	sufijo := strconv.FormatInt(time.Now().UnixNano(), 10)
+	req := registroValido()
+	req.Barberia.Nombre = "Barbería Test " + sufijo
+	req.Admin.Username = "admin" + sufijo
+	req.Admin.Email = "admin" + sufijo + "@test.com"
+
+	rec := registrar(req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Se esperaba 201, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp RegistroResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	t.Cleanup(func() { borrarBarberiaTest(conn, resp.Barberia.ID) })
+
+	if resp.Barberia.Slug != "barberia-test-"+sufijo {
+		t.Errorf("Slug inesperado: %q", resp.Barberia.Slug)
+	}
+	if resp.Admin.Rol != RolAdmin || resp.Sesion.Token == "" {
+		t.Errorf("El admin debería quedar con sesión iniciada: %+v", resp)
+	}
+	if len(resp.Servicios) != len(serviciosIniciales) {
+		t.Errorf("Se esperaban %d servicios, se obtuvieron %d", len(serviciosIniciales), len(resp.Servicios))
+	}
+	horarios, _ := q.ListHorarios(t.Context(), resp.Barberia.ID)
+	if len(horarios) != len(diasIniciales) {
+		t.Errorf("Se esperaban %d horarios, se obtuvieron %d", len(diasIniciales), len(horarios))
+	}
+
+	// Slug pedido explícitamente y ya ocupado
+	otro := registroValido()
+	otro.Barberia.Slug = resp.Barberia.Slug
+	otro.Admin.Username = "otro" + sufijo
+	otro.Admin.Email = "otro" + sufijo + "@test.com"
+	if rec := registrar(otro); rec.Code != http.StatusConflict {
+		t.Errorf("Slug ocupado: se esperaba 409, pero se obtuvo %d", rec.Code)
+	}
+
+	// Mismo nombre sin slug: se le agrega un sufijo
+	otro.Barberia.Slug = ""
+	otro.Barberia.Nombre = req.Barberia.Nombre
+	rec = registrar(otro)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Mismo nombre: se esperaba 201, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var segunda RegistroResponse
+	json.Unmarshal(rec.Body.Bytes(), &segunda)
+	t.Cleanup(func() { borrarBarberiaTest(conn, segunda.Barberia.ID) })
+	if segunda.Barberia.Slug != resp.Barberia.Slug+"-2" {
+		t.Errorf("Se esperaba el slug con sufijo, se obtuvo %q", segunda.Barberia.Slug)
+	}
+
+	// La configuración permite cambiar el slug, pero no a uno ocupado
+	bh := NewBarberiaHandler(q, conn)
+	r := chi.NewRouter()
+	r.Patch("/b/{slug}", bh.UpdateConfiguracion)
+	b, _ := json.Marshal(map[string]any{"slug": resp.Barberia.Slug})
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/b/"+segunda.Barberia.Slug, bytes.NewReader(b)))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Cambio a slug ocupado: se esperaba 409, pero se obtuvo %d", rec.Code)
+	}
+}
@@ -1,106 +1,378 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	db "agendaFacil/db/sqlc"
+	"agendaFacil/internal/telefono"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
 )
 
 // Estructura para recibir los datos del JSON
 type CreateReservaRequest struct {
-	ServicioID      int32  `json:"servicio_id"`
-	BarberoID       int32  `json:"barbero_id"`  // Puede ser 0 si es "cualquiera"
-	Fecha           string `json:"fecha"`       // YYYY-MM-DD
-	HoraInicio      string `json:"hora_inicio"` // HH:MM
-	ClienteNombre   string `json:"cliente_nombre"`
-	ClienteTelefono string `json:"cliente_telefono"`
+	ServicioID      int32  `json:"servicio_id" validar:"requerido"`
+	BarberoID       int32  `json:"barbero_id" validar:"min=0"`           // 0 u omitido = "cualquiera" (se asigna uno libre)
+	Fecha           string `json:"fecha" validar:"requerido,fecha"`      // YYYY-MM-DD
+	HoraInicio      string `json:"hora_inicio" validar:"requerido,hora"` // HH:MM
+	ClienteNombre   string `json:"cliente_nombre" validar:"requerido,max=100"`
+	ClienteTelefono string `json:"cliente_telefono"`                      // ver validarTelefonoCliente
+	ClienteEmail    string `json:"cliente_email" validar:"max=100,email"` // opcional, para reconocer al cliente
+}
+
+// normalizar recorta el nombre y normaliza el email, que junto con el
+// teléfono identifica al cliente
+func (req *CreateReservaRequest) normalizar() {
+	recortar(&req.ClienteNombre)
+	req.ClienteEmail = normalizarEmail(req.ClienteEmail)
+}
+
+// validarTelefonoCliente normaliza el teléfono a E.164, interpretando los
+// números sin código internacional según el país de la barbería. Por eso no
+// es una regla del tag: depende de la barbería y no sólo del request.
+func validarTelefonoCliente(req *CreateReservaRequest, pais string) erroresCampos {
+	if strings.TrimSpace(req.ClienteTelefono) == "" {
+		req.ClienteTelefono = ""
+		return nil
+	}
+
+	tel, err := telefono.Normalizar(req.ClienteTelefono, pais)
+	switch {
+	case errors.Is(err, telefono.ErrSinCodigoArea):
+		return erroresCampos{"cliente_telefono": "falta el código de área"}
+	case err != nil:
+		return erroresCampos{"cliente_telefono": "número de teléfono inválido"}
+	}
+	req.ClienteTelefono = tel
+	return nil
+}
+
+// ReservaResponse es el turno creado junto con el barbero que lo atiende
+// (útil cuando el cliente eligió "cualquiera" y el servidor lo asignó).
+// Token es la única copia del token de gestión: el cliente lo necesita para
+// ver, cancelar o reprogramar el turno sin cuenta.
+type ReservaResponse struct {
+	db.Turno
+	BarberoNombre string `json:"barbero_nombre"`
+	Token         string `json:"token"`
 }
 
 func (h *BarberiaHandler) PostReservar(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	slug := chi.URLParam(r, "slug")
 
-	// 1. Decodificar el body
+	// 1. Buscar la barbería: el teléfono se valida según su país
+	barberia, err := h.Queries.GetBarberiaBySlug(ctx, slug)
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	// 2. Decodificar y validar el body, con todos los errores juntos
 	var req CreateReservaRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
+	if !leerJSON(w, r, &req, false) {
+		return
+	}
+	errs := validar(&req)
+	for campo, msg := range validarTelefonoCliente(&req, barberia.Pais) {
+		errs[campo] = msg
+	}
+	if len(errs) > 0 {
+		responderCampos(w, r, errs)
 		return
 	}
+	fecha, _ := time.Parse("2006-01-02", req.Fecha)
+	horaInicio, _ := time.Parse("15:04", req.HoraInicio)
 
-	// 2. Validar Fechas y Horas
-	fecha, err := time.Parse("2006-01-02", req.Fecha)
-	if err != nil {
-		http.Error(w, "Formato de fecha incorrecto", http.StatusBadRequest)
+	// 3. Buscar el servicio (para saber duración)
+	servicio, err := h.Queries.GetServicioByID(ctx, req.ServicioID)
+	if err != nil || servicio.BarberiaID != barberia.ID {
+		responderError(w, r, http.StatusNotFound, CodigoServicioNoEncontrado, "Servicio no encontrado")
 		return
 	}
 
-	horaInicio, err := time.Parse("15:04", req.HoraInicio)
-	if err != nil {
-		http.Error(w, "Formato de hora incorrecto", http.StatusBadRequest)
+	// Políticas de la barbería: ventana de reserva y turnos por cliente
+	now := ahora()
+	if err := ventanaReserva(barberia, fecha, horaInicio, now); err != nil {
+		responderErrorAgenda(w, r, err)
+		return
+	}
+	if err := limiteCliente(ctx, h.Queries, barberia, req.ClienteTelefono, now); err != nil {
+		responderErrorAgenda(w, r, err)
 		return
 	}
 
-	// 3. Buscar Barbería y Servicio (para saber duración)
-	barberia, err := h.Queries.GetBarberiaBySlug(ctx, slug)
+	// Token para que el cliente gestione su turno; sólo se guarda el hash
+	token, tokenHash, err := nuevoToken()
 	if err != nil {
-		http.Error(w, "Barbería no encontrada", http.StatusNotFound)
+		errorInterno(w, r, err, "Error al guardar reserva")
 		return
 	}
 
-	servicio, err := h.Queries.GetServicioByID(ctx, req.ServicioID)
+	// 4. Elegir barbero y guardar de forma atómica
+	turno, u, err := h.agendar(ctx, barberia, servicio, req.BarberoID, fecha, horaInicio, 0,
+		func(qtx *db.Queries, u ubicacion) (db.Turno, error) {
+			if err := limiteClienteTx(ctx, qtx, barberia, req.ClienteTelefono, now); err != nil {
+				return db.Turno{}, err
+			}
+			clienteID, err := resolverCliente(ctx, qtx, barberia.ID, req.ClienteNombre, req.ClienteTelefono, req.ClienteEmail)
+			if err != nil {
+				return db.Turno{}, err
+			}
+			return qtx.CreateTurno(ctx, db.CreateTurnoParams{
+				BarberiaID:      barberia.ID,
+				BarberoID:       u.Barbero.ID,
+				ServicioID:      req.ServicioID,
+				Fecha:           fecha,
+				HoraInicio:      horaInicio, // SQLC maneja time.Time para columnas TIME
+				HoraFin:         u.HoraFin,
+				ClienteNombre:   req.ClienteNombre,
+				ClienteTelefono: toNullString(req.ClienteTelefono), // Helper para convertir string a sql.NullString
+				Estado:          toNullString(EstadoPendiente),
+				OcupadoInicio:   u.OcupadoInicio,
+				OcupadoFin:      u.OcupadoFin,
+				TokenHash:       toNullString(tokenHash),
+				ClienteID:       clienteID,
+				Precio:          toNullString(u.Precio),
+			})
+		})
 	if err != nil {
-		http.Error(w, "Servicio no encontrado", http.StatusNotFound)
+		responderErrorAgenda(w, r, err)
 		return
 	}
 
-	// Calcular Hora Fin
-	horaFin := horaInicio.Add(time.Duration(servicio.DuracionMinutos) * time.Minute)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ReservaResponse{Turno: turno, BarberoNombre: u.Barbero.Nombre, Token: token})
+}
+
+// ubicacion es dónde queda un turno: el barbero asignado y el tiempo que ocupa
+type ubicacion struct {
+	Barbero       db.ListBarberosPorCargaRow
+	HoraFin       time.Time
+	OcupadoInicio time.Time
+	OcupadoFin    time.Time
+	Precio        string // el del servicio con ese barbero
+}
+
+var (
+	errTurnoOcupado        = errors.New("turno ocupado")
+	errBarberoNoEncontrado = errors.New("barbero no encontrado")
+	errFueraDeHorario      = errors.New("fuera del horario de atención")
+)
+
+// errorBloqueo indica que el horario cae en un feriado, cierre o licencia
+type errorBloqueo struct {
+	Motivo string
+}
+
+func (e errorBloqueo) Error() string {
+	return "horario bloqueado: " + e.Motivo
+}
+
+// agendar aplica las reglas de una reserva (horario futuro, barbero pedido o el
+// menos cargado entre los que hacen el servicio, horario de atención, bloqueos
+// y superposición) y guarda el turno con guardar dentro de la transacción que
+// tiene tomado al barbero. excluirID es el turno que se está reprogramando,
+// para que no choque consigo mismo (0 si es nuevo).
+func (h *BarberiaHandler) agendar(ctx context.Context, barberia db.Barberia, servicio db.Servicio, barberoID int32, fecha, horaInicio time.Time, excluirID int32, guardar func(qtx *db.Queries, u ubicacion) (db.Turno, error)) (db.Turno, ubicacion, error) {
+	// Fecha y hora son hora local de la barbería: no se agenda en el pasado
+	if _, err := momentoReserva(barberia, fecha, horaInicio, ahora()); err != nil {
+		return db.Turno{}, ubicacion{}, err
+	}
+
+	// Candidatos: el barbero pedido o, si es 0, todos por orden de carga
+	candidatos, err := h.candidatosReserva(ctx, barberia.ID, fecha, barberoID)
+	if err != nil {
+		return db.Turno{}, ubicacion{}, err
+	}
+	if len(candidatos) == 0 {
+		return db.Turno{}, ubicacion{}, errBarberoNoEncontrado
+	}
+
+	// Sólo sirven los barberos que hacen el servicio, cada uno con su duración
+	ids := make([]int32, 0, len(candidatos))
+	for _, c := range candidatos {
+		ids = append(ids, c.ID)
+	}
+	condiciones, err := condicionesServicio(ctx, h.Queries, servicio, ids)
+	if err != nil {
+		return db.Turno{}, ubicacion{}, err
+	}
+	habilitados := candidatos[:0]
+	for _, c := range candidatos {
+		if _, ok := condiciones[c.ID]; ok {
+			habilitados = append(habilitados, c)
+		}
+	}
+	if len(habilitados) == 0 {
+		return db.Turno{}, ubicacion{}, errBarberoNoHabilitado
+	}
+	candidatos = habilitados
+
+	// Hora fin según la duración de cada barbero
+	finDe := func(id int32) time.Time {
+		return horaInicio.Add(time.Duration(condiciones[id].Duracion) * time.Minute)
+	}
+	antes, despues := buffersServicio(barberia, servicio)
 
-	// 4. Validar que no haya overlap (superposición)
-	// Usamos la query que ya tenés en SQLC: HasTurnoOverlap
-	overlap, err := h.Queries.HasTurnoOverlap(ctx, db.HasTurnoOverlapParams{
+	// Sólo sirven los barberos que atienden en ese horario ese día
+	horarios, err := h.Queries.ListHorarios(ctx, barberia.ID)
+	if err != nil {
+		return db.Turno{}, ubicacion{}, err
+	}
+	enHorario := candidatos[:0]
+	for _, c := range candidatos {
+		if dentroDeHorario(rangosDelDia(barberia, horarios, c.ID, fecha.Weekday()), horaInicio, finDe(c.ID)) {
+			enHorario = append(enHorario, c)
+		}
+	}
+	if len(enHorario) == 0 {
+		return db.Turno{}, ubicacion{}, errFueraDeHorario
+	}
+	candidatos = enHorario
+
+	// Feriados, cierres y licencias
+	bloqueos, err := h.Queries.ListBloqueosByFecha(ctx, db.ListBloqueosByFechaParams{
 		BarberiaID: barberia.ID,
-		BarberoID:  req.BarberoID, // Ojo: asegúrate de enviar el barbero correcto
 		Fecha:      fecha,
-		HoraInicio: horaInicio,
-		HoraFin:    horaFin,
 	})
+	if err != nil {
+		return db.Turno{}, ubicacion{}, err
+	}
+	var bloqueo db.Bloqueo
+	sinBloqueo := candidatos[:0]
+	for _, c := range candidatos {
+		if b, bloqueado := bloqueoQueChoca(bloqueos, c.ID, fecha, horaInicio, finDe(c.ID)); bloqueado {
+			bloqueo = b
+			continue
+		}
+		sinBloqueo = append(sinBloqueo, c)
+	}
+	if len(sinBloqueo) == 0 {
+		return db.Turno{}, ubicacion{}, errorBloqueo{Motivo: bloqueo.Motivo}
+	}
+
+	// Verificar overlap y guardar de forma atómica (primer candidato libre)
+	var turno db.Turno
+	var u ubicacion
+	for _, c := range sinBloqueo {
+		horaFin := finDe(c.ID)
+		ocupadoInicio, ocupadoFin := rangoOcupado(horaInicio, horaFin, antes, despues)
+		u = ubicacion{Barbero: c, HoraFin: horaFin, OcupadoInicio: ocupadoInicio, OcupadoFin: ocupadoFin, Precio: condiciones[c.ID].Precio}
+		turno, err = h.guardarTurno(ctx, barberia.ID, fecha, u, excluirID, guardar)
+		if !errors.Is(err, errTurnoOcupado) {
+			break
+		}
+	}
+	return turno, u, err
+}
 
+// responderErrorAgenda traduce los errores de agendar a la respuesta HTTP
+func responderErrorAgenda(w http.ResponseWriter, r *http.Request, err error) {
+	var bloqueo errorBloqueo
+	var politica errorPolitica
+	switch {
+	case errors.Is(err, errTurnoOcupado):
+		responderError(w, r, http.StatusConflict, CodigoSlotOcupado, "El turno seleccionado ya no está disponible")
+	case errors.Is(err, errBarberoNoEncontrado):
+		responderError(w, r, http.StatusNotFound, CodigoBarberoNoEncontrado, "Barbero no encontrado")
+	case errors.Is(err, errBarberoNoHabilitado):
+		responderError(w, r, http.StatusBadRequest, CodigoServicioNoHabilitado, "El barbero no realiza ese servicio")
+	case errors.Is(err, errHoraInexistente):
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "Ese horario no existe ese día por el cambio de horario")
+	case errors.Is(err, errFueraDeHorario):
+		responderError(w, r, http.StatusConflict, CodigoFueraDeHorario, "El horario seleccionado está fuera del horario de atención")
+	case errors.As(err, &politica):
+		responderPolitica(w, r, politica)
+	case errors.As(err, &bloqueo):
+		responderError(w, r, http.StatusConflict, CodigoSlotBloqueado, "Horario no disponible: "+bloqueo.Motivo)
+	default:
+		errorInterno(w, r, err, "Error al guardar reserva")
+	}
+}
+
+// candidatosReserva devuelve los barberos a intentar para una reserva. Con
+// barberoID != 0 es sólo ese barbero (si pertenece a la barbería); con 0 son
+// todos los activos, del menos cargado en el día al más cargado.
+func (h *BarberiaHandler) candidatosReserva(ctx context.Context, barberiaID int32, fecha time.Time, barberoID int32) ([]db.ListBarberosPorCargaRow, error) {
+	barberos, err := h.Queries.ListBarberosPorCarga(ctx, db.ListBarberosPorCargaParams{
+		BarberiaID: barberiaID,
+		Fecha:      fecha,
+	})
+	if err != nil || barberoID == 0 {
+		return barberos, err
+	}
+
+	for _, b := range barberos {
+		if b.ID == barberoID {
+			return []db.ListBarberosPorCargaRow{b}, nil
+		}
+	}
+	return nil, nil
+}
+
+// guardarTurno verifica la superposición y guarda el turno dentro de una misma
+// transacción. La fila del barbero se bloquea (SELECT ... FOR UPDATE) para que
+// dos reservas simultáneas sobre el mismo barbero se serialicen: la segunda
+// espera a que la primera confirme y recién entonces ve el turno ya creado.
+func (h *BarberiaHandler) guardarTurno(ctx context.Context, barberiaID int32, fecha time.Time, u ubicacion, excluirID int32, guardar func(qtx *db.Queries, u ubicacion) (db.Turno, error)) (db.Turno, error) {
+	tx, err := h.DB.BeginTx(ctx, nil)
 	if err != nil {
-		http.Error(w, "Error verificando disponibilidad", http.StatusInternalServerError)
-		return
+		return db.Turno{}, err
 	}
+	defer tx.Rollback()
 
-	if overlap {
-		http.Error(w, "El turno seleccionado ya no está disponible", http.StatusConflict) // 409 Conflict
-		return
+	qtx := h.Queries.WithTx(tx)
+
+	_, err = qtx.LockBarbero(ctx, db.LockBarberoParams{
+		ID:         u.Barbero.ID,
+		BarberiaID: barberiaID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Turno{}, errBarberoNoEncontrado
+	}
+	if err != nil {
+		return db.Turno{}, err
 	}
 
-	// 5. Guardar en DB
-	turno, err := h.Queries.CreateTurno(ctx, db.CreateTurnoParams{
-		BarberiaID:      barberia.ID,
-		BarberoID:       req.BarberoID, // Ojo: Si es 0 (cualquiera), tu DB podría fallar si la FK es not null.
-		ServicioID:      req.ServicioID,
-		Fecha:           fecha,
-		HoraInicio:      horaInicio, // SQLC maneja time.Time para columnas TIME
-		HoraFin:         horaFin,
-		ClienteNombre:   req.ClienteNombre,
-		ClienteTelefono: toNullString(req.ClienteTelefono), // Helper para convertir string a sql.NullString
-		Estado:          toNullString("pendiente"),
+	overlap, err := qtx.HasTurnoOverlap(ctx, db.HasTurnoOverlapParams{
+		BarberiaID:    barberiaID,
+		BarberoID:     u.Barbero.ID,
+		Fecha:         fecha,
+		OcupadoInicio: u.OcupadoInicio,
+		OcupadoFin:    u.OcupadoFin,
+		ExcluirID:     excluirID,
 	})
+	if err != nil {
+		return db.Turno{}, err
+	}
+	if overlap {
+		return db.Turno{}, errTurnoOcupado
+	}
 
+	turno, err := guardar(qtx, u)
+	if esViolacionExclusion(err) {
+		// La constraint turnos_sin_superposicion es la última barrera
+		return db.Turno{}, errTurnoOcupado
+	}
 	if err != nil {
-		http.Error(w, "Error al guardar reserva: "+err.Error(), http.StatusInternalServerError)
-		return
+		return db.Turno{}, err
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(turno)
+	return turno, tx.Commit()
+}
+
+// esViolacionExclusion indica si el error es una violación de EXCLUDE (23P01)
+func esViolacionExclusion(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23P01"
 }
 
 // Helper simple para SQLC
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+// abrirDBTest conecta a la base indicada en TEST_DATABASE_URL (con el schema
+// de db/schema ya aplicado). Si la variable no está definida el test se saltea.
+func abrirDBTest(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no definida, salteando test de integración")
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("Error abriendo DB: %v", err)
+	}
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("No se pudo conectar a la DB: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// fixtureBarberia crea una barbería aislada (slug único) con un barbero y un
+// servicio de 30 minutos, y la borra al terminar el test.
+func fixtureBarberia(t *testing.T, conn *sql.DB) (db.Barberia, int32, db.Servicio) {
+	t.Helper()
+
+	ctx := context.Background()
+	q := db.New(conn)
+	sufijo := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	barberia, err := q.CreateBarberia(ctx, db.CreateBarberiaParams{
+		Nombre:       "Barbería Test " + sufijo,
+		Slug:         "t" + sufijo,
+		HoraApertura: time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+		HoraCierre:   time.Date(0, 1, 1, 18, 0, 0, 0, time.UTC),
+		ZonaHoraria:  zonaPorDefecto,
+	})
+	if err != nil {
+		t.Fatalf("Error creando barbería: %v", err)
+	}
+
+	barberoID := crearBarberoTest(t, conn, barberia.ID, "Test")
+
+	servicio, err := q.CreateServicio(ctx, db.CreateServicioParams{
+		BarberiaID:      barberia.ID,
+		Nombre:          "Corte",
+		DuracionMinutos: 30,
+		Precio:          "10.00",
+	})
+	if err != nil {
+		t.Fatalf("Error creando servicio: %v", err)
+	}
+
+	t.Cleanup(func() { borrarBarberiaTest(conn, barberia.ID) })
+
+	return barberia, barberoID, servicio
+}
+
+// diaReservaTest es la fecha (YYYY-MM-DD) de los turnos de los tests: el
+// próximo jueves con al menos tres días de margen, así nunca queda en el
+// pasado ni dentro de la anticipación mínima.
+func diaReservaTest() string {
+	dia := time.Now().AddDate(0, 0, 3)
+	for dia.Weekday() != time.Thursday {
+		dia = dia.AddDate(0, 0, 1)
+	}
+	return dia.Format("2006-01-02")
+}
+
+// borrarBarberiaTest borra una barbería de test con todo lo que cuelga de ella
+func borrarBarberiaTest(conn *sql.DB, id int32) {
+	conn.Exec("DELETE FROM turnos WHERE barberia_id = $1", id)
+	conn.Exec("DELETE FROM clientes WHERE barberia_id = $1", id)
+	conn.Exec("DELETE FROM horarios WHERE barberia_id = $1", id)
+	conn.Exec("DELETE FROM bloqueos WHERE barberia_id = $1", id)
+	conn.Exec("DELETE FROM barbero_servicios WHERE servicio_id IN (SELECT id FROM servicios WHERE barberia_id = $1)", id)
+	conn.Exec("DELETE FROM servicios WHERE barberia_id = $1", id)
+	conn.Exec("DELETE FROM password_resets WHERE usuario_id IN (SELECT id FROM usuarios WHERE barberia_id = $1)", id)
+	conn.Exec("DELETE FROM refresh_tokens WHERE usuario_id IN (SELECT id FROM usuarios WHERE barberia_id = $1)", id)
+	conn.Exec("DELETE FROM usuarios WHERE barberia_id = $1", id)
+	conn.Exec("DELETE FROM barberias WHERE id = $1", id)
+}
+
+// crearBarberoTest agrega un barbero activo a la barbería indicada.
+func crearBarberoTest(t *testing.T, conn *sql.DB, barberiaID int32, nombre string) int32 {
+	t.Helper()
+
+	sufijo := fmt.Sprintf("%d", time.Now().UnixNano())
+	barbero, err := db.New(conn).CreateUsuario(context.Background(), db.CreateUsuarioParams{
+		BarberiaID:   barberiaID,
+		Nombre:       nombre,
+		Apellido:     "Barbero",
+		Username:     "barbero" + sufijo,
+		Email:        "barbero" + sufijo + "@test.com",
+		PasswordHash: "x",
+		Rol:          "barbero",
+	})
+	if err != nil {
+		t.Fatalf("Error creando barbero: %v", err)
+	}
+	return barbero.ID
+}
+
+// reservarTest hace un POST /b/{slug}/reservar contra el handler y devuelve la respuesta.
+func reservarTest(t *testing.T, h *BarberiaHandler, slug string, req CreateReservaRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	return pedirTest(t, func(r chi.Router) {
+		r.Post("/b/{slug}/reservar", h.PostReservar)
+	}, http.MethodPost, "/b/"+slug+"/reservar", req)
+}
+
+// pedirTest monta en un router nuevo las rutas que registra rutas y le hace el
+// request con body codificado como JSON. Las rutas van como en rutasAPI.
+func pedirTest(t *testing.T, rutas func(r chi.Router), metodo, ruta string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	rutas(r)
+
+	b, _ := json.Marshal(body)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(metodo, ruta, bytes.NewReader(b)))
+	return rec
+}
+
+// TestPostReservar_Concurrente dispara muchas reservas en paralelo sobre el
+// mismo barbero y horario: exactamente una debe ganar y el resto recibir 409.
+func TestPostReservar_Concurrente(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+
+	h := NewBarberiaHandler(db.New(conn), conn)
+	r := chi.NewRouter()
+	r.Post("/b/{slug}/reservar", h.PostReservar)
+
+	body, _ := json.Marshal(CreateReservaRequest{
+		ServicioID:      servicio.ID,
+		BarberoID:       barberoID,
+		Fecha:           diaReservaTest(),
+		HoraInicio:      "10:00",
+		ClienteNombre:   "Pedro",
+		ClienteTelefono: "11 4444-5555",
+	})
+
+	const intentos = 20
+	codigos := make(chan int, intentos)
+	inicio := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for i := 0; i < intentos; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-inicio
+			req := httptest.NewRequest(http.MethodPost, "/b/"+barberia.Slug+"/reservar", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			codigos <- rec.Code
+		}()
+	}
+	close(inicio)
+	wg.Wait()
+	close(codigos)
+
+	creados, conflictos := 0, 0
+	for c := range codigos {
+		switch c {
+		case http.StatusCreated:
+			creados++
+		case http.StatusConflict:
+			conflictos++
+		default:
+			t.Errorf("Código inesperado: %d", c)
+		}
+	}
+
+	if creados != 1 {
+		t.Errorf("Se esperaba exactamente 1 reserva creada, pero se obtuvieron %d", creados)
+	}
+	if conflictos != intentos-1 {
+		t.Errorf("Se esperaban %d conflictos, pero se obtuvieron %d", intentos-1, conflictos)
+	}
+}
+
+// TestEsViolacionExclusion tests que se reconozca el código 23P01 de Postgres
+func TestEsViolacionExclusion(t *testing.T) {
+	if !esViolacionExclusion(&pq.Error{Code: "23P01"}) {
+		t.Error("23P01 debería reconocerse como violación de exclusión")
+	}
+	if esViolacionExclusion(&pq.Error{Code: "23505"}) {
+		t.Error("23505 no es una violación de exclusión")
+	}
+	if esViolacionExclusion(nil) {
+		t.Error("nil no es una violación de exclusión")
+	}
+}
+
+// TestPostReservar_CualquierBarbero verifica que con barbero_id = 0 se asigne
+// un barbero libre, repartiendo entre los menos cargados.
+func TestPostReservar_CualquierBarbero(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, primero, servicio := fixtureBarberia(t, conn)
+	segundo := crearBarberoTest(t, conn, barberia.ID, "Segundo")
+
+	h := NewBarberiaHandler(db.New(conn), conn)
+	req := CreateReservaRequest{
+		ServicioID:    servicio.ID,
+		Fecha:         diaReservaTest(),
+		HoraInicio:    "11:00",
+		ClienteNombre: "Pedro",
+	}
+
+	asignados := map[int32]bool{}
+	for i := 0; i < 2; i++ {
+		rec := reservarTest(t, h, barberia.Slug, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Reserva %d: se esperaba 201, pero se obtuvo %d (%s)", i, rec.Code, rec.Body.String())
+		}
+		var resp ReservaResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if resp.BarberoNombre == "" {
+			t.Error("La respuesta debería incluir el nombre del barbero asignado")
+		}
+		asignados[resp.BarberoID] = true
+	}
+
+	if !asignados[primero] || !asignados[segundo] {
+		t.Errorf("Cada barbero debería haber recibido un turno, asignados: %v", asignados)
+	}
+
+	// Ya no queda ningún barbero libre en ese horario
+	if rec := reservarTest(t, h, barberia.Slug, req); rec.Code != http.StatusConflict {
+		t.Errorf("Se esperaba 409 sin barberos libres, pero se obtuvo %d", rec.Code)
+	}
+}
+
+// gestionTest llama a los endpoints de autogestión con el token del cliente.
+func gestionTest(t *testing.T, h *BarberiaHandler, metodo, ruta string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	return pedirTest(t, func(r chi.Router) {
+		r.Get("/b/{slug}/reservas/{token}", h.GetReserva)
+		r.Post("/b/{slug}/reservas/{token}/cancelar", h.CancelarReserva)
+		r.Post("/b/{slug}/reservas/{token}/reprogramar", h.ReprogramarReserva)
+	}, metodo, ruta, body)
+}
+
+// TestAutogestion_ReprogramarYCancelar cubre el ciclo del cliente: reprogramar
+// a un horario libre, chocar contra otro turno y finalmente cancelar.
+func TestAutogestion_ReprogramarYCancelar(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	h := NewBarberiaHandler(db.New(conn), conn)
+
+	reservar := func(hora string) ReservaResponse {
+		rec := reservarTest(t, h, barberia.Slug, CreateReservaRequest{
+			ServicioID:    servicio.ID,
+			BarberoID:     barberoID,
+			Fecha:         diaReservaTest(),
+			HoraInicio:    hora,
+			ClienteNombre: "Pedro",
+		})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Se esperaba 201, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+		}
+		var resp ReservaResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp
+	}
+	propio := reservar("10:00")
+	reservar("12:00")
+
+	if propio.Token == "" {
+		t.Fatal("La reserva debería devolver un token de gestión")
+	}
+	base := "/b/" + barberia.Slug + "/reservas/" + propio.Token
+
+	if rec := gestionTest(t, h, http.MethodGet, "/b/"+barberia.Slug+"/reservas/otro", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Un token inválido debería dar 404, se obtuvo %d", rec.Code)
+	}
+
+	// Al mismo horario (consigo mismo) no hay choque
+	rec := gestionTest(t, h, http.MethodPost, base+"/reprogramar", ReprogramarRequest{Fecha: diaReservaTest(), HoraInicio: "10:15"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Reprogramar: se esperaba 200, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	// Contra el turno de otro cliente sí
+	rec = gestionTest(t, h, http.MethodPost, base+"/reprogramar", ReprogramarRequest{Fecha: diaReservaTest(), HoraInicio: "12:00"})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Reprogramar encima de otro turno: se esperaba 409, pero se obtuvo %d", rec.Code)
+	}
+
+	rec = gestionTest(t, h, http.MethodPost, base+"/cancelar", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Cancelar: se esperaba 200, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+	var cancelado TurnoClienteResponse
+	json.NewDecoder(rec.Body).Decode(&cancelado)
+	if cancelado.Estado != EstadoCancelado || cancelado.HoraInicio != "10:15" {
+		t.Errorf("Se esperaba el turno de las 10:15 cancelado, se obtuvo %+v", cancelado)
+	}
+
+	if rec := gestionTest(t, h, http.MethodPost, base+"/cancelar", nil); rec.Code != http.StatusConflict {
+		t.Errorf("Cancelar dos veces: se esperaba 409, pero se obtuvo %d", rec.Code)
+	}
+}
+
+// TestAutogestion_ServicioDadoDeBaja tests que dar de baja un servicio no
+// rompa la autogestión de los turnos que ya estaban reservados
+func TestAutogestion_ServicioDadoDeBaja(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	q := db.New(conn)
+	h := NewBarberiaHandler(q, conn)
+
+	rec := reservarTest(t, h, barberia.Slug, CreateReservaRequest{
+		ServicioID:    servicio.ID,
+		BarberoID:     barberoID,
+		Fecha:         diaReservaTest(),
+		HoraInicio:    "10:00",
+		ClienteNombre: "Pedro",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Se esperaba 201, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+	var reserva ReservaResponse
+	json.NewDecoder(rec.Body).Decode(&reserva)
+
+	n, err := q.DeactivateServicio(context.Background(), db.DeactivateServicioParams{ID: servicio.ID, BarberiaID: barberia.ID})
+	if err != nil || n != 1 {
+		t.Fatalf("Error dando de baja el servicio: %v", err)
+	}
+	base := "/b/" + barberia.Slug + "/reservas/" + reserva.Token
+
+	if rec := gestionTest(t, h, http.MethodGet, base, nil); rec.Code != http.StatusOK {
+		t.Errorf("Ver el turno: se esperaba 200, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+	rec = gestionTest(t, h, http.MethodPost, base+"/reprogramar", ReprogramarRequest{Fecha: diaReservaTest(), HoraInicio: "11:00"})
+	if rec.Code != http.StatusOK {
+		t.Errorf("Reprogramar: se esperaba 200, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	rec = gestionTest(t, h, http.MethodPost, base+"/cancelar", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Cancelar: se esperaba 200, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+	var cancelado TurnoClienteResponse
+	json.NewDecoder(rec.Body).Decode(&cancelado)
+	if cancelado.Estado != EstadoCancelado || cancelado.ServicioNombre != servicio.Nombre {
+		t.Errorf("Se esperaba el turno cancelado con su servicio, se obtuvo %+v", cancelado)
+	}
+}
+
+// TestServicioDeOtraBarberia tests que no se pueda reservar ni consultar
+// disponibilidad en una barbería con el servicio de otra
+func TestServicioDeOtraBarberia(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, _ := fixtureBarberia(t, conn)
+	_, _, ajeno := fixtureBarberia(t, conn)
+	h := NewBarberiaHandler(db.New(conn), conn)
+
+	rec := reservarTest(t, h, barberia.Slug, CreateReservaRequest{
+		ServicioID:    ajeno.ID,
+		BarberoID:     barberoID,
+		Fecha:         diaReservaTest(),
+		HoraInicio:    "10:00",
+		ClienteNombre: "Pedro",
+	})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Reservar: se esperaba 404, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	r := chi.NewRouter()
+	r.Get("/b/{slug}/disponibilidad", h.GetDisponibilidad)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/b/%s/disponibilidad?fecha=%s&servicio_id=%d", barberia.Slug, diaReservaTest(), ajeno.ID), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Disponibilidad: se esperaba 404, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+}
+
+// TestAutogestion_ReprogramarPrecio tests que al reprogramar con otro barbero
+// el turno tome su precio y su duración
+func TestAutogestion_ReprogramarPrecio(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	otroID := crearBarberoTest(t, conn, barberia.ID, "Otro")
+	q := db.New(conn)
+	h := NewBarberiaHandler(q, conn)
+	ctx := context.Background()
+
+	_, err := q.CreateBarberoServicio(ctx, db.CreateBarberoServicioParams{
+		BarberoID:       otroID,
+		ServicioID:      servicio.ID,
+		DuracionMinutos: sql.NullInt32{Int32: 45, Valid: true},
+		Precio:          sql.NullString{String: "15.00", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Error asignando servicio: %v", err)
+	}
+
+	rec := reservarTest(t, h, barberia.Slug, CreateReservaRequest{
+		ServicioID:    servicio.ID,
+		BarberoID:     barberoID,
+		Fecha:         diaReservaTest(),
+		HoraInicio:    "10:00",
+		ClienteNombre: "Pedro",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Se esperaba 201, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+	var reserva ReservaResponse
+	json.NewDecoder(rec.Body).Decode(&reserva)
+	if reserva.Precio.String != "10.00" {
+		t.Fatalf("Se esperaba el precio del servicio, se obtuvo %q", reserva.Precio.String)
+	}
+
+	rec = gestionTest(t, h, http.MethodPost, "/b/"+barberia.Slug+"/reservas/"+reserva.Token+"/reprogramar",
+		ReprogramarRequest{Fecha: diaReservaTest(), HoraInicio: "11:00", BarberoID: &otroID})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Reprogramar: se esperaba 200, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	turno, err := q.GetTurnoByID(ctx, db.GetTurnoByIDParams{ID: reserva.ID, BarberiaID: barberia.ID})
+	if err != nil {
+		t.Fatalf("Error obteniendo turno: %v", err)
+	}
+	if turno.BarberoID != otroID || turno.Precio.String != "15.00" || turno.HoraFin.Format("15:04") != "11:45" {
+		t.Errorf("Se esperaba el turno del otro barbero a 15.00 hasta 11:45, se obtuvo %d a %q hasta %s", turno.BarberoID, turno.Precio.String, turno.HoraFin.Format("15:04"))
+	}
+}
+
+// TestAgendaPublica_SinDatosDelCliente tests que la agenda pública muestre
+// sólo los horarios ocupados, sin nombre ni teléfono del cliente
+func TestAgendaPublica_SinDatosDelCliente(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	h := NewBarberiaHandler(db.New(conn), conn)
+
+	rec := reservarTest(t, h, barberia.Slug, CreateReservaRequest{
+		ServicioID:      servicio.ID,
+		BarberoID:       barberoID,
+		Fecha:           diaReservaTest(),
+		HoraInicio:      "10:00",
+		ClienteNombre:   "Pedro Secreto",
+		ClienteTelefono: "11 4444-5555",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Se esperaba 201, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	r := chi.NewRouter()
+	r.Get("/b/{slug}/agenda", h.GetAgendaPublic)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/b/"+barberia.Slug+"/agenda?fecha="+diaReservaTest(), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Se esperaba 200, pero se obtuvo %d (%s)", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); strings.Contains(body, "Secreto") || strings.Contains(body, "4444") {
+		t.Errorf("La agenda pública no debería incluir datos del cliente: %s", body)
+	}
+
+	var agenda []OcupadoPublico
+	json.Unmarshal(rec.Body.Bytes(), &agenda)
+	if len(agenda) != 1 || agenda[0].BarberoID != barberoID || agenda[0].Fin != "10:30" {
+		t.Errorf("Se esperaba un rango ocupado hasta las 10:30, se obtuvo %+v", agenda)
+	}
+}
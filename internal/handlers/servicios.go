@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"regexp"
+	"strconv"
 
 	db "agendaFacil/db/sqlc"
 
@@ -11,10 +15,11 @@ import (
 
 type ServiciosHandler struct {
 	Queries *db.Queries
+	DB      *sql.DB
 }
 
-func NewServiciosHandler(q *db.Queries) *ServiciosHandler {
-	return &ServiciosHandler{Queries: q}
+func NewServiciosHandler(q *db.Queries, conn *sql.DB) *ServiciosHandler {
+	return &ServiciosHandler{Queries: q, DB: conn}
 }
 
 func (h *ServiciosHandler) ListServiciosActivos(w http.ResponseWriter, r *http.Request) {
@@ -23,14 +28,14 @@ func (h *ServiciosHandler) ListServiciosActivos(w http.ResponseWriter, r *http.R
 	//Buscar barbería
 	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), slug)
 	if err != nil {
-		http.Error(w, "barbería no encontrada", http.StatusNotFound)
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "barbería no encontrada")
 		return
 	}
 
 	// Obtener servicios activos
 	servicios, err := h.Queries.ListServicios(r.Context(), barberia.ID)
 	if err != nil {
-		http.Error(w, "error obteniendo servicios", http.StatusInternalServerError)
+		errorInterno(w, r, err, "error obteniendo servicios")
 		return
 	}
 
@@ -40,9 +45,15 @@ func (h *ServiciosHandler) ListServiciosActivos(w http.ResponseWriter, r *http.R
 }
 
 type CreateServicioRequest struct {
-	Nombre          string `json:"nombre"`
-	DuracionMinutos int32  `json:"duracion_minutos"`
-	Precio          string `json:"precio"` // Usamos string para Decimal/Numeric
+	Nombre               string `json:"nombre" validar:"requerido,max=100"`
+	DuracionMinutos      int32  `json:"duracion_minutos" validar:"min=5,max=480"`
+	Precio               string `json:"precio" validar:"requerido,precio"`              // Usamos string para Decimal/Numeric
+	BufferAntesMinutos   *int32 `json:"buffer_antes_minutos" validar:"min=0,max=120"`   // Opcional: si falta se usa el de la barbería
+	BufferDespuesMinutos *int32 `json:"buffer_despues_minutos" validar:"min=0,max=120"` // Opcional: si falta se usa el de la barbería
+}
+
+func (req *CreateServicioRequest) normalizar() {
+	recortar(&req.Nombre, &req.Precio)
 }
 
 func (h *ServiciosHandler) CreateServicio(w http.ResponseWriter, r *http.Request) {
@@ -51,30 +62,277 @@ func (h *ServiciosHandler) CreateServicio(w http.ResponseWriter, r *http.Request
 	// 1. Validar Barbería
 	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), slug)
 	if err != nil {
-		http.Error(w, "Barbería no encontrada", http.StatusNotFound)
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
 		return
 	}
 
 	// 2. Decodificar JSON
 	var req CreateServicioRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
+	if !decodificarJSON(w, r, &req) {
 		return
 	}
 
 	// 3. Crear en DB
 	nuevoServicio, err := h.Queries.CreateServicio(r.Context(), db.CreateServicioParams{
-		BarberiaID:      barberia.ID,
-		Nombre:          req.Nombre,
-		DuracionMinutos: req.DuracionMinutos,
-		Precio:          req.Precio,
+		BarberiaID:           barberia.ID,
+		Nombre:               req.Nombre,
+		DuracionMinutos:      req.DuracionMinutos,
+		Precio:               req.Precio,
+		BufferAntesMinutos:   toNullInt32(req.BufferAntesMinutos),
+		BufferDespuesMinutos: toNullInt32(req.BufferDespuesMinutos),
 	})
 
 	if err != nil {
-		http.Error(w, "Error creando servicio", http.StatusInternalServerError)
+		errorInterno(w, r, err, "Error creando servicio")
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(nuevoServicio)
 }
+
+// precioValido: hasta 8 enteros y 2 decimales, como DECIMAL(10,2)
+var precioValido = regexp.MustCompile(`^\d{1,8}(\.\d{1,2})?$`)
+
+// PatchServicioRequest admite cambios parciales: los campos omitidos no se
+// tocan. Para volver a los buffers de la barbería se usa PUT sin buffers.
+type PatchServicioRequest struct {
+	Nombre               *string `json:"nombre"`
+	DuracionMinutos      *int32  `json:"duracion_minutos"`
+	Precio               *string `json:"precio"`
+	BufferAntesMinutos   *int32  `json:"buffer_antes_minutos"`
+	BufferDespuesMinutos *int32  `json:"buffer_despues_minutos"`
+	Orden                *int32  `json:"orden" validar:"min=0"`
+	Activo               *bool   `json:"activo"`      // true reactiva un servicio dado de baja
+	Restringido          *bool   `json:"restringido"` // false lo abre a todos los barberos
+}
+
+// UpdateServicio reemplaza los datos del servicio. Orden, estado y
+// restricción se mantienen.
+func (h *ServiciosHandler) UpdateServicio(w http.ResponseWriter, r *http.Request) {
+	barberia, actual, ok := h.servicioDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateServicioRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	h.guardarServicio(w, r, barberia.ID, actual, req)
+}
+
+// PatchServicio cambia sólo los campos presentes en el body
+func (h *ServiciosHandler) PatchServicio(w http.ResponseWriter, r *http.Request) {
+	barberia, actual, ok := h.servicioDeURL(w, r)
+	if !ok {
+		return
+	}
+
+	var patch PatchServicioRequest
+	if !decodificarJSON(w, r, &patch) {
+		return
+	}
+
+	// Se parte de los valores actuales y se pisan los que vinieron
+	req := CreateServicioRequest{
+		Nombre:          actual.Nombre,
+		DuracionMinutos: actual.DuracionMinutos,
+		Precio:          actual.Precio,
+	}
+	if actual.BufferAntesMinutos.Valid {
+		req.BufferAntesMinutos = &actual.BufferAntesMinutos.Int32
+	}
+	if actual.BufferDespuesMinutos.Valid {
+		req.BufferDespuesMinutos = &actual.BufferDespuesMinutos.Int32
+	}
+	if patch.Nombre != nil {
+		req.Nombre = *patch.Nombre
+	}
+	if patch.DuracionMinutos != nil {
+		req.DuracionMinutos = *patch.DuracionMinutos
+	}
+	if patch.Precio != nil {
+		req.Precio = *patch.Precio
+	}
+	if patch.BufferAntesMinutos != nil {
+		req.BufferAntesMinutos = patch.BufferAntesMinutos
+	}
+	if patch.BufferDespuesMinutos != nil {
+		req.BufferDespuesMinutos = patch.BufferDespuesMinutos
+	}
+	// El resultado tiene que cumplir las mismas reglas que un servicio nuevo
+	if errs := validar(&req); len(errs) > 0 {
+		responderCampos(w, r, errs)
+		return
+	}
+	if patch.Orden != nil {
+		actual.Orden = *patch.Orden
+	}
+	if patch.Activo != nil {
+		actual.Activo = sql.NullBool{Bool: *patch.Activo, Valid: true}
+	}
+	if patch.Restringido != nil {
+		actual.Restringido = *patch.Restringido
+	}
+
+	h.guardarServicio(w, r, barberia.ID, actual, req)
+}
+
+// DeleteServicio da de baja el servicio. No se borra porque los turnos
+// históricos lo referencian; deja de ofrecerse para reservas nuevas.
+func (h *ServiciosHandler) DeleteServicio(w http.ResponseWriter, r *http.Request) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de servicio inválido")
+		return
+	}
+
+	n, err := h.Queries.DeactivateServicio(r.Context(), db.DeactivateServicioParams{
+		ID:         int32(id),
+		BarberiaID: barberia.ID,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error dando de baja el servicio")
+		return
+	}
+	if n == 0 {
+		responderError(w, r, http.StatusNotFound, CodigoServicioNoEncontrado, "Servicio no encontrado")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// OrdenServiciosRequest lista los ids en el orden en que se deben mostrar.
+// Los servicios que no figuran conservan su posición actual.
+type OrdenServiciosRequest struct {
+	IDs []int32 `json:"ids" validar:"requerido"`
+}
+
+func (req *OrdenServiciosRequest) validarCampos() erroresCampos {
+	vistos := map[int32]bool{}
+	for _, id := range req.IDs {
+		if vistos[id] {
+			return erroresCampos{"ids": "id repetido: " + strconv.Itoa(int(id))}
+		}
+		vistos[id] = true
+	}
+	return nil
+}
+
+// OrdenarServicios fija el orden del listado público en una sola transacción
+func (h *ServiciosHandler) OrdenarServicios(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, err := h.Queries.GetBarberiaBySlug(ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	var req OrdenServiciosRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		errorInterno(w, r, err, "Error ordenando servicios")
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.Queries.WithTx(tx)
+
+	for i, id := range req.IDs {
+		n, err := qtx.UpdateServicioOrden(ctx, db.UpdateServicioOrdenParams{
+			ID:         id,
+			BarberiaID: barberia.ID,
+			Orden:      int32(i),
+		})
+		if err != nil {
+			errorInterno(w, r, err, "Error ordenando servicios")
+			return
+		}
+		if n == 0 {
+			responderError(w, r, http.StatusNotFound, CodigoServicioNoEncontrado, "Servicio no encontrado: "+strconv.Itoa(int(id)))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		errorInterno(w, r, err, "Error ordenando servicios")
+		return
+	}
+
+	servicios, err := h.Queries.ListServicios(ctx, barberia.ID)
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo servicios")
+		return
+	}
+	writeJSON(w, servicios)
+}
+
+// servicioDeURL resuelve {slug} y {id}, incluidos los servicios dados de baja
+func (h *ServiciosHandler) servicioDeURL(w http.ResponseWriter, r *http.Request) (db.Barberia, db.Servicio, bool) {
+	barberia, err := h.Queries.GetBarberiaBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return db.Barberia{}, db.Servicio{}, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de servicio inválido")
+		return db.Barberia{}, db.Servicio{}, false
+	}
+
+	servicio, err := h.Queries.GetServicioDeBarberia(r.Context(), db.GetServicioDeBarberiaParams{
+		ID:         int32(id),
+		BarberiaID: barberia.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoServicioNoEncontrado, "Servicio no encontrado")
+		return db.Barberia{}, db.Servicio{}, false
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo servicio")
+		return db.Barberia{}, db.Servicio{}, false
+	}
+
+	return barberia, servicio, true
+}
+
+// guardarServicio escribe req sobre el servicio actual, con su orden, estado
+// y restricción
+func (h *ServiciosHandler) guardarServicio(w http.ResponseWriter, r *http.Request, barberiaID int32, actual db.Servicio, req CreateServicioRequest) {
+	servicio, err := h.Queries.UpdateServicio(r.Context(), db.UpdateServicioParams{
+		ID:                   actual.ID,
+		BarberiaID:           barberiaID,
+		Nombre:               req.Nombre,
+		DuracionMinutos:      req.DuracionMinutos,
+		Precio:               req.Precio,
+		BufferAntesMinutos:   toNullInt32(req.BufferAntesMinutos),
+		BufferDespuesMinutos: toNullInt32(req.BufferDespuesMinutos),
+		Orden:                actual.Orden,
+		Activo:               actual.Activo,
+		Restringido:          actual.Restringido,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoServicioNoEncontrado, "Servicio no encontrado")
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error actualizando servicio")
+		return
+	}
+
+	writeJSON(w, servicio)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestValidarServicio tests los límites de duración, precio y nombre
+func TestValidarServicio(t *testing.T) {
+	casos := []struct {
+		nombre string
+		req    CreateServicioRequest
+		valido bool
+	}{
+		{"válido", CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 30, Precio: "1500"}, true},
+		{"precio con decimales", CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 30, Precio: "1500.5"}, true},
+		{"precio con espacios", CreateServicioRequest{Nombre: " Corte ", DuracionMinutos: 30, Precio: " 10.00 "}, true},
+		{"sin nombre", CreateServicioRequest{Nombre: "  ", DuracionMinutos: 30, Precio: "10"}, false},
+		{"duración cero", CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 0, Precio: "10"}, false},
+		{"duración excesiva", CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 481, Precio: "10"}, false},
+		{"precio negativo", CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 30, Precio: "-10"}, false},
+		{"precio con tres decimales", CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 30, Precio: "10.123"}, false},
+		{"precio con coma", CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 30, Precio: "10,50"}, false},
+		{"precio vacío", CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 30, Precio: ""}, false},
+	}
+	for _, c := range casos {
+		errs := validar(&c.req)
+		if (len(errs) == 0) != c.valido {
+			t.Errorf("%s: se esperaba válido=%v, pero se obtuvo %v", c.nombre, c.valido, errs)
+		}
+	}
+
+	req := CreateServicioRequest{Nombre: " Corte ", DuracionMinutos: 30, Precio: " 10.00 "}
+	validar(&req)
+	if req.Nombre != "Corte" || req.Precio != "10.00" {
+		t.Errorf("No se normalizaron nombre y precio: %q %q", req.Nombre, req.Precio)
+	}
+}
+
+// serviciosTest llama a los endpoints de servicios como los monta rutasAPI
+func serviciosTest(t *testing.T, h *ServiciosHandler, metodo, ruta string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	return pedirTest(t, func(r chi.Router) {
+		r.Get("/b/{slug}/servicios", h.ListServiciosActivos)
+		r.Put("/b/{slug}/servicios/orden", h.OrdenarServicios)
+		r.Put("/b/{slug}/servicios/{id}", h.UpdateServicio)
+		r.Patch("/b/{slug}/servicios/{id}", h.PatchServicio)
+		r.Delete("/b/{slug}/servicios/{id}", h.DeleteServicio)
+	}, metodo, ruta, body)
+}
+
+// TestServicios_EditarOrdenarYBaja cubre la edición, el orden del listado
+// público y la baja lógica con reactivación.
+func TestServicios_EditarOrdenarYBaja(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, _, corte := fixtureBarberia(t, conn)
+	q := db.New(conn)
+	h := NewServiciosHandler(q, conn)
+
+	barba, err := q.CreateServicio(context.Background(), db.CreateServicioParams{
+		BarberiaID:      barberia.ID,
+		Nombre:          "Barba",
+		DuracionMinutos: 20,
+		Precio:          "5.00",
+	})
+	if err != nil {
+		t.Fatalf("Error creando servicio: %v", err)
+	}
+	if barba.Orden <= corte.Orden {
+		t.Errorf("El servicio nuevo debería ir al final: %d <= %d", barba.Orden, corte.Orden)
+	}
+
+	base := "/b/" + barberia.Slug + "/servicios"
+	listar := func() []db.Servicio {
+		rec := serviciosTest(t, h, http.MethodGet, base, nil)
+		var servicios []db.Servicio
+		json.Unmarshal(rec.Body.Bytes(), &servicios)
+		return servicios
+	}
+
+	// Edición parcial: sólo cambia el precio
+	rec := serviciosTest(t, h, http.MethodPatch, base+"/"+strconv.Itoa(int(corte.ID)), map[string]any{"precio": "12.50"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var editado db.Servicio
+	json.Unmarshal(rec.Body.Bytes(), &editado)
+	if editado.Precio != "12.50" || editado.Nombre != "Corte" || editado.DuracionMinutos != 30 {
+		t.Errorf("PATCH tocó campos que no vinieron: %+v", editado)
+	}
+
+	rec = serviciosTest(t, h, http.MethodPut, base+"/"+strconv.Itoa(int(corte.ID)), CreateServicioRequest{Nombre: "Corte", DuracionMinutos: 0, Precio: "10"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT inválido: se esperaba 400, pero se obtuvo %d", rec.Code)
+	}
+
+	// Reordenar: Barba primero
+	rec = serviciosTest(t, h, http.MethodPut, base+"/orden", OrdenServiciosRequest{IDs: []int32{barba.ID, corte.ID}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Orden: se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	if l := listar(); len(l) != 2 || l[0].ID != barba.ID {
+		t.Errorf("El listado público no respeta el orden: %+v", l)
+	}
+
+	rec = serviciosTest(t, h, http.MethodPut, base+"/orden", OrdenServiciosRequest{IDs: []int32{barba.ID, -1}})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Orden con id ajeno: se esperaba 404, pero se obtuvo %d", rec.Code)
+	}
+
+	// Baja lógica y reactivación
+	rec = serviciosTest(t, h, http.MethodDelete, base+"/"+strconv.Itoa(int(barba.ID)), nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: se esperaba 204, pero se obtuvo %d", rec.Code)
+	}
+	if l := listar(); len(l) != 1 || l[0].ID != corte.ID {
+		t.Errorf("El servicio dado de baja sigue en el listado: %+v", l)
+	}
+
+	rec = serviciosTest(t, h, http.MethodPatch, base+"/"+strconv.Itoa(int(barba.ID)), map[string]any{"activo": true})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Reactivar: se esperaba 200, pero se obtuvo %d", rec.Code)
+	}
+	if l := listar(); len(l) != 2 {
+		t.Errorf("El servicio reactivado no volvió al listado: %+v", l)
+	}
+}
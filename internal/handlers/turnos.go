@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Estados posibles de un turno
+const (
+	EstadoPendiente  = "pendiente"
+	EstadoConfirmado = "confirmado"
+	EstadoCompletado = "completado"
+	EstadoNoAsistio  = "no_asistio"
+	EstadoCancelado  = "cancelado"
+)
+
+// transicionesTurno es la máquina de estados: desde cada estado, a cuáles se
+// puede pasar. Completado, no_asistio y cancelado son finales.
+var transicionesTurno = map[string][]string{
+	EstadoPendiente:  {EstadoConfirmado, EstadoCancelado},
+	EstadoConfirmado: {EstadoCompletado, EstadoNoAsistio, EstadoCancelado},
+}
+
+func transicionValida(desde, hacia string) bool {
+	for _, e := range transicionesTurno[desde] {
+		if e == hacia {
+			return true
+		}
+	}
+	return false
+}
+
+// estadoTurno normaliza la columna nullable (los turnos viejos sin estado son pendientes)
+func estadoTurno(t db.Turno) string {
+	if !t.Estado.Valid || t.Estado.String == "" {
+		return EstadoPendiente
+	}
+	return t.Estado.String
+}
+
+type TurnosHandler struct {
+	Queries *db.Queries
+}
+
+func NewTurnosHandler(q *db.Queries) *TurnosHandler {
+	return &TurnosHandler{Queries: q}
+}
+
+func (h *TurnosHandler) Confirmar(w http.ResponseWriter, r *http.Request) {
+	h.cambiarEstado(w, r, EstadoConfirmado)
+}
+
+func (h *TurnosHandler) Cancelar(w http.ResponseWriter, r *http.Request) {
+	h.cambiarEstado(w, r, EstadoCancelado)
+}
+
+func (h *TurnosHandler) Completar(w http.ResponseWriter, r *http.Request) {
+	h.cambiarEstado(w, r, EstadoCompletado)
+}
+
+func (h *TurnosHandler) NoAsistio(w http.ResponseWriter, r *http.Request) {
+	h.cambiarEstado(w, r, EstadoNoAsistio)
+}
+
+// ListTurnos es la agenda del día para el staff (?fecha=YYYY-MM-DD y
+// opcionalmente &barbero_id=), con los ids para operar sobre cada turno y los
+// datos del cliente. El barbero sólo ve los propios.
+func (h *TurnosHandler) ListTurnos(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, err := h.Queries.GetBarberiaBySlug(ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	fecha, err := time.Parse("2006-01-02", r.URL.Query().Get("fecha"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "fecha requerida (YYYY-MM-DD)")
+		return
+	}
+
+	var barberoID sql.NullInt32
+	if s := r.URL.Query().Get("barbero_id"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			responderError(w, r, http.StatusBadRequest, CodigoValidacion, "barbero_id inválido")
+			return
+		}
+		barberoID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+	if !esAdmin(r) {
+		claims, _ := ClaimsFromContext(ctx)
+		if barberoID.Valid && barberoID.Int32 != claims.UserID {
+			responderError(w, r, http.StatusForbidden, CodigoSinPermiso, "Sólo podés ver tus propios turnos")
+			return
+		}
+		barberoID = sql.NullInt32{Int32: claims.UserID, Valid: true}
+	}
+
+	turnos, err := h.Queries.ListTurnosByFecha(ctx, db.ListTurnosByFechaParams{
+		BarberiaID: barberia.ID,
+		Fecha:      fecha,
+		BarberoID:  barberoID,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo turnos")
+		return
+	}
+	if turnos == nil {
+		turnos = []db.ListTurnosByFechaRow{}
+	}
+
+	writeJSON(w, turnos)
+}
+
+// cambiarEstado valida la transición contra el estado actual y la aplica
+func (h *TurnosHandler) cambiarEstado(w http.ResponseWriter, r *http.Request, nuevo string) {
+	ctx := r.Context()
+
+	barberia, err := h.Queries.GetBarberiaBySlug(ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de turno inválido")
+		return
+	}
+
+	turno, err := h.Queries.GetTurnoByID(ctx, db.GetTurnoByIDParams{
+		ID:         int32(id),
+		BarberiaID: barberia.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoTurnoNoEncontrado, "Turno no encontrado")
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo turno")
+		return
+	}
+
+	if !puedeGestionarBarbero(r, turno.BarberoID) {
+		responderError(w, r, http.StatusForbidden, CodigoSinPermiso, "Sólo podés gestionar tus propios turnos")
+		return
+	}
+
+	actual := estadoTurno(turno)
+	if !transicionValida(actual, nuevo) {
+		responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "No se puede pasar un turno de "+actual+" a "+nuevo)
+		return
+	}
+
+	actualizado, err := h.Queries.UpdateTurnoEstado(ctx, db.UpdateTurnoEstadoParams{
+		EstadoNuevo:  toNullString(nuevo),
+		ID:           turno.ID,
+		BarberiaID:   barberia.ID,
+		EstadoActual: actual,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Otro request cambió el estado entre la lectura y el update
+		responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "El turno cambió de estado, volvé a intentar")
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error actualizando turno")
+		return
+	}
+
+	writeJSON(w, actualizado)
+}
+
+// ReasignarTurnoRequest indica a qué barbero pasa el turno (0 = cualquiera libre)
+type ReasignarTurnoRequest struct {
+	BarberoID int32 `json:"barbero_id" validar:"min=0"`
+}
+
+// ReasignarTurno pasa un turno activo a otro barbero en el mismo horario, con
+// las mismas validaciones que una reserva (horario, bloqueos, superposición).
+// Duración y precio pasan a ser los del barbero nuevo.
+// Se usa sobre todo con los turnos de un barbero dado de baja.
+func (h *BarberiaHandler) ReasignarTurno(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	barberia, err := h.Queries.GetBarberiaBySlug(ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		responderError(w, r, http.StatusNotFound, CodigoBarberiaNoEncontrada, "Barbería no encontrada")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		responderError(w, r, http.StatusBadRequest, CodigoValidacion, "id de turno inválido")
+		return
+	}
+
+	var req ReasignarTurnoRequest
+	if !decodificarJSON(w, r, &req) {
+		return
+	}
+
+	turno, err := h.Queries.GetTurnoByID(ctx, db.GetTurnoByIDParams{
+		ID:         int32(id),
+		BarberiaID: barberia.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responderError(w, r, http.StatusNotFound, CodigoTurnoNoEncontrado, "Turno no encontrado")
+		return
+	}
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo turno")
+		return
+	}
+	if actual := estadoTurno(turno); !turnoActivo(actual) {
+		responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "No se puede reasignar un turno "+actual)
+		return
+	}
+
+	// El servicio puede haberse dado de baja después de reservar
+	servicio, err := h.Queries.GetServicioDeBarberia(ctx, db.GetServicioDeBarberiaParams{
+		ID:         turno.ServicioID,
+		BarberiaID: barberia.ID,
+	})
+	if err != nil {
+		errorInterno(w, r, err, "Error obteniendo servicio")
+		return
+	}
+
+	reasignado, _, err := h.agendar(ctx, barberia, servicio, req.BarberoID, turno.Fecha, turno.HoraInicio, turno.ID,
+		func(qtx *db.Queries, u ubicacion) (db.Turno, error) {
+			t, err := qtx.UpdateTurnoHorario(ctx, db.UpdateTurnoHorarioParams{
+				BarberoID:     u.Barbero.ID,
+				Fecha:         turno.Fecha,
+				HoraInicio:    turno.HoraInicio,
+				HoraFin:       u.HoraFin,
+				OcupadoInicio: u.OcupadoInicio,
+				OcupadoFin:    u.OcupadoFin,
+				Precio:        toNullString(u.Precio),
+				ID:            turno.ID,
+				BarberiaID:    barberia.ID,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.Turno{}, errTurnoInactivo
+			}
+			return t, err
+		})
+	if errors.Is(err, errTurnoInactivo) {
+		responderError(w, r, http.StatusConflict, CodigoEstadoTurno, "El turno cambió de estado, volvé a intentar")
+		return
+	}
+	if err != nil {
+		responderErrorAgenda(w, r, err)
+		return
+	}
+
+	writeJSON(w, reasignado)
+}
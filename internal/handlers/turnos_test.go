@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	db "agendaFacil/db/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestListTurnos_PorRol tests la agenda del staff: el admin ve los turnos de
+// todos con sus ids, el barbero sólo los propios
+func TestListTurnos_PorRol(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	otroID := crearBarberoTest(t, conn, barberia.ID, "Otro")
+	q := db.New(conn)
+	bh := NewBarberiaHandler(q, conn)
+
+	for _, id := range []int32{barberoID, otroID} {
+		rec := reservarTest(t, bh, barberia.Slug, CreateReservaRequest{
+			ServicioID:    servicio.ID,
+			BarberoID:     id,
+			Fecha:         diaReservaTest(),
+			HoraInicio:    "10:00",
+			ClienteNombre: "Pedro",
+		})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Reserva: se esperaba 201, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	r := chi.NewRouter()
+	r.With(AuthMiddleware).Get("/b/{slug}/turnos", NewTurnosHandler(q).ListTurnos)
+	listar := func(usuario db.Usuario, consulta string) ([]db.ListTurnosByFechaRow, int) {
+		t.Helper()
+		token, _, err := generarToken(usuario)
+		if err != nil {
+			t.Fatalf("Error generando token: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/b/"+barberia.Slug+"/turnos?fecha="+diaReservaTest()+consulta, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		var turnos []db.ListTurnosByFechaRow
+		json.Unmarshal(rec.Body.Bytes(), &turnos)
+		return turnos, rec.Code
+	}
+	admin := db.Usuario{ID: barberoID, BarberiaID: barberia.ID, Rol: RolAdmin}
+	barbero := db.Usuario{ID: otroID, BarberiaID: barberia.ID, Rol: RolBarbero}
+
+	if turnos, code := listar(admin, ""); code != http.StatusOK || len(turnos) != 2 || turnos[0].ID == 0 || turnos[0].ClienteNombre != "Pedro" {
+		t.Errorf("Admin: se esperaban los 2 turnos con id y cliente, se obtuvo %d %+v", code, turnos)
+	}
+	if turnos, code := listar(admin, "&barbero_id="+strconv.Itoa(int(otroID))); code != http.StatusOK || len(turnos) != 1 || turnos[0].BarberoID != otroID {
+		t.Errorf("Admin filtrando: se esperaba el turno del otro barbero, se obtuvo %d %+v", code, turnos)
+	}
+	if turnos, code := listar(barbero, ""); code != http.StatusOK || len(turnos) != 1 || turnos[0].BarberoID != otroID {
+		t.Errorf("Barbero: se esperaba sólo su turno, se obtuvo %d %+v", code, turnos)
+	}
+	if _, code := listar(barbero, "&barbero_id="+strconv.Itoa(int(barberoID))); code != http.StatusForbidden {
+		t.Errorf("Barbero pidiendo otra agenda: se esperaba 403, se obtuvo %d", code)
+	}
+}
+
+// TestConfirmar_EstadoNulo: un turno viejo con estado NULL cuenta como
+// pendiente y se puede confirmar, no queda en 409 para siempre
+func TestConfirmar_EstadoNulo(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	q := db.New(conn)
+
+	rec := reservarTest(t, NewBarberiaHandler(q, conn), barberia.Slug, CreateReservaRequest{
+		ServicioID:    servicio.ID,
+		BarberoID:     barberoID,
+		Fecha:         diaReservaTest(),
+		HoraInicio:    "10:00",
+		ClienteNombre: "Pedro",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Reserva: se esperaba 201, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var reserva ReservaResponse
+	json.NewDecoder(rec.Body).Decode(&reserva)
+	if _, err := conn.Exec("UPDATE turnos SET estado = NULL WHERE id = $1", reserva.ID); err != nil {
+		t.Fatalf("Error dejando el estado en NULL: %v", err)
+	}
+
+	token, _, err := generarToken(db.Usuario{ID: barberoID, BarberiaID: barberia.ID, Rol: RolAdmin})
+	if err != nil {
+		t.Fatalf("Error generando token: %v", err)
+	}
+	r := chi.NewRouter()
+	r.With(AuthMiddleware).Post("/b/{slug}/turnos/{id}/confirmar", NewTurnosHandler(q).Confirmar)
+	req := httptest.NewRequest(http.MethodPost, "/b/"+barberia.Slug+"/turnos/"+strconv.Itoa(int(reserva.ID))+"/confirmar", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Se esperaba 200, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+	var turno db.Turno
+	json.NewDecoder(rec.Body).Decode(&turno)
+	if turno.Estado.String != EstadoConfirmado {
+		t.Errorf("Se esperaba el turno confirmado, se obtuvo %q", turno.Estado.String)
+	}
+}
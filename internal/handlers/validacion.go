@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"agendaFacil/internal/telefono"
+)
+
+// erroresCampos son los errores de validación de un request, por campo del
+// JSON. Se responden todos juntos para que el formulario marque cada campo.
+type erroresCampos map[string]string
+
+func (e erroresCampos) Error() string {
+	return "Datos inválidos"
+}
+
+func responderCampos(w http.ResponseWriter, r *http.Request, e erroresCampos) {
+	escribirError(w, r, http.StatusBadRequest, ErrorAPI{
+		Codigo:  CodigoValidacion,
+		Mensaje: e.Error(),
+		Campos:  e,
+	})
+}
+
+// maxCuerpoJSON acota el body de los requests: el más grande (el registro
+// con sus servicios, o un horario semanal completo) no llega a unos pocos KB
+const maxCuerpoJSON = 64 << 10
+
+// decodificarJSON lee el body en dst y lo valida. Rechaza bodies de más de
+// maxCuerpoJSON, campos que no existen en dst y datos después del objeto.
+// Si algo falla ya respondió el error y devuelve false.
+func decodificarJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return decodificar(w, r, dst, false)
+}
+
+// decodificarJSONOpcional es decodificarJSON para los endpoints que aceptan
+// el body vacío (dst queda en su valor cero, que también se valida)
+func decodificarJSONOpcional(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return decodificar(w, r, dst, true)
+}
+
+func decodificar(w http.ResponseWriter, r *http.Request, dst any, opcional bool) bool {
+	if !leerJSON(w, r, dst, opcional) {
+		return false
+	}
+	if errs := validar(dst); len(errs) > 0 {
+		responderCampos(w, r, errs)
+		return false
+	}
+	return true
+}
+
+// leerJSON es la mitad de decodificarJSON que no valida, para los handlers
+// que suman reglas que dependen de otros datos (ej. el país de la barbería)
+// y quieren responder todos los errores juntos
+func leerJSON(w http.ResponseWriter, r *http.Request, dst any, opcional bool) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCuerpoJSON)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil && dec.Decode(&struct{}{}) != io.EOF {
+		err = errors.New("datos después del objeto JSON")
+	}
+	if opcional && errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if err != nil {
+		responderErrorJSON(w, r, err)
+		return false
+	}
+	return true
+}
+
+// responderErrorJSON traduce los errores del decoder: los de un campo
+// (desconocido o de otro tipo) van como error de validación de ese campo
+func responderErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	var demasiadoGrande *http.MaxBytesError
+	var tipo *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &demasiadoGrande):
+		responderError(w, r, http.StatusRequestEntityTooLarge, CodigoCuerpoDemasiadoGrande,
+			fmt.Sprintf("El cuerpo no puede superar los %d KB", maxCuerpoJSON>>10))
+	case errors.As(err, &tipo) && tipo.Field != "":
+		responderCampos(w, r, erroresCampos{tipo.Field: "tiene un tipo inválido (se esperaba " + nombreTipo(tipo.Type) + ")"})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		campo, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		responderCampos(w, r, erroresCampos{campo: "no es un campo válido"})
+	case errors.Is(err, io.EOF):
+		responderError(w, r, http.StatusBadRequest, CodigoJSONInvalido, "Falta el cuerpo del request")
+	default:
+		responderError(w, r, http.StatusBadRequest, CodigoJSONInvalido, "JSON inválido")
+	}
+}
+
+func nombreTipo(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "texto"
+	case reflect.Bool:
+		return "true o false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "número entero"
+	case reflect.Slice, reflect.Array:
+		return "lista"
+	case reflect.Struct, reflect.Map:
+		return "objeto"
+	}
+	return t.Kind().String()
+}
+
+// Los requests declaran sus reglas con el tag `validar`, separadas por coma:
+//
+//	requerido   texto no vacío (sin contar espacios), número distinto de cero, lista no vacía
+//	min=N       mínimo: caracteres para textos, valor para números, elementos para listas
+//	max=N       máximo, con las mismas unidades que min
+//	uno_de=a|b  valores permitidos
+//
+// y las de reglasTexto (email, fecha, hora, ...). Los textos vacíos y los
+// punteros nil (campos omitidos) sólo se controlan con requerido.
+//
+// Antes de validar un struct se llama a su método normalizar, si lo tiene
+// (recortar espacios, completar valores por defecto); después, si sus campos
+// pasaron las reglas, a validarCampos para las reglas entre campos. Los
+// structs y listas de structs anidados se validan con el nombre completo del
+// campo: admin.email, servicios[0].precio.
+
+// normalizable es un request que limpia sus datos antes de validarse
+type normalizable interface {
+	normalizar()
+}
+
+// validableEntreCampos es un request con reglas que involucran varios campos
+type validableEntreCampos interface {
+	validarCampos() erroresCampos
+}
+
+// recortar saca los espacios de los extremos de cada campo; es lo que hacen
+// casi todos los normalizar
+func recortar(campos ...*string) {
+	for _, c := range campos {
+		*c = strings.TrimSpace(*c)
+	}
+}
+
+var emailValido = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s.]+$`)
+
+// reglasTexto son las reglas de formato para campos de texto. Devuelven el
+// mensaje de error o "" si el valor es válido.
+var reglasTexto = map[string]func(s string) string{
+	"email": func(s string) string {
+		if !emailValido.MatchString(s) {
+			return "no es un email válido"
+		}
+		return ""
+	},
+	"fecha": func(s string) string {
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return "debe tener formato YYYY-MM-DD"
+		}
+		return ""
+	},
+	"hora": func(s string) string {
+		if _, err := time.Parse("15:04", s); err != nil {
+			return "debe tener formato HH:MM"
+		}
+		return ""
+	},
+	"precio": func(s string) string {
+		if !precioValido.MatchString(s) {
+			return "debe ser un número no negativo con hasta 2 decimales (ej. 1500.50)"
+		}
+		return ""
+	},
+	"slug": func(s string) string {
+		if !slugValido(s) {
+			return errSlugInvalido.Error()
+		}
+		return ""
+	},
+	"zona": func(s string) string {
+		if _, err := cargarZona(s); err != nil {
+			return err.Error()
+		}
+		return ""
+	},
+	"pais": func(s string) string {
+		if !telefono.Soportado(s) {
+			return errPaisNoSoportado.Error()
+		}
+		return ""
+	},
+	"password": func(s string) string {
+		if err := validarPassword(s); err != nil {
+			return err.Error()
+		}
+		return ""
+	},
+}
+
+// validar aplica las reglas de v, que debe ser un puntero a struct
+func validar(v any) erroresCampos {
+	errs := erroresCampos{}
+	validarStruct(reflect.ValueOf(v).Elem(), "", errs)
+	return errs
+}
+
+func validarStruct(v reflect.Value, prefijo string, errs erroresCampos) {
+	if n, ok := v.Addr().Interface().(normalizable); ok {
+		n.normalizar()
+	}
+
+	antes := len(errs)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		nombre, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if nombre == "-" {
+			continue
+		}
+		if nombre == "" {
+			nombre = f.Name
+		}
+		campo := prefijo + nombre
+
+		fv := v.Field(i)
+		reglas := f.Tag.Get("validar")
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				if strings.Contains(","+reglas+",", ",requerido,") {
+					errs[campo] = "es obligatorio"
+				}
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		if msg := aplicarReglas(fv, reglas); msg != "" {
+			errs[campo] = msg
+			continue
+		}
+
+		switch {
+		case fv.Kind() == reflect.Struct && f.Anonymous && f.Tag.Get("json") == "":
+			validarStruct(fv, prefijo, errs)
+		case fv.Kind() == reflect.Struct:
+			validarStruct(fv, campo+".", errs)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < fv.Len(); j++ {
+				validarStruct(fv.Index(j), fmt.Sprintf("%s[%d].", campo, j), errs)
+			}
+		}
+	}
+
+	if len(errs) > antes {
+		return
+	}
+	if c, ok := v.Addr().Interface().(validableEntreCampos); ok {
+		for campo, msg := range c.validarCampos() {
+			errs[prefijo+campo] = msg
+		}
+	}
+}
+
+// revisarRegla dice por qué una regla del tag validar está mal escrita, o ""
+// si está bien. Es un error de programación, así que aplicarReglas entra en
+// pánico; TestTagsValidar revisa todos los tags del paquete antes de que
+// llegue a un request.
+func revisarRegla(regla string) string {
+	nombre, arg, _ := strings.Cut(regla, "=")
+	switch nombre {
+	case "requerido":
+		return ""
+	case "min", "max":
+		if _, err := strconv.ParseInt(arg, 10, 64); err != nil {
+			return nombre + " necesita un número: " + regla
+		}
+		return ""
+	case "uno_de":
+		if arg == "" {
+			return "uno_de necesita opciones: " + regla
+		}
+		return ""
+	}
+	if _, ok := reglasTexto[nombre]; !ok {
+		return "regla desconocida " + nombre
+	}
+	return ""
+}
+
+// aplicarReglas devuelve el mensaje de la primera regla que no se cumple
+func aplicarReglas(v reflect.Value, reglas string) string {
+	if reglas == "" {
+		return ""
+	}
+
+	var requerido, hayMin, hayMax bool
+	var min, max int64
+	var unoDe []string
+	var formatos []string
+	for _, regla := range strings.Split(reglas, ",") {
+		if msg := revisarRegla(regla); msg != "" {
+			panic("validar: " + msg)
+		}
+		nombre, arg, _ := strings.Cut(regla, "=")
+		switch nombre {
+		case "requerido":
+			requerido = true
+		case "min":
+			min, _ = strconv.ParseInt(arg, 10, 64)
+			hayMin = true
+		case "max":
+			max, _ = strconv.ParseInt(arg, 10, 64)
+			hayMax = true
+		case "uno_de":
+			unoDe = strings.Split(arg, "|")
+		default:
+			formatos = append(formatos, nombre)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if strings.TrimSpace(s) == "" {
+			if requerido {
+				return "es obligatorio"
+			}
+			return ""
+		}
+		if msg := mensajeRango(int64(utf8.RuneCountInString(s)), hayMin, min, hayMax, max, " caracteres"); msg != "" {
+			return msg
+		}
+		if unoDe != nil && !slices.Contains(unoDe, s) {
+			return "debe ser uno de: " + strings.Join(unoDe, ", ")
+		}
+		for _, f := range formatos {
+			if msg := reglasTexto[f](s); msg != "" {
+				return msg
+			}
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := v.Int()
+		if requerido && n == 0 {
+			return "es obligatorio"
+		}
+		return mensajeRango(n, hayMin, min, hayMax, max, "")
+
+	case reflect.Slice:
+		if requerido && v.Len() == 0 {
+			return "no puede estar vacío"
+		}
+		return mensajeRango(int64(v.Len()), hayMin, min, hayMax, max, " elementos")
+	}
+	return ""
+}
+
+// mensajeRango arma el mensaje de min/max; unidad es "" para números
+func mensajeRango(n int64, hayMin bool, min int64, hayMax bool, max int64, unidad string) string {
+	fuera := (hayMin && n < min) || (hayMax && n > max)
+	switch {
+	case !fuera:
+		return ""
+	case hayMin && hayMax && unidad == "":
+		return fmt.Sprintf("debe estar entre %d y %d", min, max)
+	case hayMin && hayMax:
+		return fmt.Sprintf("debe tener entre %d y %d%s", min, max, unidad)
+	case hayMin && unidad == "":
+		return fmt.Sprintf("debe ser al menos %d", min)
+	case hayMin:
+		return fmt.Sprintf("debe tener al menos %d%s", min, unidad)
+	case unidad == "":
+		return fmt.Sprintf("no puede superar %d", max)
+	default:
+		return fmt.Sprintf("no puede tener más de %d%s", max, unidad)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestValidar_Reglas tests cada regla del tag y que se junten todos los errores
+func TestValidar_Reglas(t *testing.T) {
+	var req struct {
+		Nombre  string   `json:"nombre" validar:"requerido,max=5"`
+		Email   string   `json:"email" validar:"email"`
+		Fecha   string   `json:"fecha" validar:"fecha"`
+		Hora    *string  `json:"hora" validar:"requerido,hora"`
+		Precio  string   `json:"precio" validar:"precio"`
+		Minutos int32    `json:"minutos" validar:"min=5,max=480"`
+		Modo    string   `json:"modo" validar:"uno_de=a|b"`
+		IDs     []int32  `json:"ids" validar:"requerido"`
+		Libre   string   `json:"libre"`
+		Omitido *int32   `json:"omitido" validar:"min=1"`
+		Varios  []string `json:"varios" validar:"max=2"`
+	}
+	req.Nombre = "Demasiado largo"
+	req.Email = "sin-arroba"
+	req.Fecha = "10/01/2030"
+	req.Precio = "10,50"
+	req.Minutos = 3
+	req.Modo = "c"
+	req.Varios = []string{"x", "y", "z"}
+
+	errs := validar(&req)
+	esperados := []string{"nombre", "email", "fecha", "hora", "precio", "minutos", "modo", "ids", "varios"}
+	if len(errs) != len(esperados) {
+		t.Errorf("Se esperaban %d errores, se obtuvo %v", len(esperados), errs)
+	}
+	for _, campo := range esperados {
+		if errs[campo] == "" {
+			t.Errorf("Falta el error de %s: %v", campo, errs)
+		}
+	}
+	if errs["minutos"] != "debe estar entre 5 y 480" || errs["nombre"] != "no puede tener más de 5 caracteres" {
+		t.Errorf("Mensajes inesperados: %v", errs)
+	}
+}
+
+// TestValidar_Anidados tests los nombres completos de los campos anidados
+func TestValidar_Anidados(t *testing.T) {
+	req := RegistroRequest{
+		Barberia: RegistroBarberia{Nombre: "Centro"},
+		Admin:    CreateBarberoRequest{Nombre: "Ana", Apellido: "García", Username: "ana", Email: "ana@correo", Password: "Secreta123"},
+		Servicios: []CreateServicioRequest{
+			{Nombre: "Corte", DuracionMinutos: 30, Precio: "10"},
+			{Nombre: "Barba", DuracionMinutos: 20, Precio: "-1"},
+		},
+	}
+	errs := validar(&req)
+	if len(errs) != 2 || errs["admin.email"] == "" || errs["servicios[1].precio"] == "" {
+		t.Errorf("Se esperaban errores en admin.email y servicios[1].precio: %v", errs)
+	}
+}
+
+// decodificarTest pasa el body por decodificarJSON como lo haría un handler
+func decodificarTest(body string) (*httptest.ResponseRecorder, RespuestaError, bool) {
+	var req CreateServicioRequest
+	rec := httptest.NewRecorder()
+	ok := decodificarJSON(rec, httptest.NewRequest(http.MethodPost, "/b/a/servicios", strings.NewReader(body)), &req)
+
+	var resp RespuestaError
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp, ok
+}
+
+// TestDecodificarJSON tests los errores del body antes de llegar al handler
+func TestDecodificarJSON(t *testing.T) {
+	if rec, _, ok := decodificarTest(`{"nombre":"Corte","duracion_minutos":30,"precio":"10"}`); !ok {
+		t.Fatalf("Body válido rechazado: %s", rec.Body.String())
+	}
+
+	casos := []struct {
+		nombre string
+		body   string
+		status int
+		codigo string
+		campo  string
+	}{
+		{"vacío", ``, http.StatusBadRequest, CodigoJSONInvalido, ""},
+		{"mal formado", `{"nombre":`, http.StatusBadRequest, CodigoJSONInvalido, ""},
+		{"dos objetos", `{"nombre":"Corte","duracion_minutos":30,"precio":"10"}{}`, http.StatusBadRequest, CodigoJSONInvalido, ""},
+		{"campo desconocido", `{"nombre":"Corte","duracion_minutos":30,"precio":"10","admin":true}`, http.StatusBadRequest, CodigoValidacion, "admin"},
+		{"tipo inválido", `{"nombre":"Corte","duracion_minutos":"30","precio":"10"}`, http.StatusBadRequest, CodigoValidacion, "duracion_minutos"},
+		{"reglas", `{"nombre":" ","duracion_minutos":30,"precio":"10"}`, http.StatusBadRequest, CodigoValidacion, "nombre"},
+		{"demasiado grande", `{"nombre":"` + strings.Repeat("a", maxCuerpoJSON) + `"}`, http.StatusRequestEntityTooLarge, CodigoCuerpoDemasiadoGrande, ""},
+	}
+	for _, c := range casos {
+		rec, resp, ok := decodificarTest(c.body)
+		if ok || rec.Code != c.status || resp.Error.Codigo != c.codigo {
+			t.Errorf("%s: se esperaba %d %s, se obtuvo %d: %s", c.nombre, c.status, c.codigo, rec.Code, rec.Body.String())
+		}
+		if c.campo != "" && resp.Error.Campos[c.campo] == "" {
+			t.Errorf("%s: se esperaba el error en %s: %v", c.nombre, c.campo, resp.Error.Campos)
+		}
+	}
+
+	// Todos los errores de reglas en una sola respuesta
+	_, resp, _ := decodificarTest(`{"nombre":"","duracion_minutos":0,"precio":"x"}`)
+	if len(resp.Error.Campos) != 3 {
+		t.Errorf("Se esperaban los tres campos juntos: %v", resp.Error.Campos)
+	}
+}
+
+// TestDecodificarJSONOpcional tests que el body vacío tome los valores por defecto
+func TestDecodificarJSONOpcional(t *testing.T) {
+	var req DesactivarBarberoRequest
+	rec := httptest.NewRecorder()
+	if !decodificarJSONOpcional(rec, httptest.NewRequest(http.MethodPost, "/", nil), &req) || req.TurnosFuturos != TurnosReasignar {
+		t.Errorf("Sin body debería reasignar: %q %s", req.TurnosFuturos, rec.Body.String())
+	}
+
+	req = DesactivarBarberoRequest{}
+	rec = httptest.NewRecorder()
+	if decodificarJSONOpcional(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"turnos_futuros":"borrar"}`)), &req) {
+		t.Error("Se esperaba error para turnos_futuros inválido")
+	}
+}
+
+// TestTagsValidar revisa el tag validar de todos los structs del paquete
+// (los requests que pasan por decodificarJSON y los que anidan): una regla
+// mal escrita entraría en pánico recién al llegar el request.
+func TestTagsValidar(t *testing.T) {
+	archivos, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Error listando el paquete: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	revisados := 0
+	for _, archivo := range archivos {
+		f, err := parser.ParseFile(fset, archivo, nil, 0)
+		if err != nil {
+			t.Fatalf("Error leyendo %s: %v", archivo, err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			campo, ok := n.(*ast.Field)
+			if !ok || campo.Tag == nil {
+				return true
+			}
+			tag, err := strconv.Unquote(campo.Tag.Value)
+			if err != nil {
+				t.Errorf("%s: tag inválido %s", fset.Position(campo.Pos()), campo.Tag.Value)
+				return true
+			}
+			reglas := reflect.StructTag(tag).Get("validar")
+			if reglas == "" {
+				return true
+			}
+			revisados++
+			for _, regla := range strings.Split(reglas, ",") {
+				if msg := revisarRegla(regla); msg != "" {
+					t.Errorf("%s: %s", fset.Position(campo.Pos()), msg)
+				}
+			}
+			return true
+		})
+	}
+	if revisados == 0 {
+		t.Error("No se encontró ningún tag validar")
+	}
+}
+
+// TestRevisarRegla tests que se detecten las reglas mal escritas
+func TestRevisarRegla(t *testing.T) {
+	for _, regla := range []string{"requerido", "min=0", "max=480", "uno_de=a|b", "email", "precio"} {
+		if msg := revisarRegla(regla); msg != "" {
+			t.Errorf("%s debería ser válida: %s", regla, msg)
+		}
+	}
+	for _, regla := range []string{"requirido", "min", "max=diez", "uno_de=", "Email"} {
+		if revisarRegla(regla) == "" {
+			t.Errorf("%s debería ser inválida", regla)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	_ "time/tzdata" // las zonas IANA no dependen de que el servidor tenga /usr/share/zoneinfo
+
+	db "agendaFacil/db/sqlc"
+)
+
+// Las columnas DATE y TIME de turnos, horarios y bloqueos no tienen zona: son
+// siempre la hora de reloj de la barbería. La zona sólo hace falta para
+// compararlas con el momento actual.
+
+// zonaPorDefecto es la de las barberías que no configuraron otra (igual que
+// el DEFAULT de la columna)
+const zonaPorDefecto = "America/Argentina/Buenos_Aires"
+
+var (
+	errZonaInvalida    = errors.New("zona_horaria debe ser una zona IANA (ej. America/Argentina/Buenos_Aires)")
+	errHoraInexistente = errors.New("la hora no existe en la zona de la barbería por el cambio de horario")
+	errTurnoPasado     = errorPolitica{
+		Status:  http.StatusBadRequest,
+		Mensaje: "No se puede reservar un horario que ya pasó",
+		Motivo:  MotivoHorarioPasado,
+	}
+)
+
+// zonas cachea las zonas ya cargadas: time.LoadLocation lee la base de datos
+// de zonas en cada llamada
+var zonas sync.Map // nombre → *time.Location
+
+// cargarZona valida y carga una zona IANA. "Local" se rechaza porque
+// dependería de la configuración del servidor.
+func cargarZona(nombre string) (*time.Location, error) {
+	if loc, ok := zonas.Load(nombre); ok {
+		return loc.(*time.Location), nil
+	}
+	if nombre == "" || nombre == "Local" {
+		return nil, errZonaInvalida
+	}
+	loc, err := time.LoadLocation(nombre)
+	if err != nil {
+		return nil, errZonaInvalida
+	}
+	zonas.Store(nombre, loc)
+	return loc, nil
+}
+
+// zonaBarberia es la zona de la barbería. Una zona inválida en la DB no
+// debería pasar (se valida al guardarla); si pasa se usa la por defecto.
+func zonaBarberia(barberia db.Barberia) *time.Location {
+	loc, err := cargarZona(barberia.ZonaHoraria)
+	if err != nil {
+		log.Printf("barbería %d: zona horaria %q inválida, se usa %s", barberia.ID, barberia.ZonaHoraria, zonaPorDefecto)
+		loc, _ = cargarZona(zonaPorDefecto)
+	}
+	return loc
+}
+
+// momentoTurno combina la fecha y la hora de reloj de un turno en el instante
+// que representan en la zona de la barbería
+func momentoTurno(barberia db.Barberia, fecha, hora time.Time) time.Time {
+	return time.Date(fecha.Year(), fecha.Month(), fecha.Day(), hora.Hour(), hora.Minute(), 0, 0, zonaBarberia(barberia))
+}
+
+// momentoReserva es momentoTurno para un horario pedido por el cliente:
+// rechaza las horas que no existen (el salto del cambio de horario) y las
+// que ya pasaron.
+func momentoReserva(barberia db.Barberia, fecha, hora time.Time, now time.Time) (time.Time, error) {
+	inicio := momentoTurno(barberia, fecha, hora)
+	if inicio.Hour() != hora.Hour() || inicio.Minute() != hora.Minute() {
+		return time.Time{}, errHoraInexistente
+	}
+	if inicio.Before(now) {
+		return time.Time{}, errTurnoPasado
+	}
+	return inicio, nil
+}
+
+// relojLocal devuelve la fecha y la hora actuales de la barbería en el formato
+// de las columnas DATE y TIME
+func relojLocal(barberia db.Barberia, now time.Time) (fecha, hora time.Time) {
+	local := now.In(zonaBarberia(barberia))
+	fecha = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	hora = time.Date(0, 1, 1, local.Hour(), local.Minute(), 0, 0, time.UTC)
+	return fecha, hora
+}
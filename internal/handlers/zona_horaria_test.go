@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	db "agendaFacil/db/sqlc"
+)
+
+// TestCargarZona tests qué zonas se aceptan al configurar la barbería
+func TestCargarZona(t *testing.T) {
+	for _, z := range []string{zonaPorDefecto, "Europe/Madrid", "UTC"} {
+		if _, err := cargarZona(z); err != nil {
+			t.Errorf("Zona válida rechazada %q: %v", z, err)
+		}
+	}
+	for _, z := range []string{"", "Local", "Marte/Olympus", "GMT-3 "} {
+		if _, err := cargarZona(z); err == nil {
+			t.Errorf("Se esperaba error para la zona %q", z)
+		}
+	}
+}
+
+// TestMomentoReserva tests que la misma hora de reloj se interprete en la
+// zona de cada barbería, y el rechazo de horas pasadas o inexistentes
+func TestMomentoReserva(t *testing.T) {
+	baires := db.Barberia{ZonaHoraria: zonaPorDefecto}
+	madrid := db.Barberia{ZonaHoraria: "Europe/Madrid"}
+	fecha, h := fechaTest(2030, 1, 10), hora(10, 0)
+
+	// 10:00 en Buenos Aires (UTC-3) son las 13:00 UTC; en Madrid (UTC+1), las 09:00
+	now := time.Date(2030, 1, 10, 11, 0, 0, 0, time.UTC)
+	if _, err := momentoReserva(baires, fecha, h, now); err != nil {
+		t.Errorf("Buenos Aires: el turno todavía no empezó: %v", err)
+	}
+	if _, err := momentoReserva(madrid, fecha, h, now); !errors.Is(err, errTurnoPasado) {
+		t.Errorf("Madrid: se esperaba errTurnoPasado, se obtuvo %v", err)
+	}
+
+	// El 31/03/2030 en Madrid el reloj salta de 02:00 a 03:00
+	if _, err := momentoReserva(madrid, fechaTest(2030, 3, 31), hora(2, 30), now); !errors.Is(err, errHoraInexistente) {
+		t.Errorf("Se esperaba errHoraInexistente, se obtuvo %v", err)
+	}
+	if _, err := momentoReserva(madrid, fechaTest(2030, 3, 31), hora(3, 0), now); err != nil {
+		t.Errorf("Las 03:00 existen: %v", err)
+	}
+}
+
+// TestRelojLocal tests que "hoy" sea el día de la barbería y no el del servidor
+func TestRelojLocal(t *testing.T) {
+	// 02:00 UTC del 10/01 son las 23:00 del 09/01 en Buenos Aires
+	fecha, h := relojLocal(db.Barberia{ZonaHoraria: zonaPorDefecto}, time.Date(2030, 1, 10, 2, 0, 0, 0, time.UTC))
+	if !fecha.Equal(fechaTest(2030, 1, 9)) || !h.Equal(hora(23, 0)) {
+		t.Errorf("Se esperaba 2030-01-09 23:00, se obtuvo %s %s", fecha.Format("2006-01-02"), h.Format("15:04"))
+	}
+}
+
+// TestSlotsReservables tests que la disponibilidad de hoy oculte los horarios ya pasados
+func TestSlotsReservables(t *testing.T) {
+	barberia := db.Barberia{ZonaHoraria: zonaPorDefecto}
+	slots := []Slot{{Inicio: "10:00", Fin: "10:30"}, {Inicio: "10:15", Fin: "10:45"}, {Inicio: "11:00", Fin: "11:30"}}
+	// 10:07 en Buenos Aires
+	now := time.Date(2030, 1, 10, 13, 7, 0, 0, time.UTC)
+
+	if got := slotsReservables(barberia, fechaTest(2030, 1, 10), now, slots); len(got) != 2 || got[0].Inicio != "10:15" {
+		t.Errorf("Hoy: se esperaban los slots desde las 10:15, se obtuvo %+v", got)
+	}
+	if got := slotsReservables(barberia, fechaTest(2030, 1, 9), now, slots); len(got) != 0 {
+		t.Errorf("Ayer: no debería quedar ningún slot, se obtuvo %+v", got)
+	}
+	if got := slotsReservables(barberia, fechaTest(2030, 1, 11), now, slots); len(got) != 3 {
+		t.Errorf("Mañana: deberían quedar todos los slots, se obtuvo %+v", got)
+	}
+}
+
+// TestReservar_EnElPasado tests que no se pueda reservar un horario pasado
+func TestReservar_EnElPasado(t *testing.T) {
+	conn := abrirDBTest(t)
+	barberia, barberoID, servicio := fixtureBarberia(t, conn)
+	h := NewBarberiaHandler(db.New(conn), conn)
+
+	rec := reservarTest(t, h, barberia.Slug, CreateReservaRequest{
+		ServicioID:    servicio.ID,
+		BarberoID:     barberoID,
+		Fecha:         "2020-01-10",
+		HoraInicio:    "10:00",
+		ClienteNombre: "Pedro",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Se esperaba 400, pero se obtuvo %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,117 @@
+// Package mail envía los correos de la aplicación (por ahora, el de
+// recuperación de contraseña) a través de un Sender intercambiable.
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Mensaje es un correo de texto plano
+type Mensaje struct {
+	Para   string
+	Asunto string
+	Cuerpo string
+}
+
+// Sender entrega un mensaje. Las implementaciones deben ser seguras para uso concurrente.
+type Sender interface {
+	Enviar(ctx context.Context, m Mensaje) error
+}
+
+// LogSender escribe el correo en el log. Útil en desarrollo.
+type LogSender struct {
+	Logger *log.Logger
+}
+
+func (s LogSender) Enviar(_ context.Context, m Mensaje) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("[mail] Para: %s | Asunto: %s\n%s", m.Para, m.Asunto, m.Cuerpo)
+	return nil
+}
+
+// FileSender guarda cada correo como un archivo .eml en Dir, para
+// inspeccionarlos a mano o desde los tests.
+type FileSender struct {
+	Dir string
+}
+
+// secuencia desempata archivos creados en el mismo instante
+var secuencia atomic.Int64
+
+func (s FileSender) Enviar(_ context.Context, m Mensaje) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	nombre := fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), secuencia.Add(1))
+	return os.WriteFile(filepath.Join(s.Dir, nombre), []byte(formatear("", m)), 0o644)
+}
+
+// SMTPSender envía por SMTP con autenticación PLAIN
+type SMTPSender struct {
+	Host     string // host:puerto
+	Usuario  string
+	Password string
+	De       string
+}
+
+func (s SMTPSender) Enviar(_ context.Context, m Mensaje) error {
+	host, _, _ := strings.Cut(s.Host, ":")
+	auth := smtp.PlainAuth("", s.Usuario, s.Password, host)
+	return smtp.SendMail(s.Host, auth, s.De, []string{m.Para}, []byte(formatear(s.De, m)))
+}
+
+// formatear arma el mensaje con sus headers mínimos
+func formatear(de string, m Mensaje) string {
+	var b strings.Builder
+	if de != "" {
+		fmt.Fprintf(&b, "From: %s\r\n", de)
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", m.Para)
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Asunto)
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(m.Cuerpo)
+	return b.String()
+}
+
+// DesdeEntorno elige el Sender según MAIL_SENDER:
+//
+//	log  (por defecto)  escribe en el log
+//	file                guarda en MAIL_DIR (por defecto ./mails)
+//	smtp                usa SMTP_HOST, SMTP_USER, SMTP_PASSWORD y MAIL_FROM
+func DesdeEntorno(getenv func(string) string) (Sender, error) {
+	switch getenv("MAIL_SENDER") {
+	case "", "log":
+		return LogSender{}, nil
+	case "file":
+		dir := getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mails"
+		}
+		return FileSender{Dir: dir}, nil
+	case "smtp":
+		s := SMTPSender{
+			Host:     getenv("SMTP_HOST"),
+			Usuario:  getenv("SMTP_USER"),
+			Password: getenv("SMTP_PASSWORD"),
+			De:       getenv("MAIL_FROM"),
+		}
+		if s.Host == "" || s.De == "" {
+			return nil, errors.New("MAIL_SENDER=smtp requiere SMTP_HOST y MAIL_FROM")
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("MAIL_SENDER desconocido: %q", getenv("MAIL_SENDER"))
+	}
+}
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileSender tests que cada correo quede en su propio archivo
+func TestFileSender(t *testing.T) {
+	dir := t.TempDir()
+	s := FileSender{Dir: dir}
+
+	for i := 0; i < 2; i++ {
+		err := s.Enviar(context.Background(), Mensaje{Para: "juan@correo.com", Asunto: "Hola", Cuerpo: "Cuerpo del mail"})
+		if err != nil {
+			t.Fatalf("Error enviando: %v", err)
+		}
+	}
+
+	archivos, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(archivos) != 2 {
+		t.Fatalf("Se esperaban 2 archivos, pero se obtuvieron %d", len(archivos))
+	}
+	contenido, _ := os.ReadFile(archivos[0])
+	for _, parte := range []string{"To: juan@correo.com", "Subject: Hola", "Cuerpo del mail"} {
+		if !strings.Contains(string(contenido), parte) {
+			t.Errorf("El archivo debería contener %q", parte)
+		}
+	}
+}
+
+// TestDesdeEntorno tests la elección del Sender por variables de entorno
+func TestDesdeEntorno(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+
+	if s, err := DesdeEntorno(env(nil)); err != nil || s == nil {
+		t.Errorf("Sin configuración se esperaba el LogSender, se obtuvo %v (%v)", s, err)
+	}
+	if s, _ := DesdeEntorno(env(map[string]string{"MAIL_SENDER": "file", "MAIL_DIR": "/tmp/x"})); s != (FileSender{Dir: "/tmp/x"}) {
+		t.Errorf("Se esperaba FileSender en /tmp/x, se obtuvo %v", s)
+	}
+	if _, err := DesdeEntorno(env(map[string]string{"MAIL_SENDER": "smtp"})); err == nil {
+		t.Error("SMTP sin host debería fallar")
+	}
+	if _, err := DesdeEntorno(env(map[string]string{"MAIL_SENDER": "paloma"})); err == nil {
+		t.Error("Un sender desconocido debería fallar")
+	}
+}
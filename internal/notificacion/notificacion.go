@@ -0,0 +1,35 @@
+// Package notificacion avisa a los clientes de cambios en sus turnos. Por
+// ahora sólo se registra en el log; un canal real (SMS, WhatsApp) se enchufa
+// implementando Notificador.
+package notificacion
+
+import (
+	"context"
+	"log"
+)
+
+// Aviso es un mensaje para el cliente de un turno
+type Aviso struct {
+	Telefono string
+	Nombre   string
+	Texto    string
+}
+
+// Notificador entrega un aviso. Las implementaciones deben ser seguras para uso concurrente.
+type Notificador interface {
+	Notificar(ctx context.Context, a Aviso) error
+}
+
+// LogNotificador escribe el aviso en el log
+type LogNotificador struct {
+	Logger *log.Logger
+}
+
+func (n LogNotificador) Notificar(_ context.Context, a Aviso) error {
+	logger := n.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("[aviso] Para: %s (%s)\n%s", a.Nombre, a.Telefono, a.Texto)
+	return nil
+}
@@ -0,0 +1,205 @@
+// Package telefono normaliza números de teléfono al formato E.164
+// (+5491112345678) para que el mismo número escrito de distintas formas se
+// guarde igual. Los números sin código internacional se interpretan según el
+// país de la barbería.
+package telefono
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// PaisPorDefecto es el de las barberías que no configuraron otro
+const PaisPorDefecto = "AR"
+
+var (
+	ErrInvalido        = errors.New("número de teléfono inválido")
+	ErrSinCodigoArea   = errors.New("falta el código de área")
+	ErrPaisNoSoportado = errors.New("país no soportado")
+)
+
+// plan es lo necesario para validar los números de un país
+type plan struct {
+	codigo  string // código internacional, sin el +
+	troncal string // prefijo que se marca antes del número nacional ("" si no tiene)
+	largos  []int  // largos válidos del número nacional, sin el troncal
+}
+
+// planes son los países soportados (ISO 3166-1 alfa-2). Argentina tiene
+// reglas propias por el 9 y el 15 de los celulares; ver nacionalAR.
+var planes = map[string]plan{
+	"AR": {codigo: "54", troncal: "0"},
+	"UY": {codigo: "598", troncal: "0", largos: []int{8}},
+	"CL": {codigo: "56", largos: []int{9}},
+	"BR": {codigo: "55", troncal: "0", largos: []int{10, 11}},
+	"MX": {codigo: "52", largos: []int{10}},
+	"ES": {codigo: "34", largos: []int{9}},
+	"US": {codigo: "1", troncal: "1", largos: []int{10}},
+}
+
+// codigos son los países por código internacional, del más largo al más
+// corto para que 598 gane sobre 5x
+var codigos = func() []string {
+	var cs []string
+	for pais := range planes {
+		cs = append(cs, pais)
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		a, b := planes[cs[i]].codigo, planes[cs[j]].codigo
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	return cs
+}()
+
+// Soportado indica si se pueden interpretar números nacionales del país
+func Soportado(pais string) bool {
+	_, ok := planes[pais]
+	return ok
+}
+
+// Paises son los países soportados, en orden alfabético
+func Paises() []string {
+	var ps []string
+	for pais := range planes {
+		ps = append(ps, pais)
+	}
+	sort.Strings(ps)
+	return ps
+}
+
+// Normalizar devuelve el número en E.164. Acepta espacios, guiones, puntos,
+// paréntesis y barras como separadores, y el código internacional con + o
+// con 00. Los números de países que no están en la tabla se aceptan si
+// vienen con código internacional y tienen un largo válido para E.164.
+func Normalizar(numero, pais string) (string, error) {
+	digitos, internacional, err := limpiar(numero)
+	if err != nil {
+		return "", err
+	}
+
+	if internacional {
+		for _, p := range codigos {
+			pl := planes[p]
+			if strings.HasPrefix(digitos, pl.codigo) {
+				return pl.nacional(p, digitos[len(pl.codigo):], true)
+			}
+		}
+		if len(digitos) < 8 || len(digitos) > 15 || digitos[0] == '0' {
+			return "", ErrInvalido
+		}
+		return "+" + digitos, nil
+	}
+
+	pl, ok := planes[pais]
+	if !ok {
+		return "", ErrPaisNoSoportado
+	}
+	if pl.troncal != "" {
+		digitos = strings.TrimPrefix(digitos, pl.troncal)
+	}
+	return pl.nacional(pais, digitos, false)
+}
+
+// limpiar saca los separadores y el prefijo internacional. Cualquier otro
+// carácter (letras, un + en el medio) hace inválido al número.
+func limpiar(numero string) (digitos string, internacional bool, err error) {
+	numero = strings.TrimSpace(numero)
+	if strings.HasPrefix(numero, "+") {
+		numero, internacional = numero[1:], true
+	}
+
+	var b strings.Builder
+	for _, c := range numero {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case strings.ContainsRune(" -.()/", c):
+		default:
+			return "", false, ErrInvalido
+		}
+	}
+	digitos = b.String()
+
+	if !internacional && strings.HasPrefix(digitos, "00") {
+		digitos, internacional = digitos[2:], true
+	}
+	if digitos == "" {
+		return "", false, ErrInvalido
+	}
+	return digitos, internacional, nil
+}
+
+// nacional valida el número nacional (sin código de país ni troncal) y arma el E.164
+func (pl plan) nacional(pais, n string, internacional bool) (string, error) {
+	if pais == "AR" {
+		return nacionalAR(n, internacional)
+	}
+	for _, largo := range pl.largos {
+		if len(n) == largo {
+			return "+" + pl.codigo + n, nil
+		}
+	}
+	return "", ErrInvalido
+}
+
+// nacionalAR arma el E.164 argentino. El número nacional tiene siempre 10
+// dígitos (código de área de 2 a 4 más el abonado). Los celulares se marcan
+// desde el país con 15 después del código de área y desde afuera con 9
+// antes; en E.164 va el 9 y no el 15.
+func nacionalAR(n string, internacional bool) (string, error) {
+	if internacional {
+		// +54 011 ... no es válido pero es un error común
+		n = strings.TrimPrefix(n, "0")
+	}
+
+	celular := false
+	if len(n) == 11 && n[0] == '9' {
+		n, celular = n[1:], true
+	} else if len(n) == 12 {
+		sin15, ok := quitar15(n)
+		if !ok {
+			return "", ErrInvalido
+		}
+		n, celular = sin15, true
+	}
+
+	switch {
+	case len(n) >= 6 && len(n) <= 8 && !internacional:
+		return "", ErrSinCodigoArea
+	case len(n) != 10:
+		return "", ErrInvalido
+	case !areaValidaAR(n):
+		return "", ErrInvalido
+	}
+
+	if celular {
+		return "+549" + n, nil
+	}
+	return "+54" + n, nil
+}
+
+// quitar15 saca el 15 que sigue al código de área de un celular. El área 11
+// (AMBA) es la única de 2 dígitos; las demás tienen 3 o 4 y se prueba primero
+// con 3.
+func quitar15(n string) (string, bool) {
+	largos := []int{3, 4}
+	if strings.HasPrefix(n, "11") {
+		largos = []int{2}
+	}
+	for _, l := range largos {
+		if n[l:l+2] == "15" {
+			return n[:l] + n[l+2:], true
+		}
+	}
+	return "", false
+}
+
+// areaValidaAR descarta números que no pueden ser argentinos: los códigos de
+// área empiezan con 11, 2 o 3
+func areaValidaAR(n string) bool {
+	return strings.HasPrefix(n, "11") || n[0] == '2' || n[0] == '3'
+}
@@ -0,0 +1,79 @@
+package telefono
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestNormalizar_Argentina tests las formas habituales de escribir un número argentino
+func TestNormalizar_Argentina(t *testing.T) {
+	casos := map[string]string{
+		"+54 9 11 1234-5678":   "+5491112345678",
+		"+54 11 15 1234 5678":  "+5491112345678",
+		"011 15 1234-5678":     "+5491112345678",
+		"11 15 1234 5678":      "+5491112345678",
+		"9 11 1234 5678":       "+5491112345678",
+		"0054 9 11 1234 5678":  "+5491112345678",
+		"(011) 4444-5555":      "+541144445555",
+		"+54 011 4444 5555":    "+541144445555",
+		"0351 15 123-4567":     "+5493511234567",
+		"0351 423-4567":        "+543514234567",
+		"02954 15 12-3456":     "+5492954123456",
+		" 11.4444.5555 ":       "+541144445555",
+		"+598 99 123 456":      "+59899123456",
+		"+1 (212) 555-0100":    "+12125550100",
+		"+49 30 12345678":      "+493012345678",
+		"+54 9 351 123 4567":   "+5493511234567",
+		"+5493511234567":       "+5493511234567",
+		"+54 (351) 15-1234567": "+5493511234567",
+	}
+	for entrada, want := range casos {
+		got, err := Normalizar(entrada, "AR")
+		if err != nil || got != want {
+			t.Errorf("Normalizar(%q) = %q, %v; se esperaba %q", entrada, got, err, want)
+		}
+	}
+}
+
+// TestNormalizar_Invalidos tests los rechazos y su motivo
+func TestNormalizar_Invalidos(t *testing.T) {
+	casos := []struct {
+		numero string
+		pais   string
+		err    error
+	}{
+		{"", "AR", ErrInvalido},
+		{"+", "AR", ErrInvalido},
+		{"11-4444-555a", "AR", ErrInvalido},
+		{"12+34", "AR", ErrInvalido},
+		{"4444-5555", "AR", ErrSinCodigoArea},
+		{"0800 555 1234 5", "AR", ErrInvalido},
+		{"5555 444 333", "AR", ErrInvalido},
+		{"+54 11 4444", "AR", ErrInvalido},
+		{"+0 1234 5678", "AR", ErrInvalido},
+		{"099 123 456", "ZZ", ErrPaisNoSoportado},
+		{"1234567", "UY", ErrInvalido},
+	}
+	for _, c := range casos {
+		if _, err := Normalizar(c.numero, c.pais); !errors.Is(err, c.err) {
+			t.Errorf("Normalizar(%q, %s): se esperaba %v, se obtuvo %v", c.numero, c.pais, c.err, err)
+		}
+	}
+}
+
+// TestNormalizar_OtrosPaises tests los números nacionales según el país de la barbería
+func TestNormalizar_OtrosPaises(t *testing.T) {
+	casos := []struct{ numero, pais, want string }{
+		{"099 123 456", "UY", "+59899123456"},
+		{"9 8765 4321", "CL", "+56987654321"},
+		{"(11) 98765-4321", "BR", "+5511987654321"},
+		{"612 34 56 78", "ES", "+34612345678"},
+		{"1 (212) 555-0100", "US", "+12125550100"},
+	}
+	for _, c := range casos {
+		got, err := Normalizar(c.numero, c.pais)
+		if err != nil || got != c.want {
+			t.Errorf("Normalizar(%q, %s) = %q, %v; se esperaba %q", c.numero, c.pais, got, err, c.want)
+		}
+	}
+}